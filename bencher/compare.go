@@ -0,0 +1,79 @@
+package bencher
+
+import (
+	"fmt"
+	"math"
+)
+
+// 比较结果的状态
+const (
+	Faster    = "faster"
+	Slower    = "slower"
+	Unchanged = "unchanged"
+	Added     = "new"
+	Removed   = "removed"
+)
+
+// Delta 是一个基准测试相对基线的变化
+type Delta struct {
+	Name     string
+	Baseline float64 // 基线的平均 real time（纳秒）
+	Current  float64 // 本次的平均 real time（纳秒）
+	Change   float64 // 相对变化, 例如 0.12 表示慢了 12%
+	Status   string
+}
+
+// Compare 按名称比较两组结果。变化的绝对值不小于 threshold (例如 0.05 表示 5%)
+// 且超过两次运行的标准差之和时才认为有显著差异, 否则记为 Unchanged。
+func Compare(baseline, current []Stat, threshold float64) []Delta {
+	base := make(map[string]Stat)
+	for _, stat := range baseline {
+		base[stat.Name] = stat
+	}
+
+	var (
+		deltas []Delta
+		seen   = make(map[string]bool)
+	)
+	for _, cur := range current {
+		seen[cur.Name] = true
+
+		old, ok := base[cur.Name]
+		if !ok || old.Mean == 0 {
+			deltas = append(deltas, Delta{Name: cur.Name, Current: cur.Mean, Status: Added})
+			continue
+		}
+
+		delta := Delta{Name: cur.Name, Baseline: old.Mean, Current: cur.Mean, Change: (cur.Mean - old.Mean) / old.Mean, Status: Unchanged}
+		noise := old.StdDev + cur.StdDev
+		if math.Abs(delta.Change) >= threshold && math.Abs(cur.Mean-old.Mean) > noise {
+			if delta.Change > 0 {
+				delta.Status = Slower
+			} else {
+				delta.Status = Faster
+			}
+		}
+		deltas = append(deltas, delta)
+	}
+
+	for _, old := range baseline {
+		if !seen[old.Name] {
+			deltas = append(deltas, Delta{Name: old.Name, Baseline: old.Mean, Status: Removed})
+		}
+	}
+
+	return deltas
+}
+
+// FormatTime 以合适的单位格式化纳秒时间
+func FormatTime(ns float64) string {
+	switch {
+	case ns >= 1e9:
+		return fmt.Sprintf("%.3f s", ns/1e9)
+	case ns >= 1e6:
+		return fmt.Sprintf("%.3f ms", ns/1e6)
+	case ns >= 1e3:
+		return fmt.Sprintf("%.3f us", ns/1e3)
+	}
+	return fmt.Sprintf("%.1f ns", ns)
+}
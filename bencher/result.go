@@ -0,0 +1,180 @@
+package bencher
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// Result 是 Google Benchmark JSON 输出中的一项结果
+type Result struct {
+	Name          string  `json:"name"`
+	RunName       string  `json:"run_name,omitempty"`
+	RunType       string  `json:"run_type,omitempty"` // iteration 或 aggregate
+	AggregateName string  `json:"aggregate_name,omitempty"`
+	Repetitions   int     `json:"repetitions,omitempty"`
+	Iterations    int64   `json:"iterations"`
+	RealTime      float64 `json:"real_time"`
+	CPUTime       float64 `json:"cpu_time"`
+	TimeUnit      string  `json:"time_unit"`
+	Binary        string  `json:"binary,omitempty"` // 所属的基准测试程序, 由 cgear 添加
+}
+
+// Report 是 Google Benchmark 的 JSON 报告
+type Report struct {
+	Context    map[string]interface{} `json:"context,omitempty"`
+	Benchmarks []Result               `json:"benchmarks"`
+}
+
+// Parse 解析 --benchmark_format=json 的输出
+func Parse(r io.Reader) (*Report, error) {
+	report := &Report{}
+	if err := json.NewDecoder(r).Decode(report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// ParseFile 读取 JSON 报告文件
+func ParseFile(path string) (*Report, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Parse(file)
+}
+
+// Merge 把多个基准测试程序的报告合并为一个, 保留第一个报告的上下文
+func Merge(reports ...*Report) *Report {
+	merged := &Report{}
+	for _, report := range reports {
+		if merged.Context == nil {
+			merged.Context = report.Context
+		}
+		merged.Benchmarks = append(merged.Benchmarks, report.Benchmarks...)
+	}
+	return merged
+}
+
+// Save 把报告写入 path, 写出的文件可以作为 --compare 的基线
+func (r *Report) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Stat 是一个基准测试在所有重复运行中的统计
+type Stat struct {
+	Name       string
+	Binary     string
+	Mean       float64 // 平均 real time（纳秒）
+	StdDev     float64 // 标准差（纳秒）, 只运行一次时为 0
+	CPU        float64 // 平均 cpu time（纳秒）
+	Iterations int64
+	Samples    int
+}
+
+// Stats 按基准测试名汇总结果。
+// 有逐次运行的结果时据此计算平均值和标准差, 否则使用 mean 和 stddev 聚合结果。
+func (r *Report) Stats() []Stat {
+	var (
+		names      []string
+		samples    = make(map[string][]Result)
+		aggregates = make(map[string]map[string]Result)
+	)
+
+	for _, result := range r.Benchmarks {
+		name := result.RunName
+		if name == "" {
+			name = result.Name
+		}
+
+		if _, ok := samples[name]; !ok {
+			if _, ok := aggregates[name]; !ok {
+				names = append(names, name)
+			}
+		}
+
+		if result.RunType == "aggregate" {
+			if aggregates[name] == nil {
+				aggregates[name] = make(map[string]Result)
+			}
+			aggregates[name][result.AggregateName] = result
+			continue
+		}
+		samples[name] = append(samples[name], result)
+	}
+
+	var stats []Stat
+	for _, name := range names {
+		stat := Stat{Name: name}
+
+		if runs := samples[name]; len(runs) > 0 {
+			var real, cpu []float64
+			for _, run := range runs {
+				real = append(real, toNanoseconds(run.RealTime, run.TimeUnit))
+				cpu = append(cpu, toNanoseconds(run.CPUTime, run.TimeUnit))
+				stat.Iterations += run.Iterations
+			}
+			stat.Binary = runs[0].Binary
+			stat.Mean, stat.StdDev = meanStdDev(real)
+			stat.CPU, _ = meanStdDev(cpu)
+			stat.Samples = len(runs)
+		} else {
+			mean := aggregates[name]["mean"]
+			stddev := aggregates[name]["stddev"]
+			stat.Binary = mean.Binary
+			stat.Mean = toNanoseconds(mean.RealTime, mean.TimeUnit)
+			stat.StdDev = toNanoseconds(stddev.RealTime, stddev.TimeUnit)
+			stat.CPU = toNanoseconds(mean.CPUTime, mean.TimeUnit)
+			stat.Iterations = mean.Iterations
+			stat.Samples = mean.Repetitions
+		}
+
+		stats = append(stats, stat)
+	}
+
+	return stats
+}
+
+func toNanoseconds(value float64, unit string) float64 {
+	switch unit {
+	case "us":
+		return value * 1e3
+	case "ms":
+		return value * 1e6
+	case "s":
+		return value * 1e9
+	}
+	return value
+}
+
+func meanStdDev(values []float64) (mean, stddev float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	if len(values) < 2 {
+		return mean, 0
+	}
+
+	for _, v := range values {
+		stddev += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(stddev / float64(len(values)-1))
+}
@@ -0,0 +1,134 @@
+package bisector
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// git bisect run 使用的退出码
+const (
+	Good = 0   // 提交是好的
+	Bad  = 1   // 提交是坏的
+	Skip = 125 // 无法测试该提交, 例如构建失败
+)
+
+// Result 是一次二分查找的结果
+type Result struct {
+	FirstBad   string   // 第一个坏提交
+	Candidates []string // 只剩被跳过的提交时, 第一个坏提交可能是其中任意一个
+}
+
+// Commit 是提交的摘要信息
+type Commit struct {
+	SHA     string
+	Author  string
+	Date    string
+	Subject string
+}
+
+var (
+	firstBadPattern = regexp.MustCompile(`^([0-9a-f]{40}) is the first bad commit`)
+	shaPattern      = regexp.MustCompile(`^[0-9a-f]{40}$`)
+)
+
+// Run 在 dir 仓库中从 good 和 bad 开始运行 git bisect run, 每一步执行 command。
+// git 的输出同时写入 output。结束后总是执行 git bisect reset 恢复原来的分支。
+func Run(dir, good, bad string, command []string, output io.Writer) (*Result, error) {
+	status, err := git(dir, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(status) != "" {
+		return nil, errors.New("the working tree has uncommitted changes, commit or stash them first")
+	}
+
+	if _, err := git(dir, "bisect", "start", bad, good); err != nil {
+		return nil, err
+	}
+	defer git(dir, "bisect", "reset")
+
+	var log bytes.Buffer
+	cmd := exec.Command("git", append([]string{"bisect", "run"}, command...)...)
+	cmd.Dir = dir
+	cmd.Stdout = io.MultiWriter(output, &log)
+	cmd.Stderr = io.MultiWriter(output, &log)
+	runErr := cmd.Run()
+
+	result := parse(log.String())
+	if result.FirstBad == "" && len(result.Candidates) == 0 {
+		if runErr != nil {
+			return nil, fmt.Errorf("git bisect run failed: %w", runErr)
+		}
+		return nil, errors.New("git bisect run did not report the first bad commit")
+	}
+	return result, nil
+}
+
+// parse 从 git bisect run 的输出中找出第一个坏提交
+func parse(log string) *Result {
+	result := &Result{}
+	candidates := false
+
+	scanner := bufio.NewScanner(strings.NewReader(log))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case firstBadPattern.MatchString(line):
+			result.FirstBad = firstBadPattern.FindStringSubmatch(line)[1]
+		case strings.HasPrefix(line, "The first bad commit could be any of"):
+			candidates = true
+		case candidates && shaPattern.MatchString(line):
+			result.Candidates = append(result.Candidates, line)
+		case candidates:
+			candidates = false
+		}
+	}
+	return result
+}
+
+// Describe 返回提交的摘要信息
+func Describe(dir, rev string) (*Commit, error) {
+	output, err := git(dir, "show", "-s", "--date=short", "--format=%H%x00%an <%ae>%x00%ad%x00%s", rev)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.SplitN(strings.TrimSpace(output), "\x00", 4)
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("unexpected output of git show: %q", output)
+	}
+	return &Commit{SHA: fields[0], Author: fields[1], Date: fields[2], Subject: fields[3]}, nil
+}
+
+// ExitCode 把一步测试的结果转换为 git bisect run 的退出码。
+// 程序被信号终止或退出码超过 127 时 git bisect 会中止, 这里把它们看作坏提交。
+func ExitCode(err error) int {
+	if err == nil {
+		return Good
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return Skip
+	}
+	if code := exitErr.ExitCode(); code > 0 && code < 128 {
+		return code
+	}
+	return Bad
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return string(output), err
+}
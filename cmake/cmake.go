@@ -2,9 +2,11 @@ package cmake
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/zelviner/cgear/config"
@@ -24,14 +26,21 @@ type ConfigArg struct {
 	CXXFlags              string            // C++ 编译参数
 	NoWarnUnusedCli       bool              // 不警告在命令行声明但未使用的变量
 	ExportCompileCommands bool              // 导出编译命令
+	CodemodelQuery        bool              // 配置前写入 File API 的 codemodel 查询
+	InstallPrefix         string            // 安装路径 (CMAKE_INSTALL_PREFIX)
+	OutputPath            string            // 不为空时所有目标生成到 OutputPath/bin 和 OutputPath/lib, 代替项目设置的输出目录
+	CacheVariables        map[string]string // 其他 CMake 缓存变量
 }
 
 // cmake 构建命令参数
 type BuildArg struct {
-	BuildPath string // 构建路径
-	Target    string // 构建目标
-	BuildType string // 构建类型
-	IsMSVC    bool   // 是否为 MSVC 工具链
+	BuildPath string   // 构建路径
+	Target    string   // 构建目标, MSVC 工具链忽略
+	Targets   []string // 只构建这些目标及其依赖, 对所有工具链有效, 需要 CMake 3.15 及以上版本
+	BuildType string   // 构建类型
+	IsMSVC    bool     // 是否为 MSVC 工具链
+
+	Output io.Writer // 配置和构建的输出, 不为空时代替标准输出, 例如日志文件
 }
 
 var (
@@ -96,6 +105,14 @@ func Run(configArg *ConfigArg, buildArg *BuildArg, target string, rebuild bool)
 }
 
 func Build(configArg *ConfigArg, buildArg *BuildArg, rebuild bool, showInfo bool) error {
+	if err := Configure(configArg, buildArg.Output, rebuild, showInfo); err != nil {
+		return err
+	}
+	return Compile(buildArg, showInfo)
+}
+
+// Configure 配置构建目录, rebuild 时先删除旧的构建目录。输出写入 output, 为 nil 时由 showInfo 决定是否显示
+func Configure(configArg *ConfigArg, output io.Writer, rebuild bool, showInfo bool) error {
 	// 初始化 Toolchain
 	if configArg.Toolchain == nil {
 		env.SetToolchain()
@@ -114,9 +131,25 @@ func Build(configArg *ConfigArg, buildArg *BuildArg, rebuild bool, showInfo bool
 		}
 	}
 
+	if configArg.CodemodelQuery {
+		if err := WriteCodemodelQuery(configArg.BuildPath); err != nil {
+			return fmt.Errorf("failed to write CMake File API query: %w", err)
+		}
+	}
+
+	if configArg.OutputPath != "" {
+		if err := writeOutputScript(configArg.BuildPath); err != nil {
+			return fmt.Errorf("failed to write the output directory script: %w", err)
+		}
+	}
+
 	// 配置 CMake
 	cmakeCmd := exec.Command("cmake", configArg.toStringSlice()...)
-	if showInfo {
+	if output != nil {
+		fmt.Fprintf(output, "Running '%s'\n", cmakeCmd.String())
+		cmakeCmd.Stdout = output
+		cmakeCmd.Stderr = output
+	} else if showInfo {
 		logger.Log.Infof("Running '%s'", cmakeCmd.String())
 		cmakeCmd.Stdout = os.Stdout
 		cmakeCmd.Stderr = os.Stderr
@@ -126,9 +159,17 @@ func Build(configArg *ConfigArg, buildArg *BuildArg, rebuild bool, showInfo bool
 		return fmt.Errorf("cmake configure failed: %w", err)
 	}
 
-	// 构建 CMake
+	return nil
+}
+
+// Compile 构建已配置的构建目录
+func Compile(buildArg *BuildArg, showInfo bool) error {
 	buildCmd := exec.Command("cmake", buildArg.toStringSlice()...)
-	if showInfo {
+	if buildArg.Output != nil {
+		fmt.Fprintf(buildArg.Output, "Running CMake build: %s\n", strings.Join(buildCmd.Args, " "))
+		buildCmd.Stdout = buildArg.Output
+		buildCmd.Stderr = buildArg.Output
+	} else if showInfo {
 		logger.Log.Infof("Running CMake build: %s", strings.Join(buildCmd.Args, " "))
 		buildCmd.Stdout = os.Stdout
 		buildCmd.Stderr = os.Stderr
@@ -141,6 +182,31 @@ func Build(configArg *ConfigArg, buildArg *BuildArg, rebuild bool, showInfo bool
 	return nil
 }
 
+// Install 把构建目录中已构建的文件安装到 CMAKE_INSTALL_PREFIX, component 为空时安装全部组件。
+// 输出写入 output, 为 nil 时丢弃。
+func Install(buildPath, buildType, component string, output io.Writer) error {
+	args := []string{"--install", buildPath}
+	if buildType != "" {
+		args = append(args, "--config", buildType)
+	}
+	if component != "" {
+		args = append(args, "--component", component)
+	}
+
+	installCmd := exec.Command("cmake", args...)
+	if output != nil {
+		fmt.Fprintf(output, "Running CMake install: %s\n", strings.Join(installCmd.Args, " "))
+		installCmd.Stdout = output
+		installCmd.Stderr = output
+	}
+
+	if err := installCmd.Run(); err != nil {
+		return fmt.Errorf("cmake install failed: %w", err)
+	}
+
+	return nil
+}
+
 func (c *ConfigArg) toStringSlice() []string {
 	var result []string
 
@@ -197,6 +263,23 @@ func (c *ConfigArg) toStringSlice() []string {
 		result = append(result, "-DCMAKE_EXPORT_COMPILE_COMMANDS:BOOL=TRUE")
 	}
 
+	if c.InstallPrefix != "" {
+		result = append(result, "-DCMAKE_INSTALL_PREFIX="+c.InstallPrefix)
+	}
+
+	if c.OutputPath != "" {
+		result = append(result, "-DCMAKE_PROJECT_INCLUDE="+outputScriptPath(c.BuildPath), "-DCGEAR_OUTPUT_DIRECTORY="+filepath.ToSlash(c.OutputPath))
+	}
+
+	names := make([]string, 0, len(c.CacheVariables))
+	for name := range c.CacheVariables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result = append(result, "-D"+name+"="+c.CacheVariables[name])
+	}
+
 	if c.ProjectPath != "" {
 		result = append(result, "-S"+c.ProjectPath)
 	}
@@ -228,6 +311,11 @@ func (b *BuildArg) toStringSlice() []string {
 		}
 	}
 
+	if len(b.Targets) > 0 {
+		result = append(result, "--target")
+		result = append(result, b.Targets...)
+	}
+
 	result = append(result, "--")
 
 	return result
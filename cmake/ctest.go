@@ -0,0 +1,79 @@
+package cmake
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zelviner/cgear/logger"
+)
+
+// ctest 运行命令参数
+type TestArg struct {
+	BuildPath   string        // 构建目录
+	BuildType   string        // 构建类型
+	IsMSVC      bool          // 是否为 MSVC 工具链
+	Regex       string        // 只运行名称匹配该正则的测试 (-R)
+	Label       string        // 只运行带有该标签的测试 (-L)
+	Jobs        int           // 并行数 (-j)
+	Timeout     time.Duration // 单个测试的超时时间 (--timeout)
+	Repeat      string        // 重复运行模式, 例如 until-fail:3 (--repeat)
+	OutputJUnit string        // JUnit 结果文件路径 (--output-junit)
+}
+
+// Test 在构建目录中运行 ctest
+func Test(testArg *TestArg, showInfo bool) error {
+	ctestCmd := exec.Command("ctest", testArg.toStringSlice()...)
+	ctestCmd.Dir = testArg.BuildPath
+	if showInfo {
+		logger.Log.Infof("Running CTest: %s", strings.Join(ctestCmd.Args, " "))
+		ctestCmd.Stdout = os.Stdout
+		ctestCmd.Stderr = os.Stderr
+	}
+
+	if err := ctestCmd.Run(); err != nil {
+		return fmt.Errorf("ctest failed: %w", err)
+	}
+
+	return nil
+}
+
+func (t *TestArg) toStringSlice() []string {
+	var result []string
+
+	if t.IsMSVC && t.BuildType != "" {
+		result = append(result, "-C", t.BuildType)
+	}
+
+	if t.Regex != "" {
+		result = append(result, "-R", t.Regex)
+	}
+
+	if t.Label != "" {
+		result = append(result, "-L", t.Label)
+	}
+
+	if t.Jobs > 0 {
+		result = append(result, "-j", strconv.Itoa(t.Jobs))
+	}
+
+	if t.Timeout > 0 {
+		result = append(result, "--timeout", strconv.Itoa(int(math.Ceil(t.Timeout.Seconds()))))
+	}
+
+	if t.Repeat != "" {
+		result = append(result, "--repeat", t.Repeat)
+	}
+
+	if t.OutputJUnit != "" {
+		result = append(result, "--output-junit", t.OutputJUnit)
+	}
+
+	result = append(result, "--output-on-failure")
+
+	return result
+}
@@ -0,0 +1,113 @@
+package cmake
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// 目标文件路径中的 CMakeFiles/<target>.dir/ 部分
+var targetDirPattern = regexp.MustCompile(`(?:^|[/\\])CMakeFiles[/\\]([^/\\]+)\.dir[/\\]`)
+
+// ReadDepfiles 读取构建目录中编译器生成的依赖信息, 返回每个目标依赖的文件 (主要是头文件)。
+// Ninja 生成器的依赖保存在 .ninja_deps 中, 通过 ninja -t deps 读取;
+// Makefile 生成器的依赖保存在各目标目录的 *.d 和 compiler_depend.make 中。
+// MSVC 的 Visual Studio 生成器不产生依赖文件, 此时只能按源文件匹配。
+func ReadDepfiles(buildPath string) map[string][]string {
+	deps := make(map[string][]string)
+
+	if _, err := os.Stat(filepath.Join(buildPath, "build.ninja")); err == nil {
+		cmd := exec.Command("ninja", "-t", "deps")
+		cmd.Dir = buildPath
+		if output, err := cmd.Output(); err == nil {
+			for target, files := range ParseNinjaDeps(output, buildPath) {
+				deps[target] = append(deps[target], files...)
+			}
+		}
+	}
+
+	filepath.Walk(buildPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if filepath.Ext(path) != ".d" && info.Name() != "compiler_depend.make" {
+			return nil
+		}
+
+		loc := targetDirPattern.FindStringSubmatchIndex(path)
+		if loc == nil {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+
+		// 相对路径以目标所在的构建子目录为基准
+		target := path[loc[2]:loc[3]]
+		deps[target] = append(deps[target], ParseDepfile(data, path[:loc[0]])...)
+		return nil
+	})
+
+	return deps
+}
+
+// ParseDepfile 解析 Makefile 格式的依赖文件, 返回所有规则中的依赖, 相对路径以 dir 为基准
+func ParseDepfile(data []byte, dir string) []string {
+	// 合并续行, 暂存转义的空格
+	text := strings.ReplaceAll(string(data), "\\\r\n", " ")
+	text = strings.ReplaceAll(text, "\\\n", " ")
+	text = strings.ReplaceAll(text, "\\ ", "\x00")
+
+	var files []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// 规则目标可能是 Windows 路径, 以冒号加空格作为分隔
+		index := strings.Index(line, ": ")
+		if index == -1 {
+			continue
+		}
+		for _, file := range strings.Fields(line[index+2:]) {
+			files = append(files, absPath(dir, strings.ReplaceAll(file, "\x00", " ")))
+		}
+	}
+	return files
+}
+
+// ParseNinjaDeps 解析 ninja -t deps 的输出, 按目标返回依赖的文件, 相对路径以 buildPath 为基准
+//
+//	CMakeFiles/app.dir/src/main.cpp.o: #deps 2, deps mtime 1700000000 (VALID)
+//	    ../src/main.cpp
+//	    ../src/util.h
+func ParseNinjaDeps(data []byte, buildPath string) map[string][]string {
+	deps := make(map[string][]string)
+
+	var target string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.TrimSpace(line) == "":
+			target = ""
+		case line[0] != ' ' && line[0] != '\t':
+			target = ""
+			if index := strings.Index(line, ": #deps"); index != -1 {
+				if match := targetDirPattern.FindStringSubmatch(line[:index]); match != nil {
+					target = match[1]
+				}
+			}
+		case target != "":
+			deps[target] = append(deps[target], absPath(buildPath, strings.TrimSpace(line)))
+		}
+	}
+	return deps
+}
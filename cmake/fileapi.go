@@ -0,0 +1,199 @@
+package cmake
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// File API 客户端名称, 查询文件位于 .cmake/api/v1/query/client-cgear
+const fileAPIClient = "client-cgear"
+
+// Target 是 codemodel 中的一个构建目标
+type Target struct {
+	Name         string   // 目标名称
+	Type         string   // EXECUTABLE、STATIC_LIBRARY、SHARED_LIBRARY 等
+	Sources      []string // 源文件的绝对路径, 合并依赖文件后也包括头文件
+	Dependencies []string // 直接依赖的目标名称
+	Artifacts    []string // 生成文件的绝对路径
+}
+
+// WriteCodemodelQuery 在构建目录中写入 codemodel 查询, 之后每次配置时 CMake 都会生成 codemodel
+func WriteCodemodelQuery(buildPath string) error {
+	queryPath := filepath.Join(buildPath, ".cmake", "api", "v1", "query", fileAPIClient)
+	if err := os.MkdirAll(queryPath, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(queryPath, "codemodel-v2"), nil, 0644)
+}
+
+// ReadCodemodel 读取构建目录中最新的 codemodel 回复, buildType 选择多配置生成器中的配置
+func ReadCodemodel(buildPath, buildType string) ([]Target, error) {
+	replyPath := filepath.Join(buildPath, ".cmake", "api", "v1", "reply")
+	indexes, _ := filepath.Glob(filepath.Join(replyPath, "index-*.json"))
+	if len(indexes) == 0 {
+		return nil, fmt.Errorf("no CMake File API reply in %s, CMake 3.14 or newer is required", replyPath)
+	}
+	// 索引文件名中包含时间戳, 按名称排序后最后一个为最新
+	sort.Strings(indexes)
+
+	var index struct {
+		Objects []struct {
+			Kind     string `json:"kind"`
+			JSONFile string `json:"jsonFile"`
+		} `json:"objects"`
+	}
+	if err := readJSON(indexes[len(indexes)-1], &index); err != nil {
+		return nil, err
+	}
+
+	var codemodelFile string
+	for _, object := range index.Objects {
+		if object.Kind == "codemodel" {
+			codemodelFile = object.JSONFile
+		}
+	}
+	if codemodelFile == "" {
+		return nil, fmt.Errorf("no codemodel in the CMake File API reply")
+	}
+
+	var codemodel struct {
+		Paths struct {
+			Source string `json:"source"`
+			Build  string `json:"build"`
+		} `json:"paths"`
+		Configurations []struct {
+			Name    string `json:"name"`
+			Targets []struct {
+				ID       string `json:"id"`
+				JSONFile string `json:"jsonFile"`
+			} `json:"targets"`
+		} `json:"configurations"`
+	}
+	if err := readJSON(filepath.Join(replyPath, codemodelFile), &codemodel); err != nil {
+		return nil, err
+	}
+	if len(codemodel.Configurations) == 0 {
+		return nil, fmt.Errorf("no configurations in the codemodel")
+	}
+
+	configuration := codemodel.Configurations[0]
+	for _, c := range codemodel.Configurations {
+		if strings.EqualFold(c.Name, buildType) {
+			configuration = c
+		}
+	}
+
+	var target struct {
+		Name      string `json:"name"`
+		Type      string `json:"type"`
+		Artifacts []struct {
+			Path string `json:"path"`
+		} `json:"artifacts"`
+		Sources []struct {
+			Path string `json:"path"`
+		} `json:"sources"`
+		Dependencies []struct {
+			ID string `json:"id"`
+		} `json:"dependencies"`
+	}
+
+	// 依赖以目标 id 给出, 先建立 id 到名称的映射
+	var (
+		targets = make([]Target, len(configuration.Targets))
+		names   = make(map[string]string)
+		deps    = make([][]string, len(configuration.Targets))
+	)
+	for i, t := range configuration.Targets {
+		target.Artifacts, target.Sources, target.Dependencies = nil, nil, nil
+		if err := readJSON(filepath.Join(replyPath, t.JSONFile), &target); err != nil {
+			return nil, err
+		}
+
+		targets[i] = Target{Name: target.Name, Type: target.Type}
+		names[t.ID] = target.Name
+		for _, artifact := range target.Artifacts {
+			targets[i].Artifacts = append(targets[i].Artifacts, absPath(codemodel.Paths.Build, artifact.Path))
+		}
+		for _, source := range target.Sources {
+			targets[i].Sources = append(targets[i].Sources, absPath(codemodel.Paths.Source, source.Path))
+		}
+		for _, dep := range target.Dependencies {
+			deps[i] = append(deps[i], dep.ID)
+		}
+	}
+
+	for i := range targets {
+		for _, id := range deps[i] {
+			targets[i].Dependencies = append(targets[i].Dependencies, names[id])
+		}
+	}
+
+	return targets, nil
+}
+
+// Affected 返回受 changed 中文件影响的目标: 包含这些文件的目标, 以及直接或间接依赖它们的目标
+func Affected(targets []Target, changed []string) []Target {
+	changedFiles := make(map[string]bool)
+	for _, file := range changed {
+		changedFiles[filepath.Clean(file)] = true
+	}
+
+	affected := make(map[string]bool)
+	for _, target := range targets {
+		for _, source := range target.Sources {
+			if changedFiles[filepath.Clean(source)] {
+				affected[target.Name] = true
+				break
+			}
+		}
+	}
+
+	// 沿依赖关系反向传播, 直到不再有新的目标受影响
+	for grown := true; grown; {
+		grown = false
+		for _, target := range targets {
+			if affected[target.Name] {
+				continue
+			}
+			for _, dep := range target.Dependencies {
+				if affected[dep] {
+					affected[target.Name] = true
+					grown = true
+					break
+				}
+			}
+		}
+	}
+
+	var result []Target
+	for _, target := range targets {
+		if affected[target.Name] {
+			result = append(result, target)
+		}
+	}
+	return result
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// absPath 把相对 dir 的路径转换为绝对路径
+func absPath(dir, path string) string {
+	path = filepath.FromSlash(path)
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(filepath.FromSlash(dir), path)
+}
@@ -0,0 +1,368 @@
+package cmake
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ListsTarget 是 CMakeLists.txt 中以 add_executable、add_library 或 cgear new 生成的辅助函数定义的目标
+type ListsTarget struct {
+	Name string // 展开变量后的目标名
+	Ref  string // 文件中写的目标名, 例如 ${APP_NAME}
+	Path string // 定义目标的 CMakeLists.txt
+	Kind string // executable、library 或 interface
+}
+
+// Link 是 cgear add 写入 CMakeLists.txt 的一个依赖
+type Link struct {
+	Name     string    // 依赖名, 写在每一行末尾的注释中, 删除时据此查找
+	Packages []Package // 需要 find_package 的包
+	Targets  []string  // 链接的导入目标
+}
+
+// cgear new 生成的测试、基准测试和模糊测试的 CMakeLists.txt 中定义目标的函数,
+// 例如 add_integration_test(demo) 定义可执行目标 demo_test
+var helperSuffixes = map[string]string{
+	"add_integration_test": "_test",
+	"add_benchmark":        "_bench",
+	"add_fuzz_target":      "_fuzz",
+}
+
+// definedTarget 返回命令定义的目标在文件中写的名称和类型, 不定义目标时返回 false
+func definedTarget(cmd command) (ref, kind string, ok bool) {
+	if len(cmd.args) == 0 {
+		return "", "", false
+	}
+	if suffix, helper := helperSuffixes[cmd.name]; helper {
+		return cmd.args[0] + suffix, "executable", true
+	}
+	if cmd.name != "add_executable" && cmd.name != "add_library" {
+		return "", "", false
+	}
+
+	kind = "executable"
+	if cmd.name == "add_library" {
+		kind = "library"
+	}
+	for _, arg := range cmd.args[1:] {
+		switch arg {
+		case "ALIAS", "IMPORTED":
+			return "", "", false
+		case "INTERFACE":
+			kind = "interface"
+		}
+	}
+	return cmd.args[0], kind, true
+}
+
+// linkMarker 返回标记 cgear add 写入的行的注释
+func linkMarker(name string) string {
+	return "# cgear: " + name
+}
+
+// command 是 CMake 脚本中的一条命令
+type command struct {
+	name       string   // 小写的命令名
+	args       []string // 参数, 不含引号
+	start, end int      // 命令在文件中的字节范围
+}
+
+// parseCommands 解析脚本中的命令, 跳过注释。不支持方括号参数和方括号注释。
+func parseCommands(content string) []command {
+	var commands []command
+	for i := 0; i < len(content); {
+		switch c := content[i]; {
+		case c == '#':
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+		case c == '_' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z':
+			j := i
+			for j < len(content) && (content[j] == '_' || content[j] >= 'A' && content[j] <= 'Z' || content[j] >= 'a' && content[j] <= 'z' || content[j] >= '0' && content[j] <= '9') {
+				j++
+			}
+			k := j
+			for k < len(content) && (content[k] == ' ' || content[k] == '\t') {
+				k++
+			}
+			if k < len(content) && content[k] == '(' {
+				args, end := parseArguments(content, k+1)
+				commands = append(commands, command{name: strings.ToLower(content[i:j]), args: args, start: i, end: end})
+				i = end
+			} else {
+				i = j
+			}
+		default:
+			i++
+		}
+	}
+	return commands
+}
+
+// parseArguments 从 i 开始解析命令的参数直到匹配的右括号, 返回参数和右括号之后的位置
+func parseArguments(content string, i int) ([]string, int) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	flush := func() {
+		if inArg {
+			args = append(args, arg.String())
+			arg.Reset()
+			inArg = false
+		}
+	}
+
+	for depth := 1; i < len(content); i++ {
+		switch c := content[i]; c {
+		case '"':
+			inArg = true
+			for i++; i < len(content) && content[i] != '"'; i++ {
+				if content[i] == '\\' && i+1 < len(content) {
+					i++
+				}
+				arg.WriteByte(content[i])
+			}
+		case '#':
+			flush()
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+		case ' ', '\t', '\r', '\n':
+			flush()
+		case '(':
+			flush()
+			depth++
+		case ')':
+			flush()
+			if depth--; depth == 0 {
+				return args, i + 1
+			}
+		default:
+			inArg = true
+			arg.WriteByte(c)
+		}
+	}
+	flush()
+	return args, len(content)
+}
+
+// expand 展开 value 中已知的变量, 仍有未知变量时返回 false
+func expand(value string, variables map[string]string) (string, bool) {
+	for name, v := range variables {
+		value = strings.ReplaceAll(value, "${"+name+"}", v)
+	}
+	return value, !strings.Contains(value, "${")
+}
+
+// walkLists 对 projectPath 下的每个 CMakeLists.txt 调用 fn, 跳过隐藏目录、构建目录和 vendor
+func walkLists(projectPath string, fn func(path string) error) error {
+	return filepath.Walk(projectPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			name := info.Name()
+			if path != projectPath && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "build") || name == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Name() != "CMakeLists.txt" {
+			return nil
+		}
+		return fn(path)
+	})
+}
+
+// FindListsTargets 返回 projectPath 下所有 CMakeLists.txt 中定义的目标, 包括 add_integration_test 等辅助函数定义的目标。
+// 目标名中的变量以同一文件和上级目录中的 set 展开, 不能展开的目标和 ALIAS、IMPORTED 目标不返回。
+func FindListsTargets(projectPath string) ([]ListsTarget, error) {
+	var targets []ListsTarget
+	err := walkLists(projectPath, func(path string) error {
+		// 上级目录中 set 的变量在子目录中也可见
+		variables := make(map[string]string)
+		for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+			if data, err := os.ReadFile(filepath.Join(dir, "CMakeLists.txt")); err == nil {
+				for _, cmd := range parseCommands(string(data)) {
+					if cmd.name != "set" || len(cmd.args) != 2 {
+						continue
+					}
+					if _, ok := variables[cmd.args[0]]; !ok {
+						variables[cmd.args[0]] = cmd.args[1]
+					}
+				}
+			}
+			if dir == projectPath || dir == filepath.Dir(dir) {
+				break
+			}
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, cmd := range parseCommands(string(data)) {
+			ref, kind, ok := definedTarget(cmd)
+			if !ok {
+				continue
+			}
+			if name, ok := expand(ref, variables); ok {
+				targets = append(targets, ListsTarget{Name: name, Ref: ref, Path: path, Kind: kind})
+			}
+		}
+		return nil
+	})
+	return targets, err
+}
+
+// AddLink 在定义 target 的 CMakeLists.txt 中加入 link 的 find_package 和 target_link_libraries。
+// find_package 写在已有的 find_package 之后, 没有时写在目标的定义之前;
+// target_link_libraries 写在该目标已有的 target_link_libraries 之后, 没有时写在目标的定义之后,
+// 辅助函数定义的目标写在函数调用之后。
+// 已经找到的包和已经链接的目标不重复写入。每一行以注释标记, 之前为同一依赖写入的行会被替换。
+func AddLink(target ListsTarget, link Link) error {
+	data, err := os.ReadFile(target.Path)
+	if err != nil {
+		return err
+	}
+	content := removeMarked(string(data), linkMarker(link.Name))
+	newline := "\n"
+	if strings.Contains(content, "\r\n") {
+		newline = "\r\n"
+	}
+
+	commands := parseCommands(content)
+	var definition *command
+	found := make(map[string]bool)
+	linked := make(map[string]bool)
+	lastFind, lastLink := -1, -1
+	for i, cmd := range commands {
+		switch {
+		case cmd.name == "find_package" && len(cmd.args) > 0:
+			found[cmd.args[0]] = true
+			if definition == nil {
+				lastFind = i
+			}
+		case definition == nil && defines(cmd, target.Ref):
+			definition = &commands[i]
+		case cmd.name == "target_link_libraries" && len(cmd.args) > 0 && cmd.args[0] == target.Ref:
+			for _, arg := range cmd.args[1:] {
+				linked[arg] = true
+			}
+			lastLink = i
+		}
+	}
+	if definition == nil {
+		return fmt.Errorf("target '%s' is not defined in %s", target.Name, target.Path)
+	}
+
+	marker := " " + linkMarker(link.Name) + newline
+	var finds, links string
+	for _, pkg := range link.Packages {
+		if !found[pkg.Name] {
+			finds += "find_package(" + pkg.Name + " CONFIG REQUIRED)" + marker
+		}
+	}
+	var targets []string
+	for _, t := range link.Targets {
+		if !linked[t] {
+			targets = append(targets, t)
+		}
+	}
+	if len(targets) > 0 {
+		scope := "PRIVATE"
+		switch target.Kind {
+		case "library":
+			scope = "PUBLIC"
+		case "interface":
+			scope = "INTERFACE"
+		}
+		links = "target_link_libraries(" + target.Ref + " " + scope + " " + strings.Join(targets, " ") + ")" + marker
+	}
+	if finds == "" && links == "" {
+		return os.WriteFile(target.Path, []byte(content), 0644)
+	}
+
+	// 先在后面的位置插入, 前面的位置不受影响
+	linkAt := lineEnd(content, definition.end)
+	if lastLink >= 0 {
+		linkAt = lineEnd(content, commands[lastLink].end)
+	}
+	findAt := lineStart(content, definition.start)
+	if lastFind >= 0 {
+		findAt = lineEnd(content, commands[lastFind].end)
+	}
+	content = insertLines(content, linkAt, links, newline)
+	content = insertLines(content, findAt, finds, newline)
+	return os.WriteFile(target.Path, []byte(content), 0644)
+}
+
+// defines 判断命令是否定义了文件中写作 ref 的目标
+func defines(cmd command, ref string) bool {
+	defined, _, ok := definedTarget(cmd)
+	return ok && defined == ref
+}
+
+// RemoveLinks 从 projectPath 下所有 CMakeLists.txt 中删除 AddLink 为依赖 name 写入的行, 返回修改过的文件
+func RemoveLinks(projectPath, name string) ([]string, error) {
+	var changed []string
+	err := walkLists(projectPath, func(path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		content := removeMarked(string(data), linkMarker(name))
+		if content == string(data) {
+			return nil
+		}
+		changed = append(changed, path)
+		return os.WriteFile(path, []byte(content), 0644)
+	})
+	return changed, err
+}
+
+// removeMarked 删除以 marker 结尾的行
+func removeMarked(content, marker string) string {
+	lines := strings.SplitAfter(content, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.HasSuffix(strings.TrimRight(line, " \t\r\n"), marker) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "")
+}
+
+// lineStart 返回 i 所在行的开头, 紧挨在上面的注释行属于同一段, 也包含在内
+func lineStart(content string, i int) int {
+	start := strings.LastIndex(content[:i], "\n") + 1
+	for start > 0 {
+		prev := strings.LastIndex(content[:start-1], "\n") + 1
+		if !strings.HasPrefix(strings.TrimSpace(content[prev:start]), "#") {
+			break
+		}
+		start = prev
+	}
+	return start
+}
+
+// lineEnd 返回 i 所在行之后下一行的开头, 没有下一行时返回文件末尾
+func lineEnd(content string, i int) int {
+	if j := strings.Index(content[i:], "\n"); j >= 0 {
+		return i + j + 1
+	}
+	return len(content)
+}
+
+// insertLines 在 at 处插入 lines, at 位于没有换行结尾的最后一行之后时先补上换行
+func insertLines(content string, at int, lines, newline string) string {
+	if lines == "" {
+		return content
+	}
+	if at == len(content) && at > 0 && content[at-1] != '\n' {
+		return content + newline + lines
+	}
+	return content[:at] + lines + content[at:]
+}
@@ -0,0 +1,44 @@
+package cmake
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// outputScript 在配置结束时把项目中所有目标的生成文件重定向到 CGEAR_OUTPUT_DIRECTORY。
+// 项目模板用普通变量设置 CMAKE_RUNTIME_OUTPUT_DIRECTORY, 命令行的缓存变量会被覆盖,
+// 所以在目标全部定义之后直接设置目标属性, 需要 CMake 3.19 及以上版本。
+const outputScript = `include_guard(GLOBAL)
+
+function(cgear_redirect_outputs dir)
+  get_property(targets DIRECTORY "${dir}" PROPERTY BUILDSYSTEM_TARGETS)
+  foreach(target IN LISTS targets)
+    get_target_property(type ${target} TYPE)
+    if(NOT type STREQUAL "INTERFACE_LIBRARY" AND NOT type STREQUAL "UTILITY")
+      set_target_properties(${target} PROPERTIES
+        RUNTIME_OUTPUT_DIRECTORY "${CGEAR_OUTPUT_DIRECTORY}/bin"
+        LIBRARY_OUTPUT_DIRECTORY "${CGEAR_OUTPUT_DIRECTORY}/bin"
+        ARCHIVE_OUTPUT_DIRECTORY "${CGEAR_OUTPUT_DIRECTORY}/lib")
+    endif()
+  endforeach()
+  get_property(subdirs DIRECTORY "${dir}" PROPERTY SUBDIRECTORIES)
+  foreach(subdir IN LISTS subdirs)
+    cgear_redirect_outputs("${subdir}")
+  endforeach()
+endfunction()
+
+cmake_language(DEFER DIRECTORY "${CMAKE_SOURCE_DIR}" CALL cgear_redirect_outputs "${CMAKE_SOURCE_DIR}")
+`
+
+// outputScriptPath 返回构建目录中重定向脚本的路径
+func outputScriptPath(buildPath string) string {
+	return filepath.Join(buildPath, "cgear-output.cmake")
+}
+
+// writeOutputScript 在构建目录中写入重定向脚本, 通过 CMAKE_PROJECT_INCLUDE 引入
+func writeOutputScript(buildPath string) error {
+	if err := os.MkdirAll(buildPath, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(outputScriptPath(buildPath), []byte(outputScript), 0644)
+}
@@ -0,0 +1,85 @@
+package cmake
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 包配置文件的文件名, 例如 GTestConfig.cmake 和 fmt-config.cmake
+var configFilePattern = regexp.MustCompile(`^(.+?)(?:Config|-config)\.cmake$`)
+
+// 导入目标的声明, 例如 add_library(fmt::fmt STATIC IMPORTED)
+var importedPattern = regexp.MustCompile(`(?i)add_library\s*\(\s*([^\s\)]+)\s+(?:[A-Z]+\s+)?IMPORTED\b`)
+
+// Package 是一个可以通过 find_package 找到的 CMake 包
+type Package struct {
+	Name    string   // find_package 使用的包名
+	Targets []string // 包导出的导入目标, 按名称排序
+}
+
+// FindPackages 在 files 中查找包配置文件, 从配置文件所在目录的 .cmake 文件中读取导出的导入目标。
+// 同名的包只返回一次, 按包名排序。
+func FindPackages(files []string) ([]Package, error) {
+	targets := make(map[string]map[string]bool)
+	for _, file := range files {
+		match := configFilePattern.FindStringSubmatch(filepath.Base(file))
+		if match == nil {
+			continue
+		}
+		if targets[match[1]] == nil {
+			targets[match[1]] = make(map[string]bool)
+		}
+
+		// 导入目标通常在同目录的 <name>Targets.cmake 中声明
+		scripts, err := filepath.Glob(filepath.Join(filepath.Dir(file), "*.cmake"))
+		if err != nil {
+			return nil, err
+		}
+		for _, script := range scripts {
+			data, err := os.ReadFile(script)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range importedPattern.FindAllStringSubmatch(string(data), -1) {
+				// 由变量组成的目标名无法确定
+				if !strings.Contains(m[1], "${") {
+					targets[match[1]][m[1]] = true
+				}
+			}
+		}
+	}
+
+	var packages []Package
+	for name, set := range targets {
+		pkg := Package{Name: name}
+		for target := range set {
+			pkg.Targets = append(pkg.Targets, target)
+		}
+		sort.Strings(pkg.Targets)
+		packages = append(packages, pkg)
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Name < packages[j].Name })
+	return packages, nil
+}
+
+// DefaultTargets 返回默认链接的导入目标: 所有包的目标中除去提供 main 函数的 *_main 目标,
+// 例如 GTest::gtest_main。包只导出 *_main 目标时返回全部目标。
+func DefaultTargets(packages []Package) []string {
+	var all, targets []string
+	for _, pkg := range packages {
+		for _, target := range pkg.Targets {
+			all = append(all, target)
+			name := target[strings.LastIndex(target, ":")+1:]
+			if !strings.HasSuffix(name, "_main") {
+				targets = append(targets, target)
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return all
+	}
+	return targets
+}
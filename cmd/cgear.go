@@ -2,15 +2,28 @@ package cmd
 
 import (
 	"github.com/zelviner/cgear/cmd/commands"
+	_ "github.com/zelviner/cgear/cmd/commands/add"
+	_ "github.com/zelviner/cgear/cmd/commands/bench"
+	_ "github.com/zelviner/cgear/cmd/commands/bisect"
 	_ "github.com/zelviner/cgear/cmd/commands/build"
+	_ "github.com/zelviner/cgear/cmd/commands/cache"
 	_ "github.com/zelviner/cgear/cmd/commands/count"
 	_ "github.com/zelviner/cgear/cmd/commands/env"
+	_ "github.com/zelviner/cgear/cmd/commands/fuzz"
 	_ "github.com/zelviner/cgear/cmd/commands/generate"
+	_ "github.com/zelviner/cgear/cmd/commands/info"
 	_ "github.com/zelviner/cgear/cmd/commands/install"
+	_ "github.com/zelviner/cgear/cmd/commands/list"
+	_ "github.com/zelviner/cgear/cmd/commands/mutate"
 	_ "github.com/zelviner/cgear/cmd/commands/new"
+	_ "github.com/zelviner/cgear/cmd/commands/outdated"
 	_ "github.com/zelviner/cgear/cmd/commands/pack"
+	_ "github.com/zelviner/cgear/cmd/commands/remove"
 	_ "github.com/zelviner/cgear/cmd/commands/run"
 	_ "github.com/zelviner/cgear/cmd/commands/test"
+	_ "github.com/zelviner/cgear/cmd/commands/uninstall"
+	_ "github.com/zelviner/cgear/cmd/commands/update"
+	_ "github.com/zelviner/cgear/cmd/commands/vendor"
 	_ "github.com/zelviner/cgear/cmd/commands/version"
 	"github.com/zelviner/cgear/utils"
 )
@@ -0,0 +1,216 @@
+package add
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/zelviner/cgear/cmake"
+	"github.com/zelviner/cgear/cmd/commands"
+	"github.com/zelviner/cgear/cmd/commands/version"
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/deps"
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/utils"
+)
+
+var CmdAdd = &commands.Command{
+	UsageLine: "add <package> [-target=<name>] [-link=<targets>] [-j=4]",
+	Short:     "Add a dependency to the project and link it in CMakeLists.txt",
+	Long: `
+Add declares a dependency in cgear.json or Cgearfile, installs it like 'cgear install' and
+  records it in cgear.lock. It then reads the *Config.cmake files installed by the package,
+  and writes the find_package and target_link_libraries lines into the CMakeLists.txt that
+  defines the target:
+
+    find_package(GTest CONFIG REQUIRED) # cgear: googletest
+    target_link_libraries(${APP_NAME} PRIVATE GTest::gmock GTest::gtest) # cgear: googletest
+
+  The target defaults to the project name. The test, benchmark and fuzz targets created by
+  'cgear new', such as demo_test from add_integration_test(demo), can be chosen with -target,
+  their lines are written after the call. Executables link the package PRIVATE, libraries
+  PUBLIC. All imported targets of the package are linked unless -link chooses some of them,
+  except the *_main targets such as GTest::gtest_main that provide a main function.
+  The lines end with a '# cgear: <name>' comment, 'cgear remove' deletes them again.
+
+  A package that is already declared keeps its declaration, only the CMake lines are written.
+
+  {{"Example:"|bold}}
+    $ cgear add fmtlib:fmt                              # Link fmt::fmt to the project
+    $ cgear add google:googletest -target=demo_test     # Link googletest to the target demo_test
+    $ cgear add google:googletest -link=GTest::gtest    # Link only GTest::gtest
+`,
+	PreRun: func(cmd *commands.Command, args []string) { version.ShowShortVersionBanner() },
+	Run:    RunAdd,
+}
+
+var (
+	target string // 链接依赖的目标
+	link   string // 以逗号分隔的导入目标
+	jobs   int    // 同时进行的构建数
+)
+
+func init() {
+	CmdAdd.Flag.StringVar(&target, "target", "", "CMake target that links the package, default the project name")
+	CmdAdd.Flag.StringVar(&link, "link", "", "Comma separated imported targets to link, default all targets of the package except *_main")
+	CmdAdd.Flag.IntVar(&jobs, "j", 0, "Number of builds to run at the same time, default the number of CPUs")
+	commands.AvailableCommands = append(commands.AvailableCommands, CmdAdd)
+}
+
+func RunAdd(cmd *commands.Command, args []string) int {
+	if len(args) > 1 {
+		if err := cmd.Flag.Parse(args[1:]); err != nil {
+			logger.Log.Fatal("Parse args err" + err.Error())
+		}
+	}
+	if len(args) == 0 {
+		logger.Log.Fatal("Argument <package> is missing")
+	}
+	projectPath := utils.GetCgearWorkPath()
+
+	// 已声明的依赖可以以包名或来源给出
+	dependencies := append([]config.Dependency(nil), config.Conf.Dependencies...)
+	var dep *config.Dependency
+	for i := range dependencies {
+		if dependencies[i].Name == args[0] || dependencies[i].Source == args[0] {
+			dep = &dependencies[i]
+		}
+	}
+	if dep == nil {
+		source, err := deps.ParseSource(args[0])
+		if err != nil {
+			logger.Log.Fatal(err.Error())
+		}
+		dependencies = append(dependencies, config.Dependency{Name: source.Name(), Source: args[0]})
+		dep = &dependencies[len(dependencies)-1]
+	}
+	if err := deps.Validate(dependencies); err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+
+	// 安装前先确定目标, 避免安装之后才发现目标不存在
+	lists, err := findTarget(projectPath, target)
+	if err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+
+	lockPath := filepath.Join(projectPath, deps.LockFile)
+	lock, err := deps.ReadLock(lockPath)
+	if err != nil {
+		logger.Log.Fatalf("Failed to read %s: %s", deps.LockFile, err)
+	}
+
+	installer := deps.NewInstaller()
+	installer.Jobs = jobs
+	if _, _, err := installer.Sync(dependencies, lock, false); err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+	if err := config.SaveDependencies(projectPath, dependencies); err != nil {
+		logger.Log.Fatalf("Failed to save the dependencies: %s", err)
+	}
+	config.Conf.Dependencies = dependencies
+	if err := lock.Save(lockPath); err != nil {
+		logger.Log.Fatalf("Failed to write %s: %s", deps.LockFile, err)
+	}
+
+	record, err := deps.ReadRecord(installer.Prefix(), dep.Name)
+	if err != nil {
+		logger.Log.Fatalf("Failed to read the installed files of '%s': %s", dep.Name, err)
+	}
+	packages, err := cmake.FindPackages(configFiles(installer.Prefix(), record))
+	if err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+	if len(packages) == 0 {
+		logger.Log.Warnf("'%s' installs no CMake package configuration, link it in CMakeLists.txt by hand", dep.Name)
+		return 0
+	}
+
+	targets, err := linkTargets(packages, link)
+	if err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+	if len(targets) == 0 {
+		logger.Log.Warnf("'%s' exports no imported targets, link it in CMakeLists.txt by hand", dep.Name)
+		return 0
+	}
+	if err := cmake.AddLink(*lists, cmake.Link{Name: dep.Name, Packages: packages, Targets: targets}); err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+
+	logger.Log.Successf("Added '%s', %s links %s in %s", dep.Name, lists.Name, strings.Join(targets, " "), relative(projectPath, lists.Path))
+	return 0
+}
+
+// findTarget 返回项目中名为 name 的目标, name 为空时使用项目名
+func findTarget(projectPath, name string) (*cmake.ListsTarget, error) {
+	if name == "" {
+		appName, err := utils.GetCgearAppName(projectPath)
+		if err != nil {
+			return nil, err
+		}
+		name = appName
+	}
+
+	targets, err := cmake.FindListsTargets(projectPath)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for i := range targets {
+		if targets[i].Name == name {
+			return &targets[i], nil
+		}
+		names = append(names, targets[i].Name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no target is defined in the CMakeLists.txt files of the project")
+	}
+	return nil, fmt.Errorf("target '%s' is not defined in the project, use -target to choose one of %s", name, strings.Join(names, ", "))
+}
+
+// configFiles 返回依赖安装的 Release 版本的文件的绝对路径, 包配置文件在其中
+func configFiles(prefix string, record *deps.Record) []string {
+	var files []string
+	for _, file := range record.Files {
+		if !strings.HasPrefix(file, "debug/") {
+			files = append(files, filepath.Join(prefix, filepath.FromSlash(file)))
+		}
+	}
+	return files
+}
+
+// linkTargets 返回要链接的导入目标: selected 中以逗号分隔的目标, 为空时是所有包的默认目标
+func linkTargets(packages []cmake.Package, selected string) ([]string, error) {
+	exported := make(map[string]bool)
+	var all []string
+	for _, pkg := range packages {
+		for _, t := range pkg.Targets {
+			exported[t] = true
+			all = append(all, t)
+		}
+	}
+	if selected == "" {
+		return cmake.DefaultTargets(packages), nil
+	}
+
+	var targets []string
+	for _, t := range strings.Split(selected, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		if !exported[t] {
+			return nil, fmt.Errorf("the package does not export '%s', its targets are %s", t, strings.Join(all, ", "))
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+func relative(base, path string) string {
+	if rel, err := filepath.Rel(base, path); err == nil {
+		return rel
+	}
+	return path
+}
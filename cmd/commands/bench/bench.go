@@ -0,0 +1,282 @@
+package bench
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zelviner/cgear/bencher"
+	"github.com/zelviner/cgear/cmake"
+	"github.com/zelviner/cgear/cmd/commands"
+	"github.com/zelviner/cgear/cmd/commands/version"
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/logger/colors"
+	"github.com/zelviner/cgear/utils"
+)
+
+var CmdBench = &commands.Command{
+	UsageLine: "bench [filter] [-r] [-repetitions=N] [-compare=baseline.json] [-threshold=5] [-o=results.json]",
+	Short:     "Build the project in Release and run its benchmarks",
+	Long: `
+Bench builds the benchmark targets (executables named *_bench) of the project in Release mode
+  in build-release and runs them. The programs are written to build-release/bin, the bin directory
+  of the project is left untouched. This requires CMake 3.19 or later.
+  The results are saved as JSON to .cgear/bench, and can be used as a baseline for later runs.
+
+  {{"Example:"|bold}}
+    $ cgear bench                                  # Run all benchmarks
+    $ cgear bench BM_Parse                         # Run the benchmarks matching a regular expression
+    $ cgear bench -repetitions 10                  # Repeat each benchmark to measure the noise
+    $ cgear bench -o baseline.json                 # Save the results as a baseline
+    $ cgear bench -compare baseline.json           # Compare with a baseline
+    $ cgear bench -compare baseline.json -threshold 10
+`,
+	PreRun: func(cmd *commands.Command, args []string) { version.ShowShortVersionBanner() },
+	Run:    RunBench,
+}
+
+var (
+	rebuild     bool    // 是否重新构建
+	repetitions int     // 每个基准测试的重复次数
+	compare     string  // 用于比较的基线结果
+	threshold   float64 // 显著变化的阈值（百分比）
+	outputPath  string  // 结果的保存路径
+	appPath     string
+	buildPath   string
+)
+
+func init() {
+	CmdBench.Flag.BoolVar(&rebuild, "r", false, "Clear the release build folder and rebuild, default false")
+	CmdBench.Flag.IntVar(&repetitions, "repetitions", 0, "Number of times to repeat each benchmark")
+	CmdBench.Flag.StringVar(&compare, "compare", "", "Compare the results with a baseline JSON file")
+	CmdBench.Flag.Float64Var(&threshold, "threshold", 5, "Minimum change in percent reported as significant with -compare")
+	CmdBench.Flag.StringVar(&outputPath, "o", "", "Path of the JSON results, default .cgear/bench/<time>.json")
+	commands.AvailableCommands = append(commands.AvailableCommands, CmdBench)
+}
+
+func RunBench(cmd *commands.Command, args []string) int {
+	appPath = utils.GetCgearWorkPath()
+	buildPath = filepath.Join(appPath, "build-release")
+
+	if len(args) > 1 {
+		if err := cmd.Flag.Parse(args[1:]); err != nil {
+			logger.Log.Fatal("Parse args err" + err.Error())
+		}
+	}
+
+	var filter string
+	if len(args) > 0 {
+		filter = args[0]
+	}
+
+	// 基准测试总是使用独立的 Release 构建目录, 不影响日常的 Debug 构建
+	configArg := cmake.ConfigArg{
+		Toolchain:             config.Conf.Toolchain,
+		Platform:              config.Conf.Platform,
+		BuildType:             "Release",
+		Generator:             config.Conf.Generator,
+		NoWarnUnusedCli:       true,
+		ExportCompileCommands: false,
+		ProjectPath:           appPath,
+		BuildPath:             buildPath,
+		CXXFlags:              "-D_MD",
+		CodemodelQuery:        true,
+		OutputPath:            buildPath,
+	}
+
+	buildArg := cmake.BuildArg{
+		BuildPath: buildPath,
+		BuildType: "Release",
+		IsMSVC:    configArg.Toolchain != nil && configArg.Toolchain.IsMSVC,
+	}
+
+	// 设置临时环境变量
+	dllPath := getDllPath()
+	logger.Log.Infof("Setting PATH environment variable to: %s", dllPath)
+	restore, err := utils.SetEnvTemp("PATH", dllPath)
+	if err != nil {
+		logger.Log.Errorf("Failed to set PATH environment variable: %v", err)
+		return 1
+	}
+	defer restore() // 确保在函数结束时恢复原始 PATH
+
+	if err := cmake.Configure(&configArg, nil, rebuild, false); err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+	targets, err := benchTargets()
+	if err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+	if len(targets) == 0 {
+		logger.Log.Fatal("No benchmarks found in the project, create one with 'cgear new <name>' and select Benchmark")
+	}
+
+	// 只构建基准测试目标及其依赖
+	logger.Log.Info("Building the benchmarks in Release mode ...")
+	var programs []string
+	for _, target := range targets {
+		buildArg.Targets = append(buildArg.Targets, target.Name)
+	}
+	if err := cmake.Compile(&buildArg, false); err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+	for _, target := range targets {
+		// MSVC 的生成文件还包括 .pdb
+		for _, artifact := range target.Artifacts {
+			if strings.TrimSuffix(filepath.Base(artifact), ".exe") == target.Name {
+				programs = append(programs, artifact)
+			}
+		}
+	}
+
+	var reports []*bencher.Report
+	for _, program := range programs {
+		report, err := runProgram(program, filter)
+		if err != nil {
+			logger.Log.Fatalf("Failed to run %s: %s", filepath.Base(program), err)
+		}
+		reports = append(reports, report)
+	}
+	report := bencher.Merge(reports...)
+
+	if outputPath == "" {
+		outputPath = filepath.Join(appPath, ".cgear", "bench", time.Now().Format("20060102-150405")+".json")
+	}
+	if err := report.Save(outputPath); err != nil {
+		logger.Log.Errorf("Failed to save benchmark results: %s", err)
+	} else {
+		logger.Log.Infof("Benchmark results written to: %s", outputPath)
+	}
+
+	stats := report.Stats()
+	if compare == "" {
+		showStats(stats)
+		return 0
+	}
+
+	baseline, err := bencher.ParseFile(compare)
+	if err != nil {
+		logger.Log.Fatalf("Failed to read baseline '%s': %s", compare, err)
+	}
+	showDeltas(bencher.Compare(baseline.Stats(), stats, threshold/100))
+
+	return 0
+}
+
+// runProgram 运行一个基准测试程序, 并解析其 JSON 输出
+func runProgram(program string, filter string) (*bencher.Report, error) {
+	args := []string{"--benchmark_format=json"}
+	if filter != "" {
+		args = append(args, "--benchmark_filter="+filter)
+	}
+	if repetitions > 0 {
+		args = append(args, "--benchmark_repetitions="+strconv.Itoa(repetitions))
+	}
+
+	logger.Log.Infof("Running %s", filepath.Base(program))
+
+	var stdout bytes.Buffer
+	cmd := exec.Command(program, args...)
+	cmd.Dir = appPath
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	report, err := bencher.Parse(&stdout)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(program), filepath.Ext(program))
+	for i := range report.Benchmarks {
+		report.Benchmarks[i].Binary = name
+	}
+	return report, nil
+}
+
+// showStats 打印每个基准测试的平均耗时
+func showStats(stats []bencher.Stat) {
+	fmt.Println()
+	fmt.Println(colors.MagentaBold("BENCHMARKS"))
+	fmt.Printf("    %-40s %14s %14s %12s\n", "Benchmark", "Time", "CPU", "Iterations")
+	for _, stat := range stats {
+		elapsed := bencher.FormatTime(stat.Mean)
+		if stat.Samples > 1 {
+			elapsed += fmt.Sprintf(" ±%.1f%%", stat.StdDev/stat.Mean*100)
+		}
+		fmt.Printf("    %-40s %14s %14s %12d\n", stat.Name, elapsed, bencher.FormatTime(stat.CPU), stat.Iterations)
+	}
+	fmt.Println()
+}
+
+// showDeltas 打印相对基线的变化
+func showDeltas(deltas []bencher.Delta) {
+	var faster, slower int
+
+	fmt.Println()
+	fmt.Println(colors.MagentaBold("COMPARISON"))
+	fmt.Printf("    %-40s %14s %14s %10s\n", "Benchmark", "Baseline", "Current", "Change")
+	for _, delta := range deltas {
+		switch delta.Status {
+		case bencher.Added:
+			fmt.Printf("    %-40s %14s %14s %10s\n", delta.Name, "-", bencher.FormatTime(delta.Current), colors.Bold("new"))
+			continue
+		case bencher.Removed:
+			fmt.Printf("    %-40s %14s %14s %10s\n", delta.Name, bencher.FormatTime(delta.Baseline), "-", colors.Bold("removed"))
+			continue
+		}
+
+		change := fmt.Sprintf("%+.1f%%", delta.Change*100)
+		switch delta.Status {
+		case bencher.Faster:
+			faster++
+			change = colors.GreenBold(change)
+		case bencher.Slower:
+			slower++
+			change = colors.RedBold(change)
+		}
+		fmt.Printf("    %-40s %14s %14s %10s\n", delta.Name, bencher.FormatTime(delta.Baseline), bencher.FormatTime(delta.Current), change)
+	}
+	fmt.Println()
+
+	if slower > 0 {
+		logger.Log.Warnf("%d benchmarks faster, %d slower (threshold %.1f%%)", faster, slower, threshold)
+	} else {
+		logger.Log.Successf("%d benchmarks faster, %d slower (threshold %.1f%%)", faster, slower, threshold)
+	}
+}
+
+// benchTargets 从 codemodel 中查找所有基准测试程序, 即名称以 _bench 结尾的可执行目标
+func benchTargets() ([]cmake.Target, error) {
+	targets, err := cmake.ReadCodemodel(buildPath, "Release")
+	if err != nil {
+		return nil, err
+	}
+
+	var benches []cmake.Target
+	for _, target := range targets {
+		if target.Type == "EXECUTABLE" && strings.HasSuffix(target.Name, "_bench") {
+			benches = append(benches, target)
+		}
+	}
+	return benches, nil
+}
+
+func getDllPath() string {
+	cgearHome := utils.GetCgearHomePath()
+	switch config.Conf.Platform {
+	case "x86":
+		return filepath.Join(cgearHome, "installed", "x86-windows", "bin")
+	case "x64":
+		return filepath.Join(cgearHome, "installed", "x64-windows", "bin")
+	}
+	return ""
+}
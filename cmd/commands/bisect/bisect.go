@@ -0,0 +1,251 @@
+package bisect
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/zelviner/cgear/bisector"
+	"github.com/zelviner/cgear/cmake"
+	"github.com/zelviner/cgear/cmd/commands"
+	"github.com/zelviner/cgear/cmd/commands/version"
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/logger/colors"
+	"github.com/zelviner/cgear/tester"
+	"github.com/zelviner/cgear/utils"
+)
+
+var CmdBisect = &commands.Command{
+	UsageLine: "bisect <good> <bad> [-test=Suite.Case] [-run-script=command]",
+	Short:     "Find the commit that broke a test with git bisect",
+	Long: `
+Bisect runs 'git bisect run' between a good and a bad commit. At each step the project is built
+  incrementally in .cgear/bisect/build, then the selected tests or the script are run.
+  Programs and libraries are written to .cgear/bisect/bin and .cgear/bisect/lib, the bin
+  directory of the project is left untouched. This requires CMake 3.19 or later.
+  Commits that fail to build are skipped. When the search ends the first bad commit is reported
+  and the repository is restored to the original branch.
+
+  The script follows the 'git bisect run' convention: exit 0 for good, 125 to skip, other codes for bad.
+  It finds the programs built for the step in .cgear/bisect/bin.
+
+  {{"Example:"|bold}}
+    $ cgear bisect v1.2.0 HEAD                           # Bisect with all tests
+    $ cgear bisect v1.2.0 HEAD -test Json.parser         # Bisect with a single case
+    $ cgear bisect v1.2.0 main -test Json                # Bisect with all cases of a suite
+    $ cgear bisect v1.2.0 HEAD -run-script ./check.sh    # Bisect with a script run in the project directory
+`,
+	PreRun: func(cmd *commands.Command, args []string) {},
+	Run:    RunBisect,
+}
+
+var (
+	testFilter string // 每一步运行的用例
+	runScript  string // 每一步运行的命令
+	step       bool   // 由 git bisect run 调用, 测试当前提交
+	appPath    string
+	outputPath string
+	buildPath  string
+	testPath   string
+)
+
+func init() {
+	CmdBisect.Flag.StringVar(&testFilter, "test", "", "Tests to run at each step, for example Json.parser or Json")
+	CmdBisect.Flag.StringVar(&runScript, "run-script", "", "Command to run at each step instead of the tests")
+	CmdBisect.Flag.BoolVar(&step, "step", false, "Build and test the checked out commit, used internally by git bisect run")
+	commands.AvailableCommands = append(commands.AvailableCommands, CmdBisect)
+}
+
+func RunBisect(cmd *commands.Command, args []string) int {
+	appPath = utils.GetCgearWorkPath()
+	outputPath = filepath.Join(appPath, ".cgear", "bisect")
+	buildPath = filepath.Join(outputPath, "build")
+	testPath = filepath.Join(outputPath, "bin")
+
+	if len(args) > 2 {
+		if err := cmd.Flag.Parse(args[2:]); err != nil {
+			logger.Log.Fatal("Parse args err" + err.Error())
+		}
+	}
+
+	if step {
+		return runStep()
+	}
+
+	version.ShowShortVersionBanner()
+	if len(args) < 2 {
+		logger.Log.Fatal("Arguments <good> and <bad> are required")
+	}
+	if testFilter != "" && runScript != "" {
+		logger.Log.Fatal("Flags -test and -run-script cannot be used together")
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+
+	command := []string{executable, "bisect", "-step"}
+	if testFilter != "" {
+		command = append(command, "-test="+testFilter)
+	}
+	if runScript != "" {
+		command = append(command, "-run-script="+runScript)
+	}
+
+	logger.Log.Infof("Bisecting between good %s and bad %s", args[0], args[1])
+	result, err := bisector.Run(appPath, args[0], args[1], command, os.Stdout)
+	if err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+
+	fmt.Println()
+	if result.FirstBad != "" {
+		logger.Log.Error("First bad commit:")
+		showCommit(result.FirstBad)
+		return 0
+	}
+
+	logger.Log.Warn("Some commits could not be built, the first bad commit is one of:")
+	for _, sha := range result.Candidates {
+		showCommit(sha)
+	}
+	return 0
+}
+
+// runStep 构建当前提交并运行测试, 以 git bisect run 的约定返回退出码
+func runStep() int {
+	head, _ := bisector.Describe(appPath, "HEAD")
+	if head != nil {
+		logger.Log.Infof("Testing %s %s", head.SHA[:10], head.Subject)
+	}
+
+	// 删除其他提交构建出的程序, 它们只在 .cgear/bisect 中, 不会影响项目的 bin 目录
+	os.RemoveAll(testPath)
+
+	configArg := cmake.ConfigArg{
+		Toolchain:             config.Conf.Toolchain,
+		Platform:              config.Conf.Platform,
+		BuildType:             config.Conf.BuildType,
+		Generator:             config.Conf.Generator,
+		NoWarnUnusedCli:       true,
+		ExportCompileCommands: false,
+		ProjectPath:           appPath,
+		BuildPath:             buildPath,
+		CXXFlags:              "-D_MD",
+		OutputPath:            outputPath,
+	}
+
+	buildArg := cmake.BuildArg{
+		BuildPath: buildPath,
+		BuildType: config.Conf.BuildType,
+		IsMSVC:    configArg.Toolchain != nil && configArg.Toolchain.IsMSVC,
+	}
+
+	if err := cmake.Build(&configArg, &buildArg, false, false); err != nil {
+		logger.Log.Warnf("Skipping, the build failed: %s", err)
+		return bisector.Skip
+	}
+
+	if runScript != "" {
+		return runCommand(runScript)
+	}
+	return runTests()
+}
+
+// runCommand 在项目目录中通过 shell 运行命令
+func runCommand(command string) int {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Dir = appPath
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	code := bisector.ExitCode(cmd.Run())
+	logger.Log.Infof("'%s' exited with %d", command, code)
+	return code
+}
+
+// runTests 运行匹配 -test 的用例, 没有匹配的用例时跳过该提交
+func runTests() int {
+	framework, err := tester.Lookup(config.Conf.TestFramework)
+	if err != nil {
+		logger.Log.Errorf("Skipping: %s", err)
+		return bisector.Skip
+	}
+
+	filter := testFilter
+	switch {
+	case filter == "":
+		filter = "*"
+	case !strings.Contains(filter, "."):
+		filter += "*"
+	}
+
+	var (
+		cases  []tester.Case
+		failed bool
+	)
+	for i, program := range findTestPrograms() {
+		tests, err := tester.ListTests(framework, program, filter)
+		if err != nil || len(tests) == 0 {
+			continue
+		}
+
+		xmlPath := filepath.Join(appPath, ".cgear", "bisect", "results", fmt.Sprintf("%s-%d.xml", tester.ProgramName(program), i))
+		result := tester.RunJob(framework, tester.Job{Program: program, Filter: filter}, xmlPath, os.Stdout)
+		if result.Err != nil {
+			failed = true
+		}
+		cases = append(cases, result.Cases...)
+	}
+
+	if len(cases) == 0 {
+		logger.Log.Warnf("Skipping, no tests match '%s'", filter)
+		return bisector.Skip
+	}
+
+	summary := tester.Summarize(cases)
+	if failed || summary.Failed > 0 {
+		logger.Log.Errorf("Bad: %d passed, %d failed", summary.Passed, summary.Failed)
+		return bisector.Bad
+	}
+	logger.Log.Successf("Good: %d passed", summary.Passed)
+	return bisector.Good
+}
+
+// findTestPrograms 查找 .cgear/bisect/bin 下的所有测试程序
+func findTestPrograms() []string {
+	var programs []string
+	filepath.Walk(testPath, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(info.Name(), "_test.exe") {
+			programs = append(programs, path)
+		}
+		return nil
+	})
+
+	return programs
+}
+
+func showCommit(sha string) {
+	commit, err := bisector.Describe(appPath, sha)
+	if err != nil {
+		fmt.Println("    " + sha)
+		return
+	}
+
+	fmt.Println(`    ├── ` + colors.RedBold(commit.SHA[:10]) + " " + commit.Subject)
+	fmt.Println(`    │    └── ` + commit.Author + ", " + commit.Date)
+}
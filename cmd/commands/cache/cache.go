@@ -0,0 +1,214 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/zelviner/cgear/cmd/commands"
+	"github.com/zelviner/cgear/deps"
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/logger/colors"
+	"github.com/zelviner/cgear/utils"
+)
+
+var CmdCache = &commands.Command{
+	UsageLine: "cache <ls|prune|stats> [-json] [-name=fmt] [-older=720h] [-max-size=2048] [-all]",
+	Short:     "Manage the binary cache of built dependencies",
+	Long: `
+Cache manages the libraries built by 'cgear install'. The cache is CGEAR_HOME/cache by default,
+  set CGEAR_CACHE to a directory, such as a network share, or to the address of an HTTP server
+  that accepts GET, PUT and DELETE and lists its files.
+
+  {{"Example:"|bold}}
+    $ cgear cache ls                      # List the cached builds
+    $ cgear cache ls -name fmt -json      # Print the cached builds of fmt as JSON
+    $ cgear cache prune -older 720h       # Remove builds created more than 30 days ago
+    $ cgear cache prune -max-size 2048    # Remove the oldest builds until the cache is below 2048 MB
+    $ cgear cache prune -name fmt -all    # Remove all builds of fmt
+    $ cgear cache stats                   # Show the size of the cache and the hit rate of this machine
+`,
+	PreRun: func(cmd *commands.Command, args []string) {},
+	Run:    RunCache,
+}
+
+var (
+	asJSON  bool          // 以 JSON 输出
+	name    string        // 只处理该包
+	older   time.Duration // 删除创建时间早于该时长的构建
+	maxSize int64         // 缓存的最大大小, 单位 MB
+	all     bool          // 删除所有构建
+)
+
+func init() {
+	CmdCache.Flag.BoolVar(&asJSON, "json", false, "Print the cached builds as JSON, default false")
+	CmdCache.Flag.StringVar(&name, "name", "", "Only list or prune the builds of this package")
+	CmdCache.Flag.DurationVar(&older, "older", 0, "Prune builds created longer ago than this duration, for example 720h")
+	CmdCache.Flag.Int64Var(&maxSize, "max-size", 0, "Prune the oldest builds until the cache is smaller than this size in MB")
+	CmdCache.Flag.BoolVar(&all, "all", false, "Prune all builds, default false")
+	commands.AvailableCommands = append(commands.AvailableCommands, CmdCache)
+}
+
+func RunCache(cmd *commands.Command, args []string) int {
+	if len(args) > 1 {
+		if err := cmd.Flag.Parse(args[1:]); err != nil {
+			logger.Log.Fatal("Parse args err" + err.Error())
+		}
+		if cmd.Flag.NArg() > 0 {
+			logger.Log.Fatal("Too many parameters")
+		}
+	}
+	if len(args) == 0 {
+		logger.Log.Fatal("Argument <ls|prune|stats> is missing")
+	}
+
+	cache := deps.NewCache(utils.GetCgearCachePath())
+	entries, err := cache.Entries()
+	if err != nil {
+		logger.Log.Fatalf("Failed to read the cache %s: %s", cache.Store, err)
+	}
+	if name != "" {
+		var selected []*deps.CacheEntry
+		for _, entry := range entries {
+			if entry.Name == name {
+				selected = append(selected, entry)
+			}
+		}
+		entries = selected
+	}
+
+	switch args[0] {
+	case "ls":
+		return list(cache, entries)
+	case "prune":
+		return prune(cache, entries)
+	case "stats":
+		return stats(cache, entries)
+	}
+
+	logger.Log.Fatalf("Unknown cache command '%s', use ls, prune or stats", args[0])
+	return 1
+}
+
+func list(cache *deps.Cache, entries []*deps.CacheEntry) int {
+	if asJSON {
+		if entries == nil {
+			entries = []*deps.CacheEntry{}
+		}
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			logger.Log.Fatal(err.Error())
+		}
+		fmt.Println(string(data))
+		return 0
+	}
+
+	if len(entries) == 0 {
+		logger.Log.Infof("No builds in the cache %s", cache.Store)
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tREVISION\tTRIPLET\tTYPE\tSIZE\tCREATED\tKEY")
+	for _, entry := range entries {
+		revision := entry.Revision
+		if len(revision) > 10 {
+			revision = revision[:10]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", entry.Name, revision, entry.Triplet, entry.BuildType,
+			formatSize(entry.Size), entry.Created.Format("2006-01-02 15:04"), entry.Key)
+	}
+	w.Flush()
+	return 0
+}
+
+// prune 删除选中的构建: 全部、早于 -older 的, 以及超出 -max-size 时最旧的
+func prune(cache *deps.Cache, entries []*deps.CacheEntry) int {
+	if !all && older == 0 && maxSize == 0 {
+		logger.Log.Fatal("Specify -older, -max-size or -all to choose the builds to prune")
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Created.Before(entries[j].Created) })
+
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+	}
+
+	var removed int
+	var freed int64
+	for _, entry := range entries {
+		expired := older > 0 && time.Since(entry.Created) > older
+		oversize := maxSize > 0 && total > maxSize*1024*1024
+		if !all && !expired && !oversize {
+			continue
+		}
+
+		if err := cache.Remove(entry.Key); err != nil {
+			logger.Log.Errorf("Failed to remove %s: %s", entry.Key, err)
+			continue
+		}
+		total -= entry.Size
+		freed += entry.Size
+		removed++
+	}
+
+	logger.Log.Successf("Removed %d builds, %s freed, %s left in %s", removed, formatSize(freed), formatSize(total), cache.Store)
+	return 0
+}
+
+func stats(cache *deps.Cache, entries []*deps.CacheEntry) int {
+	var total int64
+	packages := make(map[string]bool)
+	var oldest, newest time.Time
+	for _, entry := range entries {
+		total += entry.Size
+		packages[entry.Name] = true
+		if oldest.IsZero() || entry.Created.Before(oldest) {
+			oldest = entry.Created
+		}
+		if entry.Created.After(newest) {
+			newest = entry.Created
+		}
+	}
+
+	fmt.Println(colors.Bold("Cache"))
+	fmt.Printf("    %-14s %s\n", "Location:", cache.Store)
+	fmt.Printf("    %-14s %d builds of %d packages\n", "Entries:", len(entries), len(packages))
+	fmt.Printf("    %-14s %s\n", "Size:", formatSize(total))
+	if len(entries) > 0 {
+		fmt.Printf("    %-14s %s\n", "Oldest:", oldest.Format("2006-01-02 15:04"))
+		fmt.Printf("    %-14s %s\n", "Newest:", newest.Format("2006-01-02 15:04"))
+	}
+
+	usage, err := deps.ReadCacheStats(deps.CacheStatsPath())
+	if err != nil {
+		logger.Log.Fatalf("Failed to read the cache statistics: %s", err)
+	}
+	fmt.Println(colors.Bold("This machine"))
+	if lookups := usage.Hits + usage.Misses; lookups > 0 {
+		fmt.Printf("    %-14s %d of %d (%.0f%%)\n", "Hits:", usage.Hits, lookups, float64(usage.Hits)*100/float64(lookups))
+		fmt.Printf("    %-14s %d\n", "Misses:", usage.Misses)
+		fmt.Printf("    %-14s %d\n", "Uploads:", usage.Uploads)
+		fmt.Printf("    %-14s %d\n", "Errors:", usage.Errors)
+		fmt.Printf("    %-14s %s\n", "Since:", usage.Since.Format("2006-01-02 15:04"))
+	} else {
+		fmt.Println("    The cache has not been used yet")
+	}
+	return 0
+}
+
+func formatSize(size int64) string {
+	switch {
+	case size >= 1024*1024*1024:
+		return fmt.Sprintf("%.1f GB", float64(size)/(1024*1024*1024))
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	}
+	return fmt.Sprintf("%d B", size)
+}
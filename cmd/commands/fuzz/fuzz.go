@@ -0,0 +1,259 @@
+package fuzz
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zelviner/cgear/cmake"
+	"github.com/zelviner/cgear/cmd/commands"
+	"github.com/zelviner/cgear/cmd/commands/version"
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/fuzzer"
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/logger/colors"
+	"github.com/zelviner/cgear/tester"
+	"github.com/zelviner/cgear/utils"
+)
+
+var CmdFuzz = &commands.Command{
+	UsageLine: "fuzz [target] [-time=60s] [-corpus=fuzz/corpus/<target>] [-regress] [-r]",
+	Short:     "Build and run libFuzzer targets, or replay their corpus",
+	Long: `
+Fuzz builds the project and runs the libFuzzer target under bin/fuzz.
+  New inputs are added to fuzz/corpus/<target>, and crashing inputs are collected into fuzz/crashes/<target>.
+  Fuzzing requires a Clang toolchain; with other compilers the targets can only replay their corpus.
+
+  {{"Example:"|bold}}
+    $ cgear fuzz parser                       # Fuzz the parser target for 60 seconds
+    $ cgear fuzz parser -time 10m             # Fuzz for 10 minutes
+    $ cgear fuzz parser -corpus seeds/parser  # Use another corpus directory
+    $ cgear fuzz -regress                     # Replay the corpus and crashes of all targets as a test
+    $ cgear fuzz parser -regress              # Replay the corpus and crashes of one target
+`,
+	PreRun: func(cmd *commands.Command, args []string) { version.ShowShortVersionBanner() },
+	Run:    RunFuzz,
+}
+
+var (
+	rebuild   bool          // 是否重新构建
+	duration  time.Duration // fuzz 的运行时间
+	corpus    string        // 语料目录
+	regress   bool          // 回放语料和崩溃输入
+	appPath   string
+	buildPath string
+	fuzzPath  string
+)
+
+func init() {
+	CmdFuzz.Flag.BoolVar(&rebuild, "r", false, "Clear the build folder in the project and rebuild, default false")
+	CmdFuzz.Flag.DurationVar(&duration, "time", time.Minute, "How long to fuzz, for example 60s or 10m")
+	CmdFuzz.Flag.StringVar(&corpus, "corpus", "", "Corpus directory, default fuzz/corpus/<target>")
+	CmdFuzz.Flag.BoolVar(&regress, "regress", false, "Replay the corpus and crashing inputs once as a test, default false")
+	commands.AvailableCommands = append(commands.AvailableCommands, CmdFuzz)
+}
+
+func RunFuzz(cmd *commands.Command, args []string) int {
+	appPath = utils.GetCgearWorkPath()
+	buildPath = filepath.Join(appPath, "build")
+	fuzzPath = filepath.Join(appPath, "bin", "fuzz")
+
+	if len(args) > 1 {
+		if err := cmd.Flag.Parse(args[1:]); err != nil {
+			logger.Log.Fatal("Parse args err" + err.Error())
+		}
+	}
+
+	var target string
+	if len(args) > 0 {
+		target = args[0]
+	}
+	if target == "" && !regress {
+		logger.Log.Fatal("Argument [target] is missing")
+	}
+
+	configArg := cmake.ConfigArg{
+		Toolchain:             config.Conf.Toolchain,
+		Platform:              config.Conf.Platform,
+		BuildType:             config.Conf.BuildType,
+		Generator:             config.Conf.Generator,
+		NoWarnUnusedCli:       true,
+		ExportCompileCommands: true,
+		ProjectPath:           appPath,
+		BuildPath:             buildPath,
+		CXXFlags:              "-D_MD",
+	}
+
+	buildArg := cmake.BuildArg{
+		BuildPath: buildPath,
+		BuildType: config.Conf.BuildType,
+		IsMSVC:    configArg.Toolchain != nil && configArg.Toolchain.IsMSVC,
+	}
+
+	if err := cmake.Build(&configArg, &buildArg, rebuild, false); err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+
+	if regress {
+		return runRegress(target)
+	}
+
+	return runFuzz(target)
+}
+
+// runFuzz 运行 libFuzzer, 并报告新发现的崩溃输入
+func runFuzz(target string) int {
+	if config.Conf.Toolchain != nil && !strings.Contains(strings.ToLower(config.Conf.Toolchain.Compiler.CXX), "clang") {
+		logger.Log.Warnf("Toolchain '%s' is not Clang, the target is built without libFuzzer and can only replay inputs", config.Conf.Toolchain.Name)
+	}
+
+	program := programOf(target)
+	if !utils.IsExist(program) {
+		logger.Log.Fatalf("Fuzz target '%s' not found, create one with 'cgear new %s' and select Fuzz target", program, target)
+	}
+
+	if corpus == "" {
+		corpus = corpusPath(target)
+	}
+	crashes := crashesPath(target)
+	os.MkdirAll(corpus, 0755)
+	os.MkdirAll(crashes, 0755)
+
+	before := make(map[string]bool)
+	for _, input := range fuzzer.Inputs(crashes) {
+		before[input] = true
+	}
+
+	args := []string{
+		"-max_total_time=" + strconv.Itoa(int(math.Ceil(duration.Seconds()))),
+		"-artifact_prefix=" + crashes + string(filepath.Separator),
+		corpus,
+	}
+
+	fuzzCmd := exec.Command(program, args...)
+	fuzzCmd.Dir = appPath
+	fuzzCmd.Stdout = os.Stdout
+	fuzzCmd.Stderr = os.Stderr
+	logger.Log.Infof("Fuzzing %s for %s", target, duration)
+	err := fuzzCmd.Run()
+
+	var found []string
+	for _, input := range fuzzer.Inputs(crashes) {
+		if !before[input] {
+			found = append(found, input)
+		}
+	}
+
+	fmt.Println()
+	logger.Log.Infof("Corpus of %s has %d inputs", target, len(fuzzer.Inputs(corpus)))
+	if len(found) == 0 && err == nil {
+		logger.Log.Success("No crashes found")
+		return 0
+	}
+
+	for _, input := range found {
+		logger.Log.Errorf("Crashing input: %s", input)
+	}
+	if len(found) > 0 {
+		fmt.Printf("    reproduce: %s %s\n", program, found[0])
+		fmt.Printf("    regress:   cgear fuzz %s -regress\n", target)
+	} else {
+		logger.Log.Errorf("Fuzz target %s exited with %s", target, err)
+	}
+	return 1
+}
+
+// runRegress 把语料和崩溃输入各回放一次, 每个输入作为一个测试用例报告
+func runRegress(target string) int {
+	targets := []string{target}
+	if target == "" {
+		targets = findTargets()
+	}
+	if len(targets) == 0 {
+		logger.Log.Fatalf("No fuzz targets found in %s", fuzzPath)
+	}
+
+	var cases []tester.Case
+	for _, t := range targets {
+		program := programOf(t)
+		if !utils.IsExist(program) {
+			logger.Log.Fatalf("Fuzz target '%s' not found", program)
+		}
+
+		dirs := []string{corpusPath(t), crashesPath(t)}
+		if corpus != "" && target != "" {
+			dirs[0] = corpus
+		}
+
+		inputs := fuzzer.Inputs(dirs...)
+		logger.Log.Infof("Replaying %d inputs of %s", len(inputs), t)
+		for _, result := range fuzzer.Replay(program, inputs) {
+			c := tester.Case{Suite: t + "_fuzz", Name: relative(result.Input), Status: tester.StatusPassed, Time: result.Time, Binary: t + "_fuzz"}
+			if !result.Passed {
+				c.Status = tester.StatusFailed
+				c.Message = string(result.Output)
+				fmt.Println(colors.RedBold("==== " + c.FullName() + " ===="))
+				os.Stdout.Write(result.Output)
+			}
+			cases = append(cases, c)
+		}
+	}
+
+	junitPath := filepath.Join(buildPath, "test-results", "fuzz.xml")
+	if err := tester.WriteJUnit(junitPath, cases); err != nil {
+		logger.Log.Errorf("Failed to write JUnit report: %s", err)
+	} else {
+		logger.Log.Infof("JUnit report written to: %s", junitPath)
+	}
+
+	summary := tester.Summarize(cases)
+	if summary.Failed > 0 {
+		logger.Log.Errorf("%d inputs passed, %d failed", summary.Passed, summary.Failed)
+		return 1
+	}
+
+	logger.Log.Successf("%d inputs passed, %d failed", summary.Passed, summary.Failed)
+	return 0
+}
+
+// findTargets 查找 bin/fuzz 下的所有 fuzz 目标
+func findTargets() []string {
+	var targets []string
+	filepath.Walk(fuzzPath, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(info.Name(), "_fuzz.exe") {
+			targets = append(targets, strings.TrimSuffix(info.Name(), "_fuzz.exe"))
+		}
+		return nil
+	})
+
+	return targets
+}
+
+func programOf(target string) string {
+	return filepath.Join(fuzzPath, target+"_fuzz.exe")
+}
+
+func corpusPath(target string) string {
+	return filepath.Join(appPath, "fuzz", "corpus", target)
+}
+
+func crashesPath(target string) string {
+	return filepath.Join(appPath, "fuzz", "crashes", target)
+}
+
+// relative 返回相对项目目录的路径, 作为报告中的用例名
+func relative(path string) string {
+	if rel, err := filepath.Rel(appPath, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
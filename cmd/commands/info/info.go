@@ -0,0 +1,103 @@
+package info
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zelviner/cgear/cmd/commands"
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/deps"
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/logger/colors"
+	"github.com/zelviner/cgear/utils"
+)
+
+var CmdInfo = &commands.Command{
+	UsageLine: "info <package> [-files] [-triplet=x64-windows]",
+	Short:     "Show the details of an installed package",
+	Long: `
+Info shows the source, revision, options and installed files of a package in CGEAR_HOME/installed/<triplet>.
+
+  {{"Example:"|bold}}
+    $ cgear info fmt           # Show the details of fmt
+    $ cgear info fmt -files    # Also list every installed file
+`,
+	PreRun: func(cmd *commands.Command, args []string) {},
+	Run:    RunInfo,
+}
+
+var (
+	showFiles bool   // 列出所有安装的文件
+	triplet   string // 安装目录名
+)
+
+func init() {
+	CmdInfo.Flag.BoolVar(&showFiles, "files", false, "List all installed files, default false")
+	CmdInfo.Flag.StringVar(&triplet, "triplet", "", "Installed triplet, default the platform of the project")
+	commands.AvailableCommands = append(commands.AvailableCommands, CmdInfo)
+}
+
+func RunInfo(cmd *commands.Command, args []string) int {
+	if len(args) > 1 {
+		if err := cmd.Flag.Parse(args[1:]); err != nil {
+			logger.Log.Fatal("Parse args err" + err.Error())
+		}
+	}
+	if len(args) == 0 {
+		logger.Log.Fatal("Argument <package> is missing")
+	}
+
+	if triplet == "" {
+		triplet = deps.Triplet(config.Conf.Platform)
+	}
+	prefix := filepath.Join(utils.GetCgearInstalledPath(), triplet)
+
+	record, err := deps.ReadRecord(prefix, args[0])
+	if err != nil {
+		logger.Log.Fatalf("Package '%s' is not installed in %s", args[0], prefix)
+	}
+	dependents, _ := deps.Dependents(prefix, record.Name)
+
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Printf("    %-14s %s\n", name+":", value)
+		}
+	}
+
+	fmt.Println(colors.Bold(record.Name))
+	field("Source", record.Source)
+	field("URL", record.URL)
+	field("Version", record.Version)
+	field("Revision", record.Revision)
+	field("Build types", strings.Join(record.BuildTypes, ", "))
+	field("Components", strings.Join(record.Components, ", "))
+	field("Options", formatOptions(record.Options))
+	field("Depends on", strings.Join(record.Dependencies, ", "))
+	field("Required by", strings.Join(dependents, ", "))
+	field("Installed", record.Time.Format("2006-01-02 15:04:05"))
+	field("Prefix", prefix)
+	field("Files", fmt.Sprintf("%d", len(record.Files)))
+
+	if showFiles {
+		for _, file := range record.Files {
+			fmt.Println("        " + file)
+		}
+	}
+	return 0
+}
+
+func formatOptions(options map[string]string) string {
+	var names []string
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var pairs []string
+	for _, name := range names {
+		pairs = append(pairs, name+"="+options[name])
+	}
+	return strings.Join(pairs, " ")
+}
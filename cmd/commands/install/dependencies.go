@@ -0,0 +1,112 @@
+package install
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/deps"
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/utils"
+)
+
+// installDependencies 安装项目声明的所有依赖, 并把解析出的版本写入 cgear.lock
+func installDependencies() int {
+	if err := deps.Validate(config.Conf.Dependencies); err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+
+	lockPath := filepath.Join(utils.GetCgearWorkPath(), deps.LockFile)
+	lock, err := deps.ReadLock(lockPath)
+	if err != nil {
+		logger.Log.Fatalf("Failed to read %s: %s", deps.LockFile, err)
+	}
+	if locked && len(lock.Packages) == 0 {
+		logger.Log.Fatalf("%s not found, run 'cgear install' first", deps.LockFile)
+	}
+
+	installer := deps.NewInstaller()
+	installer.Force = reinstall
+	installer.Jobs = jobs
+	if noCache {
+		installer.Cache = nil
+	}
+	if offline {
+		vendor, err := deps.ReadVendor(filepath.Join(utils.GetCgearWorkPath(), deps.VendorDir))
+		if err != nil {
+			logger.Log.Fatal(err.Error())
+		}
+		installer.Vendor = vendor
+	}
+	if graph || dot {
+		return printGraph(installer, config.Conf.Dependencies, lock)
+	}
+
+	installed, upToDate, err := installer.Sync(config.Conf.Dependencies, lock, locked)
+	if err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+
+	if !locked {
+		if err := lock.Save(lockPath); err != nil {
+			logger.Log.Fatalf("Failed to write %s: %s", deps.LockFile, err)
+		}
+	}
+
+	logger.Log.Successf("%d dependencies installed into %s, %d up to date", installed, installer.Prefix(), upToDate)
+	return 0
+}
+
+// installPackage 安装命令行指定的包, 包名取自仓库名
+func installPackage(spec string) int {
+	if offline {
+		logger.Log.Fatal("-offline installs the dependencies of the project from vendor/, add the package to cgear.json and run 'cgear vendor'")
+	}
+
+	source, err := deps.ParseSource(spec)
+	if err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+
+	dep := config.Dependency{Name: source.Name(), Source: spec}
+	if err := deps.Validate([]config.Dependency{dep}); err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+
+	installer := deps.NewInstaller()
+	installer.Force = reinstall
+	installer.Jobs = jobs
+	if noCache {
+		installer.Cache = nil
+	}
+	if graph || dot {
+		return printGraph(installer, []config.Dependency{dep}, &deps.Lock{})
+	}
+
+	installed, _, err := installer.Sync([]config.Dependency{dep}, &deps.Lock{}, false)
+	if err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+
+	if installed == 0 {
+		logger.Log.Infof("'%s' is already installed, use -r to reinstall", dep.Name)
+	}
+	return 0
+}
+
+// printGraph 下载依赖并输出依赖图, 不构建任何包
+func printGraph(installer *deps.Installer, dependencies []config.Dependency, lock *deps.Lock) int {
+	installer.ShowInfo = false
+	g, err := installer.Graph(dependencies, lock, locked)
+	if err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+
+	root := filepath.Base(utils.GetCgearWorkPath())
+	if dot {
+		g.WriteDOT(os.Stdout, root)
+	} else {
+		g.WriteText(os.Stdout, root)
+	}
+	return 0
+}
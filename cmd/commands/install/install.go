@@ -1,41 +1,139 @@
 package install
 
 import (
-	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
+	"strings"
+	"time"
 
-	"github.com/charmbracelet/bubbles/list"
-	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 	"github.com/zelviner/cgear/cmake"
 	"github.com/zelviner/cgear/cmd/commands"
 	"github.com/zelviner/cgear/cmd/commands/version"
 	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/deps"
 	"github.com/zelviner/cgear/logger"
-	"github.com/zelviner/cgear/logger/colors"
 	"github.com/zelviner/cgear/utils"
 )
 
 // CmdInstall represents the install command
 var CmdInstall = &commands.Command{
-	UsageLine: "install [package]",
+	UsageLine: "install [package] [-r] [-j=4] [-locked] [-offline] [-no-cache] [-graph] [-dot] [-name=<name>] [-lib=<dir>] [-debug-lib=<dir>]",
 	Short:     "Downloading and installing C++ third-party open source libraries from GitHub",
 	Long: `
-Install downloads and compiles C++ third-party libraries from GitHub.
+Install downloads and compiles C++ third-party libraries from git repositories.
+  Inside a project, the libraries listed in "dependencies" of cgear.json or Cgearfile are installed.
+  Without dependencies, the project in the current directory is installed as a library.
+
+  A dependency has a name, a source and optionally a version (git tag, branch, commit or a
+  constraint such as ^10.1, see 'cgear help outdated'), CMake options and the install components.
+  The resolved commits are recorded in cgear.lock, later installs use the locked commits until
+  'cgear update' moves them forward:
+
+    "dependencies": [
+      {"name": "fmt", "source": "fmtlib:fmt", "version": "10.2.1", "options": {"FMT_TEST": "OFF"}}
+    ]
+
+  Sources can be written as:
+    author:repository                 GitHub repository over SSH
+    author/repository@v1.2.3          GitHub repository over HTTPS at a tag
+    gitlab.com/group/repository       Repository on another host over HTTPS
+    https://host/repo.git#main        Any https://, ssh://, git:// or file:// URL, or git@host:repo
+    ../libs/json#3f2a9c1              Local repository
+  A #branch or #<commit> suffix selects the revision.
+
+  Libraries published only as release archives are declared with a url to a .tar.gz, .tar.xz or .zip
+  and its sha256. The archive is verified and unpacked into CGEAR_HOME/pkg/<name>-<version>.
+  Patches in the git diff format are applied to the sources before building:
+
+    {"name": "json", "url": "https://example.com/json-3.11.3.tar.xz", "version": "3.11.3",
+     "sha256": "d6c65aca6b1ed68e7a182f4757257b107ae403032760ed6ef121c9d55e81757d",
+     "patches": ["patches/json-install.patch"]}
+
+  "options" are passed to CMake as cache variables, "build_type_options" override them for the
+  Debug or Release build. Libraries that do not use CMake declare "build" commands instead, they run
+  in the source directory with CGEAR_SOURCE_DIR, CGEAR_BUILD_DIR, CGEAR_INSTALL_DIR, CGEAR_PREFIX_PATH,
+  CGEAR_BUILD_TYPE and the options in the environment, and install into CGEAR_INSTALL_DIR:
+
+    {"name": "fmt", "source": "fmtlib:fmt", "options": {"FMT_TEST": "OFF", "FMT_DOC": "OFF"},
+     "build_type_options": {"Debug": {"CMAKE_DEBUG_POSTFIX": "d"}}}
+    {"name": "lua", "url": "https://www.lua.org/ftp/lua-5.4.6.tar.gz", "sha256": "...",
+     "build": ["make posix", "make install INSTALL_TOP=$CGEAR_INSTALL_DIR"]}
+
+  A library is built again when its options, patches or build commands change.
+
+  The dependencies declared by the cgear.json, Cgearfile or vcpkg.json of each library are installed too,
+  before the libraries that need them. The dependencies of the project take precedence, two libraries
+  requiring different versions of the same library or a dependency cycle stop the install.
+
+  Libraries that do not depend on each other, and the Debug and Release builds of each library,
+  are built in parallel. The output of each build is written to CGEAR_HOME/logs/<triplet>/<name>-<type>.log.
+
+  Built libraries are stored in a binary cache, keyed by library, commit, toolchain, platform,
+  build type, options, patches, build commands and the commits of its dependencies. A build with
+  the same key is restored from the cache instead of being built again. The cache is CGEAR_HOME/cache
+  by default, set CGEAR_CACHE to use another directory, such as a network share, or an HTTP server
+  that accepts GET, PUT and DELETE. See 'cgear help cache'.
+
+  Without network access, run 'cgear vendor' on a connected machine to save the sources of all
+  dependencies into vendor/, then 'cgear install -offline' installs them from vendor/ only.
+  "mirrors" replaces the beginning of source and archive URLs, for example with an internal git
+  server or a local directory. CGEAR_MIRRORS=from=to,from=to takes precedence over the project:
+
+    "mirrors": {"https://github.com/": "https://git.example.com/github/",
+                "git@github.com:": "/srv/mirror/github/"}
+
+  A local directory that is not a git repository is installed as a prebuilt library. Its include,
+  lib, debug/lib, bin and debug/bin directories are used unless -include, -lib, -debug-lib, -bin or
+  -debug-bin name others, relative to the directory. Without libraries it is header-only, without
+  debug libraries the Debug build links the release libraries. Without debug DLLs the release
+  DLLs are copied to debug/bin too, since Debug runs only put debug/bin on PATH. cgear generates
+  lib/cmake/<name>/<name>Config.cmake with an IMPORTED target <name>::<library> for each library
+  and <name>::<name> for all of them, and lib/pkgconfig/<name>.pc:
+
+    cgear install D:/sdk/foo -name=foo -version=2.1 -lib=lib/x64/Release -debug-lib=lib/x64/Debug
+    find_package(foo CONFIG REQUIRED)
+    target_link_libraries(${APP_NAME} PRIVATE foo::foo)
+
 Usage:
-    cgear install                     # Install in current directory
+    cgear install                     # Install the dependencies of the project, or the project itself
+    cgear install -r                  # Reinstall the dependencies even if they are up to date
+    cgear install -j 2                # Build at most two libraries at the same time
+    cgear install -locked             # Install exactly the revisions recorded in cgear.lock
+    cgear install -offline            # Install from the sources saved by 'cgear vendor'
+    cgear install -no-cache           # Build every library from source
+    cgear install -graph              # Print the dependency graph without building anything
+    cgear install -dot | dot -Tsvg    # Print the dependency graph in the DOT format of Graphviz
     cgear install author:repository   # Install specific repository
+    cgear install fmtlib/fmt@10.2.1   # Install specific repository at a tag
+    cgear install /opt/foo -name=foo  # Install a prebuilt library
 `,
-	PreRun: func(cmd *commands.Command, args []string) { version.ShowShortVersionBanner() },
-	Run:    install,
+	PreRun: func(cmd *commands.Command, args []string) {
+		if !dot {
+			version.ShowShortVersionBanner()
+		}
+	},
+	Run: install,
 }
 
 var (
-	vendorPath     string
-	vendorInfo     string
-	repositoryName string
+	vendorPath string
+	vendorInfo string
+	reinstall  bool // 重新安装已安装的依赖
+	jobs       int  // 同时进行的构建数
+	noCache    bool // 不使用二进制缓存
+	locked     bool // 只安装 cgear.lock 中记录的版本
+	offline    bool // 只从 vendor 目录安装
+	graph      bool // 只输出依赖图
+	dot        bool // 以 DOT 格式输出依赖图
+
+	prebuiltName    string // 预编译库的包名
+	prebuiltVersion string // 预编译库的版本
+	triplet         string // 预编译库的安装目录名
+	includeDir      string // 预编译库的头文件目录
+	libDir          string // 预编译库的 Release 库目录
+	debugLibDir     string // 预编译库的 Debug 库目录
+	binDir          string // 预编译库的 Release 运行时库目录
+	debugBinDir     string // 预编译库的 Debug 运行时库目录
 
 	cgearHome      = utils.GetCgearHomePath()
 	cgearPkg       = utils.GetCgearPkgPath()
@@ -43,25 +141,54 @@ var (
 )
 
 func init() {
+	CmdInstall.Flag.BoolVar(&reinstall, "r", false, "Reinstall dependencies that are already installed, default false")
+	CmdInstall.Flag.IntVar(&jobs, "j", 0, "Number of builds to run at the same time, default the number of CPUs")
+	CmdInstall.Flag.BoolVar(&noCache, "no-cache", false, "Do not restore from or upload to the binary cache, default false")
+	CmdInstall.Flag.BoolVar(&locked, "locked", false, "Install exactly the revisions in cgear.lock and fail if it is out of date, default false")
+	CmdInstall.Flag.BoolVar(&offline, "offline", false, "Install from the vendor directory without network access, default false")
+	CmdInstall.Flag.BoolVar(&graph, "graph", false, "Print the dependency graph as a tree and exit without building, default false")
+	CmdInstall.Flag.BoolVar(&dot, "dot", false, "Print the dependency graph in the DOT format and exit without building, default false")
+	CmdInstall.Flag.StringVar(&prebuiltName, "name", "", "Package name of a prebuilt library, default the directory name")
+	CmdInstall.Flag.StringVar(&prebuiltVersion, "version", "", "Version of a prebuilt library")
+	CmdInstall.Flag.StringVar(&triplet, "triplet", "", "Installed triplet of a prebuilt library, default the platform of the project")
+	CmdInstall.Flag.StringVar(&includeDir, "include", "", "Header directory of a prebuilt library, default include")
+	CmdInstall.Flag.StringVar(&libDir, "lib", "", "Release library directory of a prebuilt library, default lib")
+	CmdInstall.Flag.StringVar(&debugLibDir, "debug-lib", "", "Debug library directory of a prebuilt library, default debug/lib")
+	CmdInstall.Flag.StringVar(&binDir, "bin", "", "Release DLL directory of a prebuilt library, default bin")
+	CmdInstall.Flag.StringVar(&debugBinDir, "debug-bin", "", "Debug DLL directory of a prebuilt library, default debug/bin")
 	commands.AvailableCommands = append(commands.AvailableCommands, CmdInstall)
 }
 
 func install(cmd *commands.Command, args []string) int {
 
+	if len(args) > 1 {
+		if err := cmd.Flag.Parse(args[1:]); err != nil {
+			logger.Log.Fatal("Parse args err" + err.Error())
+		}
+		if cmd.Flag.NArg() > 0 {
+			logger.Log.Fatal("Too many parameters")
+		}
+	}
+
 	switch len(args) {
 	case 0:
+		if len(config.Conf.Dependencies) > 0 {
+			return installDependencies()
+		}
+		if graph || dot {
+			logger.Log.Info("The project has no dependencies")
+			return 0
+		}
 		vendorPath = utils.GetCgearWorkPath()
 		vendorInfo = filepath.Base(vendorPath)
-	case 1:
-		cmd.Flag.Parse(args[1:])
+	default:
 		vendorInfo = args[0]
-		if filepath.IsAbs(vendorInfo) {
+		// 含有 include 或 lib 目录的本地目录是预编译的库, 否则是源码仓库
+		if isPrebuilt(vendorInfo) {
 			releaseInstall()
 			return 0
 		}
-		getPKG(true)
-	default:
-		logger.Log.Fatal("Too many parameters")
+		return installPackage(vendorInfo)
 	}
 
 	logger.Log.Infof("Installing '%s' ...", vendorInfo)
@@ -74,63 +201,11 @@ func install(cmd *commands.Command, args []string) int {
 	return 0
 }
 
-func getPKG(showInfo bool) {
-
-	re, err := regexp.Compile("(.+):(.+)")
-	if err != nil {
-		logger.Log.Fatal(err.Error())
-	}
-
-	if !re.MatchString(vendorInfo) {
-		logger.Log.Fatal("Please specify the correct third-party library information, for example: google:googletest")
-	}
-
-	Author := re.FindStringSubmatch(vendorInfo)[1]
-	repositoryName = re.FindStringSubmatch(vendorInfo)[2]
-
-	ssh := "git@github.com:" + Author + "/" + repositoryName
-	vendorPath = filepath.Join(cgearPkg, repositoryName)
-
-	if utils.IsExist(vendorPath) {
-		logger.Log.Errorf(colors.Bold("%s '%s' already exists"), vendorInfo, vendorPath)
-		logger.Log.Warn(colors.Bold("Do you want to update it? [Yes]|No "))
-		if utils.AskForConfirmation() {
-			logger.Log.Infof("'%s' already exists, updating ...", vendorInfo)
-			os.RemoveAll(vendorPath)
-		} else {
-			return
-		}
-	}
-
-	err = downloadPKG(ssh, vendorPath, showInfo)
-	if err != nil {
-		logger.Log.Fatal(err.Error())
-	}
-}
-
-// DownloadPKG downloads a package from GitHub using git clone
-// ssh: GitHub SSH URL
-// vendorPath: local path to store the package
-// showInfo: whether to show download progress
-func downloadPKG(ssh string, vendorPath string, showInfo bool) error {
-
-	logger.Log.Info("Downloading third-party libraries: " + repositoryName)
-
-	command := exec.Command("git", "clone", ssh, vendorPath, "--depth=1")
-	if showInfo {
-		command.Stdout = os.Stdout
-		command.Stderr = os.Stderr
-	}
-	err := command.Run()
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
 func compileInstall(showInfo bool) error {
-	// debug compile
+	prefix := filepath.Join(cgearInstalled, deps.Triplet(config.Conf.Platform))
+	record := &deps.Record{Name: vendorInfo, Source: vendorPath, URL: vendorPath, BuildTypes: deps.BuildTypes, Time: time.Now()}
+
+	// debug compile, Debug 版本安装到 <triplet>/debug 下
 	buildPath := filepath.Join(vendorPath, "build")
 	configArg := cmake.ConfigArg{
 		Toolchain:             config.Conf.Toolchain,
@@ -142,6 +217,7 @@ func compileInstall(showInfo bool) error {
 		ProjectPath:           vendorPath,
 		BuildPath:             buildPath,
 		CXXFlags:              "-D_MD",
+		InstallPrefix:         filepath.Join(prefix, "debug"),
 	}
 
 	buildArg := cmake.BuildArg{
@@ -153,131 +229,98 @@ func compileInstall(showInfo bool) error {
 	if err != nil {
 		return err
 	}
+	files, err := deps.InstalledFiles(buildPath, prefix)
+	if err != nil {
+		return err
+	}
+	record.Files = append(record.Files, files...)
 
 	// release compile
 	configArg.BuildType = "Release"
+	configArg.InstallPrefix = prefix
 	err = cmake.Build(&configArg, &buildArg, true, showInfo)
 	if err != nil {
 		return err
 	}
-
-	return nil
-}
-
-// 自定义列表项
-type archItem struct {
-	title, desc string
-}
-
-func (i archItem) Title() string       { return i.title }
-func (i archItem) Description() string { return i.desc }
-func (i archItem) FilterValue() string { return i.title }
-
-// 主模型
-type archModel struct {
-	list   list.Model
-	choice string
-}
-
-// 初始化模型
-func newArchModel() archModel {
-	items := []list.Item{
-		archItem{
-			title: "x86-windows",
-			desc:  "32位 Windows 架构（推荐）",
-		},
-		archItem{
-			title: "x64-windows",
-			desc:  "64位 Windows 架构",
-		},
+	files, err = deps.InstalledFiles(buildPath, prefix)
+	if err != nil {
+		return err
 	}
+	record.Files = append(record.Files, files...)
 
-	l := list.New(items, list.NewDefaultDelegate(), 40, 14)
-	l.Title = "请选择目标架构"
-	l.Styles.Title = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FFFFFF")).
-		Background(lipgloss.Color("#3C3C3C")).
-		Padding(0, 1)
-
-	return archModel{list: l}
-}
-
-func (m archModel) Init() tea.Cmd { return nil }
-
-func (m archModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "enter":
-			if selected, ok := m.list.SelectedItem().(archItem); ok {
-				m.choice = selected.title
-			}
-			return m, tea.Quit
-		case "ctrl+c", "q":
-			return m, tea.Quit
+	// 记录安装的文件, 以便 cgear uninstall 删除
+	if revision, err := exec.Command("git", "-C", vendorPath, "rev-parse", "HEAD").Output(); err == nil {
+		record.Revision = strings.TrimSpace(string(revision))
+	}
+	if manifest, err := deps.ReadManifest(vendorPath); err == nil && manifest != nil {
+		for _, d := range manifest.Dependencies {
+			record.Dependencies = append(record.Dependencies, d.Name)
 		}
-	case tea.WindowSizeMsg:
-		m.list.SetWidth(msg.Width)
-		return m, nil
 	}
-
-	var cmd tea.Cmd
-	m.list, cmd = m.list.Update(msg)
-	return m, cmd
+	record.Hash = deps.HashFiles(prefix, record.Files)
+	return deps.WriteRecord(prefix, record)
 }
 
-func (m archModel) View() string {
-	return "\n" + m.list.View()
+// isPrebuilt 判断 dir 是否为预编译的库: 不是 git 仓库的本地目录, 并且给出了预编译库的目录参数或含有 include 或 lib 目录。
+// 相对路径以当前目录为基准
+func isPrebuilt(dir string) bool {
+	dir, err := filepath.Abs(dir)
+	if err != nil || !utils.IsExist(dir) || utils.IsExist(filepath.Join(dir, ".git")) {
+		return false
+	}
+	if includeDir != "" || libDir != "" || debugLibDir != "" || binDir != "" || debugBinDir != "" {
+		return true
+	}
+	return utils.IsExist(filepath.Join(dir, "include")) || utils.IsExist(filepath.Join(dir, "lib"))
 }
 
-// 使用选择器的函数
-func selectArch() string {
-	p := tea.NewProgram(newArchModel())
-	m, err := p.Run()
-	if err != nil {
-		logger.Log.Fatalf("选择架构失败:", err)
+// prebuiltDir 返回预编译库的一个目录: flag 给出的目录, 相对路径以库所在的目录为基准; 没有给出时是库中存在的 fallback 目录
+func prebuiltDir(root, flag, fallback string) string {
+	if flag != "" {
+		if !filepath.IsAbs(flag) {
+			flag = filepath.Join(root, flag)
+		}
+		if !utils.IsExist(flag) {
+			logger.Log.Fatalf("Directory not found: %s", flag)
+		}
+		return flag
 	}
-
-	if model, ok := m.(archModel); ok && model.choice != "" {
-		return model.choice
+	if fallback != "" && utils.IsExist(filepath.Join(root, fallback)) {
+		return filepath.Join(root, fallback)
 	}
-	logger.Log.Fatal("未选择架构")
 	return ""
 }
 
+// releaseInstall 安装预编译的库, 并生成 CMake 包配置和 pkg-config 文件
 func releaseInstall() {
-
-	// 检测 vendorInfo 是否存在
-	if !utils.IsExist(vendorInfo) {
-		logger.Log.Fatal("Third-party library not found: " + vendorInfo)
+	root, err := filepath.Abs(vendorInfo)
+	if err != nil {
+		logger.Log.Fatal(err.Error())
 	}
 
-	// 检测 vendorInfo/include 和 vendorInfo/lib 是否存在
-	includePath := filepath.Join(vendorInfo, "include")
-	libPath := filepath.Join(vendorInfo, "lib")
-	if !utils.IsExist(includePath) || !utils.IsExist(libPath) {
-		logger.Log.Fatalf("%s is not a third-party library", vendorInfo)
+	prebuilt := &deps.Prebuilt{
+		Name:     prebuiltName,
+		Source:   root,
+		Version:  prebuiltVersion,
+		Include:  prebuiltDir(root, includeDir, "include"),
+		Lib:      prebuiltDir(root, libDir, "lib"),
+		DebugLib: prebuiltDir(root, debugLibDir, filepath.Join("debug", "lib")),
+		Bin:      prebuiltDir(root, binDir, "bin"),
+		DebugBin: prebuiltDir(root, debugBinDir, filepath.Join("debug", "bin")),
 	}
-
-	repositoryName = filepath.Base(vendorInfo)
-	logger.Log.Info("Installing third-party libraries: " + vendorInfo)
-	logger.Log.Infof("Please set the third-party library name (default: %s):", repositoryName)
-	temp := utils.ReadLine()
-	if temp != "" {
-		repositoryName = temp
+	if prebuilt.Name == "" {
+		prebuilt.Name = filepath.Base(root)
+	}
+	if triplet == "" {
+		triplet = deps.Triplet(config.Conf.Platform)
 	}
 
-	// 调用选择器
-	archDir := selectArch()
-
-	// 拷贝 vendorInfo 下的 include 和 lib 目录到 releasePath 下
-	releasePath := filepath.Join(cgearInstalled, archDir)
-	utils.CopyDir(includePath, filepath.Join(releasePath, "include", repositoryName))
-	utils.CopyDir(libPath, filepath.Join(releasePath, "lib", repositoryName))
-
-	// 拷贝 vendorInfo 下的 include 和 lib 目录到 debugPath 下
-	debugPath := filepath.Join(releasePath, "debug")
-	utils.CopyDir(libPath, filepath.Join(debugPath, "lib", repositoryName))
+	logger.Log.Infof("Installing prebuilt library '%s' from %s ...", prebuilt.Name, root)
+	prefix := filepath.Join(cgearInstalled, triplet)
+	record, err := deps.InstallPrebuilt(prefix, prebuilt)
+	if err != nil {
+		logger.Log.Fatal(err.Error())
+	}
 
-	logger.Log.Successf("Successfully installed '%s'", repositoryName)
+	logger.Log.Successf("Successfully installed '%s' into %s, %d files, use find_package(%s CONFIG REQUIRED)", prebuilt.Name, prefix, len(record.Files), prebuilt.Name)
 }
@@ -0,0 +1,89 @@
+package list
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/zelviner/cgear/cmd/commands"
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/deps"
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/utils"
+)
+
+var CmdList = &commands.Command{
+	UsageLine: "list [-json] [-triplet=x64-windows]",
+	Short:     "List the packages installed in CGEAR_HOME",
+	Long: `
+List shows the packages installed under CGEAR_HOME/installed/<triplet>, with their versions and sources.
+
+  {{"Example:"|bold}}
+    $ cgear list                        # List the packages of the project platform
+    $ cgear list -json                  # Print the package database as JSON
+    $ cgear list -triplet x86-windows   # List the packages of another platform
+`,
+	PreRun: func(cmd *commands.Command, args []string) {},
+	Run:    RunList,
+}
+
+var (
+	asJSON  bool   // 以 JSON 输出
+	triplet string // 安装目录名
+)
+
+func init() {
+	CmdList.Flag.BoolVar(&asJSON, "json", false, "Print the installed packages as JSON, default false")
+	CmdList.Flag.StringVar(&triplet, "triplet", "", "Installed triplet to list, default the platform of the project")
+	commands.AvailableCommands = append(commands.AvailableCommands, CmdList)
+}
+
+func RunList(cmd *commands.Command, args []string) int {
+	if triplet == "" {
+		triplet = deps.Triplet(config.Conf.Platform)
+	}
+	prefix := filepath.Join(utils.GetCgearInstalledPath(), triplet)
+
+	records, err := deps.List(prefix)
+	if err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+
+	if asJSON {
+		if records == nil {
+			records = []*deps.Record{}
+		}
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			logger.Log.Fatal(err.Error())
+		}
+		fmt.Println(string(data))
+		return 0
+	}
+
+	if len(records) == 0 {
+		logger.Log.Infof("No packages installed in %s", prefix)
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVERSION\tREVISION\tFILES\tSOURCE")
+	for _, record := range records {
+		version := record.Version
+		if version == "" {
+			version = "-"
+		}
+		revision := record.Revision
+		if len(revision) > 10 {
+			revision = revision[:10]
+		}
+		if revision == "" {
+			revision = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", record.Name, version, revision, len(record.Files), record.Source)
+	}
+	w.Flush()
+	return 0
+}
@@ -0,0 +1,302 @@
+package mutate
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/zelviner/cgear/cmake"
+	"github.com/zelviner/cgear/cmd/commands"
+	"github.com/zelviner/cgear/cmd/commands/version"
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/logger/colors"
+	"github.com/zelviner/cgear/mutator"
+	"github.com/zelviner/cgear/utils"
+)
+
+var CmdMutate = &commands.Command{
+	UsageLine: "mutate [path] [-max=N] [-timeout=duration]",
+	Short:     "Measure the quality of the unit tests with mutation testing",
+	Long: `
+Mutate applies small changes to the sources under src/, one at a time, in a scratch copy of the project.
+  For each mutant the project is rebuilt and 'cgear test' is run. A mutant is killed when a test fails,
+  and survives when all tests pass. Mutants that do not compile are not counted.
+
+  Mutations: flip comparison operators, swap && and ||, replace integer and boolean constants, delete statements.
+  Reports are written to build/mutation/mutation.json and build/mutation/mutation.html.
+
+  {{"Example:"|bold}}
+    $ cgear mutate                      # Mutate all sources under src/
+    $ cgear mutate src/json             # Mutate the sources of one directory
+    $ cgear mutate src/json/parser.cpp  # Mutate a single file
+    $ cgear mutate -max 50              # Test at most 50 mutants spread over the sources
+`,
+	PreRun: func(cmd *commands.Command, args []string) { version.ShowShortVersionBanner() },
+	Run:    RunMutate,
+}
+
+var (
+	maxMutants int           // 最多测试的变异体数量
+	timeout    time.Duration // 每次运行测试的超时时间
+	appPath    string
+	workspace  string // 临时的项目副本
+)
+
+// 源文件扩展名
+var sourceExts = []string{".c", ".cc", ".cpp", ".cxx", ".h", ".hh", ".hpp", ".hxx"}
+
+// 不复制到临时副本中的目录
+var skipDirs = []string{".git", ".cgear", ".cache", "build", "build-release", "bin", "lib"}
+
+func init() {
+	CmdMutate.Flag.IntVar(&maxMutants, "max", 0, "Maximum number of mutants to test, 0 means all")
+	CmdMutate.Flag.DurationVar(&timeout, "timeout", 0, "Timeout of each test run, default 3 times the time of the unmutated run")
+	commands.AvailableCommands = append(commands.AvailableCommands, CmdMutate)
+}
+
+func RunMutate(cmd *commands.Command, args []string) int {
+	appPath = utils.GetCgearWorkPath()
+	workspace = filepath.Join(appPath, ".cgear", "mutate", "workspace")
+
+	if len(args) > 1 {
+		if err := cmd.Flag.Parse(args[1:]); err != nil {
+			logger.Log.Fatal("Parse args err" + err.Error())
+		}
+	}
+
+	target := "src"
+	if len(args) > 0 {
+		target = args[0]
+	}
+	path := target
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(appPath, path)
+	}
+	rel, err := filepath.Rel(appPath, path)
+	rel = filepath.ToSlash(rel)
+	if err != nil || (rel != "src" && !strings.HasPrefix(rel, "src/")) {
+		logger.Log.Fatalf("Path '%s' is not under src/", target)
+	}
+
+	mutants := generate(rel)
+	if len(mutants) == 0 {
+		logger.Log.Warnf("No mutants generated for %s", rel)
+		return 0
+	}
+	logger.Log.Infof("Generated %d mutants in %s", len(mutants), rel)
+
+	logger.Log.Infof("Copying the project to %s", workspace)
+	if err := copyProject(); err != nil {
+		logger.Log.Fatalf("Failed to copy the project: %s", err)
+	}
+
+	// 未变异的项目必须能通过测试, 并以其耗时确定超时时间
+	started := time.Now()
+	if err := build(); err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+	if err := runTests(0); err != nil {
+		logger.Log.Fatalf("Tests fail without mutations, fix them first: %s", err)
+	}
+	if timeout == 0 {
+		timeout = 3*time.Since(started) + 10*time.Second
+	}
+
+	var results []mutator.Result
+	for i, mutant := range mutants {
+		status := test(mutant)
+		results = append(results, mutator.Result{Mutant: mutant, Status: status})
+		fmt.Printf("    [%d/%d] %s:%d %s %s\n", i+1, len(mutants), mutant.File, mutant.Line, describe(mutant), statusText(status))
+	}
+
+	report := mutator.NewReport(results)
+	reportPath := filepath.Join(appPath, "build", "mutation")
+	if err := report.WriteJSON(filepath.Join(reportPath, "mutation.json")); err != nil {
+		logger.Log.Errorf("Failed to write JSON report: %s", err)
+	}
+	if err := report.WriteHTML(filepath.Join(reportPath, "mutation.html")); err != nil {
+		logger.Log.Errorf("Failed to write HTML report: %s", err)
+	}
+	logger.Log.Infof("Mutation reports written to: %s", reportPath)
+
+	showReport(report)
+	return 0
+}
+
+// generate 为 target 下的源文件生成变异体, 指定 -max 时在所有变异体中均匀抽取
+func generate(target string) []mutator.Mutant {
+	var mutants []mutator.Mutant
+	filepath.Walk(filepath.Join(appPath, filepath.FromSlash(target)), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !isSource(path) {
+			return nil
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(appPath, path)
+		mutants = append(mutants, mutator.Generate(filepath.ToSlash(rel), src)...)
+		return nil
+	})
+
+	if maxMutants > 0 && len(mutants) > maxMutants {
+		var sampled []mutator.Mutant
+		step := float64(len(mutants)) / float64(maxMutants)
+		for i := 0; i < maxMutants; i++ {
+			sampled = append(sampled, mutants[int(float64(i)*step)])
+		}
+		mutants = sampled
+	}
+
+	return mutants
+}
+
+// test 在临时副本中应用变异, 构建并运行测试, 之后恢复源文件
+func test(mutant mutator.Mutant) string {
+	path := filepath.Join(workspace, filepath.FromSlash(mutant.File))
+	original, err := os.ReadFile(path)
+	if err != nil {
+		logger.Log.Fatalf("Failed to read %s: %s", path, err)
+	}
+	defer os.WriteFile(path, original, 0644)
+
+	if err := os.WriteFile(path, mutator.Apply(original, mutant), 0644); err != nil {
+		logger.Log.Fatalf("Failed to write %s: %s", path, err)
+	}
+
+	if err := build(); err != nil {
+		return mutator.Invalid
+	}
+	if err := runTests(timeout); err != nil {
+		return mutator.Killed
+	}
+	return mutator.Survived
+}
+
+// build 使用项目的配置构建临时副本
+func build() error {
+	buildPath := filepath.Join(workspace, "build")
+
+	configArg := cmake.ConfigArg{
+		Toolchain:             config.Conf.Toolchain,
+		Platform:              config.Conf.Platform,
+		BuildType:             config.Conf.BuildType,
+		Generator:             config.Conf.Generator,
+		NoWarnUnusedCli:       true,
+		ExportCompileCommands: false,
+		ProjectPath:           workspace,
+		BuildPath:             buildPath,
+		CXXFlags:              "-D_MD",
+	}
+
+	buildArg := cmake.BuildArg{
+		BuildPath: buildPath,
+		BuildType: config.Conf.BuildType,
+		IsMSVC:    configArg.Toolchain != nil && configArg.Toolchain.IsMSVC,
+	}
+
+	return cmake.Build(&configArg, &buildArg, false, false)
+}
+
+// runTests 在临时副本中运行 cgear test, 有用例失败时返回错误
+func runTests(timeout time.Duration) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"test", "-report"}
+	if timeout > 0 {
+		args = append(args, "-binary-timeout="+timeout.String())
+	}
+
+	cmd := exec.Command(executable, args...)
+	cmd.Dir = workspace
+	return cmd.Run()
+}
+
+// copyProject 把项目复制到临时副本, 保留副本中的构建目录以便增量构建
+func copyProject() error {
+	entries, err := os.ReadDir(workspace)
+	if err == nil {
+		for _, entry := range entries {
+			if entry.Name() != "build" {
+				os.RemoveAll(filepath.Join(workspace, entry.Name()))
+			}
+		}
+	}
+
+	return filepath.Walk(appPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, _ := filepath.Rel(appPath, path)
+		if info.IsDir() {
+			for _, dir := range skipDirs {
+				if rel == dir {
+					return filepath.SkipDir
+				}
+			}
+			return os.MkdirAll(filepath.Join(workspace, rel), 0755)
+		}
+
+		_, err = utils.CopyFile(path, filepath.Join(workspace, rel))
+		return err
+	})
+}
+
+// showReport 打印存活的变异体和变异得分
+func showReport(report *mutator.Report) {
+	fmt.Println()
+	for _, file := range report.Files {
+		if len(file.Survived) == 0 {
+			continue
+		}
+		fmt.Println(`    ├── ` + colors.RedBold(file.File))
+		for _, result := range file.Survived {
+			fmt.Printf("    │    └── line %d: %s\n", result.Line, describe(result.Mutant))
+		}
+	}
+	fmt.Println()
+
+	message := fmt.Sprintf("Mutation score %.1f%%: %d killed, %d survived, %d did not compile",
+		report.Score*100, report.Killed, report.Survived, report.Invalid)
+	if report.Survived > 0 {
+		logger.Log.Warn(message)
+	} else {
+		logger.Log.Success(message)
+	}
+}
+
+func describe(mutant mutator.Mutant) string {
+	if mutant.Operator == mutator.Deletion {
+		return fmt.Sprintf("deleted '%s'", mutant.Original)
+	}
+	return fmt.Sprintf("'%s' -> '%s'", mutant.Original, mutant.Replacement)
+}
+
+func statusText(status string) string {
+	switch status {
+	case mutator.Killed:
+		return colors.GreenBold("KILLED")
+	case mutator.Survived:
+		return colors.RedBold("SURVIVED")
+	}
+	return colors.YellowBold("INVALID")
+}
+
+func isSource(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range sourceExts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
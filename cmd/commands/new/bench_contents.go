@@ -0,0 +1,40 @@
+package new
+
+var benchCMakeLists = `# [1] 基准测试配置 -------------------------------------------------
+set(CMAKE_RUNTIME_OUTPUT_DIRECTORY ${CMAKE_SOURCE_DIR}/bin/bench)
+
+# [2] 查找依赖 -----------------------------------------------------
+find_package(benchmark QUIET)
+if(NOT benchmark_FOUND)
+    message(STATUS "Google Benchmark not found, benchmarks are skipped")
+    return()
+endif()
+
+# [3] 添加基准测试目标 ----------------------------------------------
+function(add_benchmark name)
+    file(GLOB_RECURSE files ${name}/*.cpp)
+    add_executable(${name}_bench ${files})
+    target_include_directories(${name}_bench
+        PUBLIC
+            # 包含头文件目录
+            ${PROJECT_SOURCE_DIR}/bench
+    )
+    target_link_libraries(${name}_bench
+        PUBLIC
+            benchmark::benchmark_main
+            ${ARGN}
+    )
+endfunction(add_benchmark name)
+
+# [4] 添加具体基准测试 ----------------------------------------------
+`
+
+var benchContent = `#include <benchmark/benchmark.h>
+
+static void BM_{{ .benchName }}(benchmark::State &state) {
+    for (auto _ : state) {
+    }
+}
+
+BENCHMARK(BM_{{ .benchName }});
+`
@@ -1,7 +1,9 @@
 package new
 
 var gitignore = `.cache
+.cgear
 build
+build-release
 bin
 lib
 cgear.json
@@ -251,7 +253,13 @@ endif()
 
 # [6] 子目录添加 ----------------------------------------------------
 add_subdirectory(src)
-add_subdirectory(test)`
+add_subdirectory(test)
+if(EXISTS ${PROJECT_SOURCE_DIR}/bench/CMakeLists.txt)
+  add_subdirectory(bench)
+endif()
+if(EXISTS ${PROJECT_SOURCE_DIR}/fuzz/CMakeLists.txt)
+  add_subdirectory(fuzz)
+endif()`
 
 var testCMakeLists = `# [1] 测试配置 -----------------------------------------------------
 set(CMAKE_RUNTIME_OUTPUT_DIRECTORY ${CMAKE_SOURCE_DIR}/bin/test)
@@ -259,6 +267,7 @@ enable_testing()
 
 # [2] 查找依赖 -----------------------------------------------------
 find_package(GTest REQUIRED)
+include(GoogleTest)
 
 # [3] 添加测试目标 --------------------------------------------------
 function(add_integration_test name)
@@ -274,6 +283,12 @@ function(add_integration_test name)
             GTest::gtest_main
             ${ARGN}
     )
+
+    # 注册到 CTest, 以测试目录名作为标签
+    gtest_discover_tests(${name}_test
+        WORKING_DIRECTORY ${PROJECT_SOURCE_DIR}
+        PROPERTIES LABELS ${name}
+    )
 endfunction(add_integration_test name)
 
 # [4] 添加具体测试 --------------------------------------------------
@@ -0,0 +1,68 @@
+package new
+
+var fuzzCMakeLists = `# [1] fuzz 配置 ----------------------------------------------------
+set(CMAKE_RUNTIME_OUTPUT_DIRECTORY ${CMAKE_SOURCE_DIR}/bin/fuzz)
+
+# [2] 添加 fuzz 目标 ------------------------------------------------
+# Clang 使用 libFuzzer 和 AddressSanitizer 构建;
+# 其他编译器使用独立入口构建, 只能回放语料 (cgear fuzz -regress)
+function(add_fuzz_target name)
+    file(GLOB_RECURSE files ${name}/*.cpp)
+    add_executable(${name}_fuzz ${files})
+    target_include_directories(${name}_fuzz
+        PUBLIC
+            # 包含头文件目录
+            ${PROJECT_SOURCE_DIR}/fuzz
+    )
+    target_link_libraries(${name}_fuzz
+        PUBLIC
+            ${ARGN}
+    )
+
+    if(CMAKE_CXX_COMPILER_ID MATCHES "Clang")
+        target_compile_options(${name}_fuzz PRIVATE -fsanitize=fuzzer,address -fno-omit-frame-pointer)
+        target_link_options(${name}_fuzz PRIVATE -fsanitize=fuzzer,address)
+    else()
+        target_sources(${name}_fuzz PRIVATE ${CMAKE_CURRENT_SOURCE_DIR}/standalone_main.cpp)
+    endif()
+endfunction(add_fuzz_target name)
+
+# [3] 添加具体 fuzz 目标 --------------------------------------------
+`
+
+var fuzzStandaloneMain = `// 不支持 libFuzzer 的编译器使用的入口: 把命令行中的每个文件作为输入执行一次
+#include <cstddef>
+#include <cstdint>
+#include <cstdio>
+#include <fstream>
+#include <iterator>
+#include <vector>
+
+extern "C" int LLVMFuzzerTestOneInput(const uint8_t *data, size_t size);
+
+int main(int argc, char **argv) {
+    for (int i = 1; i < argc; i++) {
+        // 忽略 libFuzzer 的参数, 例如 -runs=0
+        if (argv[i][0] == '-') continue;
+
+        std::ifstream              file(argv[i], std::ios::binary);
+        std::vector<unsigned char> data((std::istreambuf_iterator<char>(file)), std::istreambuf_iterator<char>());
+
+        std::printf("Running: %s\n", argv[i]);
+        LLVMFuzzerTestOneInput(data.data(), data.size());
+    }
+
+    return 0;
+}
+`
+
+var fuzzContent = `#include <cstddef>
+#include <cstdint>
+
+extern "C" int LLVMFuzzerTestOneInput(const uint8_t *data, size_t size) {
+    (void) data;
+    (void) size;
+
+    return 0;
+}
+`
@@ -13,17 +13,19 @@ import (
 	"github.com/zelviner/cgear/env"
 	"github.com/zelviner/cgear/logger"
 	"github.com/zelviner/cgear/logger/colors"
+	"github.com/zelviner/cgear/tester"
 	ui "github.com/zelviner/cgear/ui/select"
 	"github.com/zelviner/cgear/utils"
 )
 
 var (
-	test         bool
-	qt           bool
-	cgearVersion utils.DocValue
-	output       io.Writer
-	projectPath  string
-	projectName  string
+	test          bool
+	qt            bool
+	testFramework string
+	cgearVersion  utils.DocValue
+	output        io.Writer
+	projectPath   string
+	projectName   string
 )
 
 var CmdNew = &commands.Command{
@@ -45,6 +47,8 @@ var CmdNew = &commands.Command{
             │     └── main.cpp
             ├── {{"test"|foldername}}
             │     └── CMakeLists.txt
+            ├── {{"bench"|foldername}}
+            │     └── CMakeLists.txt
             ├── {{".vecode"|foldername}}
             │     └── launch.json
             ├── {{"docs"|foldername}}
@@ -56,6 +60,7 @@ var CmdNew = &commands.Command{
 func init() {
 	CmdNew.Flag.BoolVar(&qt, "qt", false, "New a Qt Application, default false")
 	CmdNew.Flag.BoolVar(&test, "test", false, "New a Test Case, default false")
+	CmdNew.Flag.StringVar(&testFramework, "framework", "", "Test framework of the project: gtest, catch2 or doctest, default gtest. Test cases always use the framework of the project")
 	commands.AvailableCommands = append(commands.AvailableCommands, CmdNew)
 }
 
@@ -84,7 +89,7 @@ func Create(cmd *commands.Command, args []string) int {
 	config.Conf.ProjectPath = projectPath
 
 	// 选择项目类型
-	projectTypes := []string{"Application", "QT Application", "Static library", "Dynamic library", "Test cases"}
+	projectTypes := []string{"Application", "QT Application", "Static library", "Dynamic library", "Test cases", "Benchmark", "Fuzz target"}
 	projectType, cancelled, err := ui.ListOption("Please select project type: ", projectTypes, func(p string) string { return p })
 	if err != nil {
 		logger.Log.Errorf("Failed to select project type: %s", err)
@@ -99,10 +104,38 @@ func Create(cmd *commands.Command, args []string) int {
 	config.Conf.ProjectType = projectType
 
 	if strings.Compare(projectType, "Test cases") == 0 {
+		// 测试用例必须使用项目的框架, test/CMakeLists.txt 只查找和链接该框架
+		framework, err := tester.Lookup(config.Conf.TestFramework)
+		if err != nil {
+			logger.Log.Fatal(err.Error())
+		}
+		if chosen, err := tester.Lookup(testFramework); testFramework != "" && (err != nil || chosen.Name() != framework.Name()) {
+			logger.Log.Fatalf("The project tests with %s, a test case cannot use '%s'", framework.Name(), testFramework)
+		}
+		config.Conf.TestFramework = framework.Name()
 		createTestCase()
 		return 0
 	}
 
+	// 选择测试框架
+	if testFramework != "" || config.Conf.TestFramework == "" {
+		framework, err := tester.Lookup(testFramework)
+		if err != nil {
+			logger.Log.Fatal(err.Error())
+		}
+		config.Conf.TestFramework = framework.Name()
+	}
+
+	if strings.Compare(projectType, "Benchmark") == 0 {
+		createBenchmark()
+		return 0
+	}
+
+	if strings.Compare(projectType, "Fuzz target") == 0 {
+		createFuzzTarget()
+		return 0
+	}
+
 	// 选择工具链
 	env.SetToolchain()
 
@@ -153,6 +186,8 @@ func createApp() {
 	os.MkdirAll(filepath.Join(projectPath, "src", "utils"), 0755)
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "test")+string(filepath.Separator), "\x1b[0m")
 	os.MkdirAll(filepath.Join(projectPath, "test"), 0755)
+	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "bench")+string(filepath.Separator), "\x1b[0m")
+	os.MkdirAll(filepath.Join(projectPath, "bench"), 0755)
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "cmake")+string(filepath.Separator), "\x1b[0m")
 	os.MkdirAll(filepath.Join(projectPath, "cmake"), 0755)
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, ".vecode")+string(filepath.Separator), "\x1b[0m")
@@ -180,7 +215,9 @@ func createApp() {
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "src/utils", "utils.cpp"), "\x1b[0m")
 	utils.WriteToFile(filepath.Join(projectPath, "src/utils", "utils.cpp"), strings.Replace(appUtilsCPP, "{{ .ProjectName }} ", "utils", -1))
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "test", "CMakeLists.txt"), "\x1b[0m")
-	utils.WriteToFile(filepath.Join(projectPath, "test", "CMakeLists.txt"), testCMakeLists)
+	utils.WriteToFile(filepath.Join(projectPath, "test", "CMakeLists.txt"), testCMakeListsOf(config.Conf.TestFramework))
+	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "bench", "CMakeLists.txt"), "\x1b[0m")
+	utils.WriteToFile(filepath.Join(projectPath, "bench", "CMakeLists.txt"), benchCMakeLists)
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "cmake", "clang-32bit-toolchain.cmake"), "\x1b[0m")
 	utils.WriteToFile(filepath.Join(projectPath, "cmake", "clang-32bit-toolchain.cmake"), toolchainFile32Bit)
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "cmake", "clang-64bit-toolchain.cmake"), "\x1b[0m")
@@ -207,6 +244,8 @@ func createQTApp() {
 	os.MkdirAll(filepath.Join(projectPath, "src", "app"), 0755)
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "test")+string(filepath.Separator), "\x1b[0m")
 	os.MkdirAll(filepath.Join(projectPath, "test"), 0755)
+	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "bench")+string(filepath.Separator), "\x1b[0m")
+	os.MkdirAll(filepath.Join(projectPath, "bench"), 0755)
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, ".vecode")+string(filepath.Separator), "\x1b[0m")
 	os.MkdirAll(filepath.Join(projectPath, ".vscode"), 0755)
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "res")+string(filepath.Separator), "\x1b[0m")
@@ -246,7 +285,9 @@ func createQTApp() {
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "src/utils", "utils.cpp"), "\x1b[0m")
 	utils.WriteToFile(filepath.Join(projectPath, "src/utils", "utils.cpp"), strings.Replace(appUtilsCPP, "{{ .ProjectName }} ", "utils", -1))
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "test", "CMakeLists.txt"), "\x1b[0m")
-	utils.WriteToFile(filepath.Join(projectPath, "test", "CMakeLists.txt"), testCMakeLists)
+	utils.WriteToFile(filepath.Join(projectPath, "test", "CMakeLists.txt"), testCMakeListsOf(config.Conf.TestFramework))
+	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "bench", "CMakeLists.txt"), "\x1b[0m")
+	utils.WriteToFile(filepath.Join(projectPath, "bench", "CMakeLists.txt"), benchCMakeLists)
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, ".vsocde", "CMakeLists.txt"), "\x1b[0m")
 	utils.WriteToFile(filepath.Join(projectPath, ".vscode", "launch.json"), launch)
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, ".vsocde", "CMakeLists.txt"), "\x1b[0m")
@@ -288,6 +329,8 @@ func createLib(libType string) {
 	os.MkdirAll(filepath.Join(projectPath, "src", "utils"), 0755)
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "test")+string(filepath.Separator), "\x1b[0m")
 	os.MkdirAll(filepath.Join(projectPath, "test"), 0755)
+	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "bench")+string(filepath.Separator), "\x1b[0m")
+	os.MkdirAll(filepath.Join(projectPath, "bench"), 0755)
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, ".vecode")+string(filepath.Separator), "\x1b[0m")
 	os.MkdirAll(filepath.Join(projectPath, ".vscode"), 0755)
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "cmake")+string(filepath.Separator), "\x1b[0m")
@@ -315,7 +358,9 @@ func createLib(libType string) {
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "src/utils", "utils.cpp"), "\x1b[0m")
 	utils.WriteToFile(filepath.Join(projectPath, "src/utils", "utils.cpp"), strings.Replace(libUtilsCPP, "{{ .ProjectName }}", filepath.Base(projectName), -1))
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "test", "CMakeLists.txt"), "\x1b[0m")
-	utils.WriteToFile(filepath.Join(projectPath, "test", "CMakeLists.txt"), testCMakeLists)
+	utils.WriteToFile(filepath.Join(projectPath, "test", "CMakeLists.txt"), testCMakeListsOf(config.Conf.TestFramework))
+	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "bench", "CMakeLists.txt"), "\x1b[0m")
+	utils.WriteToFile(filepath.Join(projectPath, "bench", "CMakeLists.txt"), benchCMakeLists)
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "cmake", projectName+"Config.cmake.in"), "\x1b[0m")
 	utils.WriteToFile(filepath.Join(projectPath, "cmake", projectName+"Config.cmake.in"), strings.Replace(configCMakeIn, "{{ .ProjectName }}", filepath.Base(projectName), -1))
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(projectPath, "cmake", "clang-32bit-toolchain.cmake"), "\x1b[0m")
@@ -359,7 +404,7 @@ func createTestCase() {
 
 	// 创建C++项目所需文件
 	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(testPath, testFileName), "\x1b[0m")
-	utils.WriteToFile(filepath.Join(testPath, testFileName), strings.Replace(testContent, "{{ .testName }}", utils.CapitalizeFirstLetter(projectName), -1))
+	utils.WriteToFile(filepath.Join(testPath, testFileName), strings.Replace(testContentOf(config.Conf.TestFramework), "{{ .testName }}", utils.CapitalizeFirstLetter(projectName), -1))
 	utils.ReplaceFileContent(testConfigPath, "//{{ .configuration }}", testLaunch)
 	utils.ReplaceFileContent(testConfigPath, "{{ .testName }}", projectName)
 
@@ -378,3 +423,144 @@ func createTestCase() {
 
 	logger.Log.Success("New test case successfully created!")
 }
+
+func createBenchmark() {
+
+	var (
+		benchPath     string
+		benchesPath   string
+		benchFileName string
+	)
+
+	benchesPath = filepath.Join(utils.GetCgearWorkPath(), "bench")
+	benchPath = filepath.Join(benchesPath, projectName)
+	benchFileName = projectName + "_bench.cpp"
+
+	if utils.IsExist(benchPath) {
+		logger.Log.Errorf(colors.Bold("Benchmark '%s' already exists"), benchPath)
+		logger.Log.Warn(colors.Bold("Do you want to overwrite it? [Yes]|No "))
+		if !utils.AskForConfirmation() {
+			os.Exit(2)
+		}
+	}
+	logger.Log.Info("Creating benchmark...")
+
+	// 早期创建的项目没有 bench 目录, 补充基准测试的 CMake 配置
+	ensureSubdirectory("bench", [2]string{"CMakeLists.txt", benchCMakeLists})
+
+	// 创建基准测试文件
+	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", benchPath, "\x1b[0m")
+	os.MkdirAll(benchPath, 0755)
+	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(benchPath, benchFileName), "\x1b[0m")
+	utils.WriteToFile(filepath.Join(benchPath, benchFileName), strings.Replace(benchContent, "{{ .benchName }}", utils.CapitalizeFirstLetter(projectName), -1))
+
+	// 向 bench/cmakelists 中追加写入内容
+	fmt.Fprintf(output, "\t%s%sadd%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(benchesPath, "CMakeLists.txt"), "\x1b[0m")
+	file, err := os.OpenFile(filepath.Join(benchesPath, "CMakeLists.txt"), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		logger.Log.Fatalf("Open '%s' err: %s", filepath.Join(benchesPath, "CMakeLists.txt"), err.Error())
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(fmt.Sprintf("add_benchmark(%s)\n", projectName))
+	if err != nil {
+		logger.Log.Fatalf("Write '%s' err: %s", filepath.Join(benchesPath, "CMakeLists.txt"), err.Error())
+	}
+
+	logger.Log.Success("New benchmark successfully created!")
+}
+
+func createFuzzTarget() {
+
+	var (
+		fuzzPath     string
+		fuzzesPath   string
+		fuzzFileName string
+	)
+
+	fuzzesPath = filepath.Join(utils.GetCgearWorkPath(), "fuzz")
+	fuzzPath = filepath.Join(fuzzesPath, projectName)
+	fuzzFileName = projectName + "_fuzz.cpp"
+
+	if utils.IsExist(fuzzPath) {
+		logger.Log.Errorf(colors.Bold("Fuzz target '%s' already exists"), fuzzPath)
+		logger.Log.Warn(colors.Bold("Do you want to overwrite it? [Yes]|No "))
+		if !utils.AskForConfirmation() {
+			os.Exit(2)
+		}
+	}
+	logger.Log.Info("Creating fuzz target...")
+
+	ensureSubdirectory("fuzz",
+		[2]string{"CMakeLists.txt", fuzzCMakeLists},
+		[2]string{"standalone_main.cpp", fuzzStandaloneMain},
+	)
+
+	// 创建 fuzz 入口和语料目录
+	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", fuzzPath, "\x1b[0m")
+	os.MkdirAll(fuzzPath, 0755)
+	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(fuzzPath, fuzzFileName), "\x1b[0m")
+	utils.WriteToFile(filepath.Join(fuzzPath, fuzzFileName), fuzzContent)
+	fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(fuzzesPath, "corpus", projectName)+string(filepath.Separator), "\x1b[0m")
+	os.MkdirAll(filepath.Join(fuzzesPath, "corpus", projectName), 0755)
+
+	// 向 fuzz/cmakelists 中追加写入内容
+	fmt.Fprintf(output, "\t%s%sadd%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(fuzzesPath, "CMakeLists.txt"), "\x1b[0m")
+	file, err := os.OpenFile(filepath.Join(fuzzesPath, "CMakeLists.txt"), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		logger.Log.Fatalf("Open '%s' err: %s", filepath.Join(fuzzesPath, "CMakeLists.txt"), err.Error())
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(fmt.Sprintf("add_fuzz_target(%s)\n", projectName))
+	if err != nil {
+		logger.Log.Fatalf("Write '%s' err: %s", filepath.Join(fuzzesPath, "CMakeLists.txt"), err.Error())
+	}
+
+	logger.Log.Success("New fuzz target successfully created!")
+}
+
+// ensureSubdirectory 在 name 目录缺少 CMake 配置时写入 files, 并把该目录添加到根 CMakeLists.txt
+func ensureSubdirectory(name string, files ...[2]string) {
+	dir := filepath.Join(utils.GetCgearWorkPath(), name)
+	if utils.IsExist(filepath.Join(dir, "CMakeLists.txt")) {
+		return
+	}
+
+	os.MkdirAll(dir, 0755)
+	for _, f := range files {
+		fmt.Fprintf(output, "\t%s%screate%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", filepath.Join(dir, f[0]), "\x1b[0m")
+		utils.WriteToFile(filepath.Join(dir, f[0]), f[1])
+	}
+
+	rootCMakeLists := filepath.Join(utils.GetCgearWorkPath(), "CMakeLists.txt")
+	content, err := os.ReadFile(rootCMakeLists)
+	if err == nil && !strings.Contains(string(content), "add_subdirectory("+name+")") {
+		fmt.Fprintf(output, "\t%s%sadd%s\t %s%s\n", "\x1b[32m", "\x1b[1m", "\x1b[21m", rootCMakeLists, "\x1b[0m")
+		utils.WriteToFile(rootCMakeLists, "\nadd_subdirectory("+name+")\n")
+	}
+}
+
+// testCMakeListsOf 返回测试框架对应的 test/CMakeLists.txt 模板
+func testCMakeListsOf(framework string) string {
+	switch framework {
+	case tester.Catch2.Name():
+		return catch2TestCMakeLists
+	case tester.Doctest.Name():
+		return doctestTestCMakeLists
+	default:
+		return testCMakeLists
+	}
+}
+
+// testContentOf 返回测试框架对应的测试用例模板
+func testContentOf(framework string) string {
+	switch framework {
+	case tester.Catch2.Name():
+		return catch2TestContent
+	case tester.Doctest.Name():
+		return doctestTestContent
+	default:
+		return testContent
+	}
+}
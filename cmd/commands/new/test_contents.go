@@ -0,0 +1,84 @@
+package new
+
+var catch2TestCMakeLists = `# [1] 测试配置 -----------------------------------------------------
+set(CMAKE_RUNTIME_OUTPUT_DIRECTORY ${CMAKE_SOURCE_DIR}/bin/test)
+enable_testing()
+
+# [2] 查找依赖 -----------------------------------------------------
+find_package(Catch2 3 REQUIRED)
+include(Catch)
+
+# [3] 添加测试目标 --------------------------------------------------
+function(add_integration_test name)
+    file(GLOB_RECURSE files ${name}/*.cpp)
+    add_executable(${name}_test ${files})
+    target_include_directories(${name}_test 
+        PUBLIC
+            # 包含头文件目录
+            ${PROJECT_SOURCE_DIR}/test
+    )
+    target_link_libraries(${name}_test
+        PUBLIC
+            Catch2::Catch2WithMain
+            ${ARGN}
+    )
+
+    # 注册到 CTest, 以测试目录名作为标签
+    catch_discover_tests(${name}_test
+        WORKING_DIRECTORY ${PROJECT_SOURCE_DIR}
+        PROPERTIES LABELS ${name}
+    )
+endfunction(add_integration_test name)
+
+# [4] 添加具体测试 --------------------------------------------------
+`
+
+var catch2TestContent = `#include <catch2/catch_test_macros.hpp>
+
+TEST_CASE("{{ .testName }}", "[{{ .testName }}]") {
+ 
+
+}`
+
+var doctestTestCMakeLists = `# [1] 测试配置 -----------------------------------------------------
+set(CMAKE_RUNTIME_OUTPUT_DIRECTORY ${CMAKE_SOURCE_DIR}/bin/test)
+enable_testing()
+
+# [2] 查找依赖 -----------------------------------------------------
+find_package(doctest REQUIRED)
+include(doctest)
+
+# [3] 添加测试目标 --------------------------------------------------
+function(add_integration_test name)
+    file(GLOB_RECURSE files ${name}/*.cpp)
+    add_executable(${name}_test ${files})
+    target_include_directories(${name}_test 
+        PUBLIC
+            # 包含头文件目录
+            ${PROJECT_SOURCE_DIR}/test
+    )
+    target_link_libraries(${name}_test
+        PUBLIC
+            doctest::doctest
+            ${ARGN}
+    )
+
+    # 由 doctest 生成 main 函数
+    target_compile_definitions(${name}_test PRIVATE DOCTEST_CONFIG_IMPLEMENT_WITH_MAIN)
+
+    # 注册到 CTest, 以测试目录名作为标签
+    doctest_discover_tests(${name}_test
+        WORKING_DIRECTORY ${PROJECT_SOURCE_DIR}
+        PROPERTIES LABELS ${name}
+    )
+endfunction(add_integration_test name)
+
+# [4] 添加具体测试 --------------------------------------------------
+`
+
+var doctestTestContent = `#include <doctest/doctest.h>
+
+TEST_CASE("{{ .testName }}") {
+ 
+
+}`
@@ -0,0 +1,146 @@
+package outdated
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/zelviner/cgear/cmd/commands"
+	"github.com/zelviner/cgear/cmd/commands/version"
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/deps"
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/utils"
+)
+
+var CmdOutdated = &commands.Command{
+	UsageLine: "outdated [-json] [-all]",
+	Short:     "List dependencies that have newer tags than the locked revisions",
+	Long: `
+Outdated lists the tags of the repository of each dependency with 'git ls-remote --tags' and
+  compares them with the revision locked in cgear.lock:
+
+    CURRENT  the tag of the locked revision
+    WANTED   the newest tag matching the declared version constraint, see below
+    LATEST   the newest tag that is not a pre-release
+
+  A version can be a constraint instead of a tag or branch. 'cgear install' and 'cgear update'
+  then use the newest tag that matches it:
+
+    ^1.2.3    compatible with 1.2.3, that is >=1.2.3, <2.0.0 (>=0.2.3, <0.3.0 for 0.x)
+    ~1.2.3    only patch releases, that is >=1.2.3, <1.3.0
+    >=1.2, <2 any combination of >=, >, <=, < and =
+
+  A version that is a tag like v1.2.3 wants the newest tag compatible with it, ^v1.2.3.
+  'cgear update' moves to WANTED, 'cgear update -latest' to LATEST.
+
+  {{"Example:"|bold}}
+    $ cgear outdated         # List the dependencies that can be updated
+    $ cgear outdated -all    # List all dependencies, including those that are up to date
+    $ cgear outdated -json   # Print all dependencies as JSON
+`,
+	PreRun: func(cmd *commands.Command, args []string) {
+		if !asJSON {
+			version.ShowShortVersionBanner()
+		}
+	},
+	Run: RunOutdated,
+}
+
+var (
+	asJSON bool // 以 JSON 输出
+	all    bool // 也列出已经是最新的依赖
+)
+
+func init() {
+	CmdOutdated.Flag.BoolVar(&asJSON, "json", false, "Print all dependencies as JSON, default false")
+	CmdOutdated.Flag.BoolVar(&all, "all", false, "List up to date dependencies too, default false")
+	commands.AvailableCommands = append(commands.AvailableCommands, CmdOutdated)
+}
+
+func RunOutdated(cmd *commands.Command, args []string) int {
+	if len(args) > 0 {
+		logger.Log.Fatal("Too many parameters")
+	}
+
+	dependencies := config.Conf.Dependencies
+	if len(dependencies) == 0 {
+		logger.Log.Fatal("No dependencies declared in cgear.json")
+	}
+	if err := deps.Validate(dependencies); err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+
+	lock, err := deps.ReadLock(filepath.Join(utils.GetCgearWorkPath(), deps.LockFile))
+	if err != nil {
+		logger.Log.Fatalf("Failed to read %s: %s", deps.LockFile, err)
+	}
+
+	// 项目的依赖在前, 其后是锁文件中的传递依赖
+	declared := make(map[string]bool)
+	for _, dep := range dependencies {
+		declared[dep.Name] = true
+	}
+	for _, locked := range lock.Packages {
+		if !declared[locked.Name] {
+			dependencies = append(dependencies, config.Dependency{Name: locked.Name, Source: locked.Source, URL: locked.URL, Version: locked.Version, Options: locked.Options})
+		}
+	}
+
+	installer := deps.NewInstaller()
+	var results []*deps.Outdated
+	for _, dep := range dependencies {
+		var revision string
+		if locked := lock.Find(dep.Name); locked != nil && locked.Matches(dep) {
+			revision = locked.Revision
+		}
+		outdated, err := installer.CheckOutdated(dep, revision)
+		if err != nil {
+			logger.Log.Fatal(err.Error())
+		}
+		results = append(results, outdated)
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			logger.Log.Fatal(err.Error())
+		}
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVERSION\tLOCKED\tCURRENT\tWANTED\tLATEST")
+	count := 0
+	for _, o := range results {
+		if !o.IsOutdated() && !all {
+			continue
+		}
+		count++
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", o.Name, dash(o.Version), dash(shortRevision(o.Revision)), dash(o.Current), dash(o.Wanted), dash(o.Latest))
+	}
+	if count == 0 {
+		logger.Log.Success("All dependencies are up to date")
+		return 0
+	}
+	w.Flush()
+	return 0
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func shortRevision(revision string) string {
+	if len(revision) > 10 {
+		return revision[:10]
+	}
+	return revision
+}
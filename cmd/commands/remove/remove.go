@@ -0,0 +1,150 @@
+package remove
+
+import (
+	"path/filepath"
+
+	"github.com/zelviner/cgear/cmake"
+	"github.com/zelviner/cgear/cmd/commands"
+	"github.com/zelviner/cgear/cmd/commands/version"
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/deps"
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/utils"
+)
+
+var CmdRemove = &commands.Command{
+	UsageLine: "remove <package> [-keep]",
+	Short:     "Remove a dependency added by 'cgear add' from the project",
+	Long: `
+Remove reverses 'cgear add': it deletes the find_package and target_link_libraries lines
+  marked with '# cgear: <name>' from the CMakeLists.txt files of the project, removes the
+  dependency from cgear.json or Cgearfile and cgear.lock, and uninstalls the package.
+
+  Packages that were only locked because the removed package required them are removed from
+  cgear.lock too. They stay installed, other projects may use them. A package that another
+  installed package depends on is not uninstalled, and -keep leaves the package installed.
+
+  {{"Example:"|bold}}
+    $ cgear remove fmt
+    $ cgear remove googletest -keep   # Keep the package installed
+`,
+	PreRun: func(cmd *commands.Command, args []string) { version.ShowShortVersionBanner() },
+	Run:    RunRemove,
+}
+
+var keep bool // 不卸载依赖
+
+func init() {
+	CmdRemove.Flag.BoolVar(&keep, "keep", false, "Keep the package installed, default false")
+	commands.AvailableCommands = append(commands.AvailableCommands, CmdRemove)
+}
+
+func RunRemove(cmd *commands.Command, args []string) int {
+	if len(args) > 1 {
+		if err := cmd.Flag.Parse(args[1:]); err != nil {
+			logger.Log.Fatal("Parse args err" + err.Error())
+		}
+	}
+	if len(args) == 0 {
+		logger.Log.Fatal("Argument <package> is missing")
+	}
+	projectPath := utils.GetCgearWorkPath()
+
+	// 依赖可以以包名或来源给出
+	name := args[0]
+	var dependencies []config.Dependency
+	declared := false
+	for _, dep := range config.Conf.Dependencies {
+		if dep.Name == args[0] || dep.Source == args[0] {
+			name, declared = dep.Name, true
+			continue
+		}
+		dependencies = append(dependencies, dep)
+	}
+
+	changed, err := cmake.RemoveLinks(projectPath, name)
+	if err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+	if !declared && len(changed) == 0 {
+		logger.Log.Fatalf("Dependency '%s' is not declared in cgear.json", args[0])
+	}
+	for _, path := range changed {
+		if rel, err := filepath.Rel(projectPath, path); err == nil {
+			path = rel
+		}
+		logger.Log.Infof("Removed '%s' from %s", name, path)
+	}
+
+	if declared {
+		if err := config.SaveDependencies(projectPath, dependencies); err != nil {
+			logger.Log.Fatalf("Failed to save the dependencies: %s", err)
+		}
+		config.Conf.Dependencies = dependencies
+	}
+
+	prefix := deps.NewInstaller().Prefix()
+	lockPath := filepath.Join(projectPath, deps.LockFile)
+	lock, err := deps.ReadLock(lockPath)
+	if err != nil {
+		logger.Log.Fatalf("Failed to read %s: %s", deps.LockFile, err)
+	}
+	if len(lock.Packages) > 0 {
+		for _, unused := range unusedPackages(prefix, name, dependencies) {
+			lock.Remove(unused)
+		}
+		if err := lock.Save(lockPath); err != nil {
+			logger.Log.Fatalf("Failed to write %s: %s", deps.LockFile, err)
+		}
+	}
+
+	if !keep {
+		if _, err := deps.ReadRecord(prefix, name); err == nil {
+			if err := deps.Uninstall(prefix, name); err != nil {
+				logger.Log.Warnf("'%s' stays installed: %s", name, err)
+			}
+		}
+	}
+
+	logger.Log.Successf("Successfully removed '%s'", name)
+	return 0
+}
+
+// unusedPackages 返回 name 和只因它而需要的传递依赖, 依赖关系取自 prefix 中的安装记录
+func unusedPackages(prefix, name string, dependencies []config.Dependency) []string {
+	requires := func(name string) []string {
+		if record, err := deps.ReadRecord(prefix, name); err == nil {
+			return record.Dependencies
+		}
+		return nil
+	}
+
+	// 剩下的依赖直接或间接需要的包
+	needed := make(map[string]bool)
+	var queue []string
+	for _, dep := range dependencies {
+		queue = append(queue, dep.Name)
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if !needed[n] {
+			needed[n] = true
+			queue = append(queue, requires(n)...)
+		}
+	}
+
+	var unused []string
+	seen := make(map[string]bool)
+	for queue = []string{name}; len(queue) > 0; {
+		n := queue[0]
+		queue = queue[1:]
+		if seen[n] || needed[n] {
+			continue
+		}
+		seen[n] = true
+		unused = append(unused, n)
+		queue = append(queue, requires(n)...)
+	}
+	return unused
+}
@@ -0,0 +1,122 @@
+package test
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/zelviner/cgear/cmake"
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/tester"
+)
+
+// affectedPrograms 从 programs 中选出依赖 git 改动文件的测试程序。
+// 构建脚本有改动或无法读取 codemodel 时, 返回全部测试程序。
+func affectedPrograms(programs []string) []string {
+	changed, err := changedFiles(since)
+	if err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	for _, file := range changed {
+		if name := filepath.Base(file); name == "CMakeLists.txt" || name == "cgear.json" || filepath.Ext(name) == ".cmake" {
+			logger.Log.Infof("Build script %s changed, running all tests", relative(file))
+			return programs
+		}
+	}
+
+	targets, err := cmake.ReadCodemodel(buildPath, config.Conf.BuildType)
+	if err != nil {
+		logger.Log.Warnf("Failed to read the CMake codemodel, running all tests: %s", err)
+		return programs
+	}
+
+	// 合并编译器记录的头文件依赖
+	depfiles := cmake.ReadDepfiles(buildPath)
+	for i := range targets {
+		targets[i].Sources = append(targets[i].Sources, depfiles[targets[i].Name]...)
+	}
+
+	artifacts := make(map[string]string)
+	for _, target := range cmake.Affected(targets, changed) {
+		for _, artifact := range target.Artifacts {
+			artifacts[filepath.Clean(artifact)] = target.Name
+		}
+	}
+
+	var selected []string
+	for _, program := range programs {
+		if _, ok := artifacts[filepath.Clean(program)]; ok {
+			selected = append(selected, program)
+		}
+	}
+	return selected
+}
+
+// changedFiles 返回相对 since 的合并基础有改动的文件, 包括未提交和未跟踪的文件
+func changedFiles(since string) ([]string, error) {
+	root, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("not a git repository: %w", err)
+	}
+	root = strings.TrimSpace(root)
+
+	base, err := git("merge-base", since, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the merge base of %s and HEAD: %w", since, err)
+	}
+
+	diff, err := git("-c", "core.quotePath=false", "diff", "--name-only", strings.TrimSpace(base))
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git("-c", "core.quotePath=false", "ls-files", "--others", "--exclude-standard", "--full-name")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(diff+"\n"+untracked, "\n") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		file := filepath.Join(root, filepath.FromSlash(name))
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+func git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = appPath
+	output, err := cmd.Output()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return string(output), err
+}
+
+// showAffected 打印选中的测试程序
+func showAffected(programs []string) {
+	logger.Log.Infof("%d test programs affected by changes since %s", len(programs), since)
+	for _, program := range programs {
+		fmt.Println(`    ├── ` + tester.ProgramName(program))
+	}
+}
+
+// relative 返回相对项目目录的路径
+func relative(path string) string {
+	if rel, err := filepath.Rel(appPath, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
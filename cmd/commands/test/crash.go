@@ -0,0 +1,35 @@
+package test
+
+import (
+	"fmt"
+
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/logger/colors"
+	"github.com/zelviner/cgear/tester"
+)
+
+// showCrash 打印测试程序崩溃或超时时正在运行的用例和调用栈
+func showCrash(crash *tester.Crash) {
+	if crash == nil {
+		return
+	}
+
+	test := crash.Test
+	if test == "" {
+		test = "unknown"
+	}
+
+	if crash.TimedOut {
+		logger.Log.Errorf("Test %s %s, the test process tree was killed", colors.Bold(test), crash.Reason)
+	} else {
+		logger.Log.Errorf("Test %s crashed: %s", colors.Bold(test), crash.Reason)
+	}
+
+	if len(crash.Frames) > 0 {
+		fmt.Println(colors.MagentaBold("STACK TRACE"))
+		for i, frame := range crash.Frames {
+			fmt.Printf("    #%-3d %s\n", i, frame)
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,100 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/zelviner/cgear/cmake"
+	"github.com/zelviner/cgear/cmd/commands/version"
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/tester"
+	"github.com/zelviner/cgear/utils"
+)
+
+// runCTest 构建项目后通过 ctest 运行测试, 并把 JUnit 结果以树形结构打印出来
+func runCTest(args []string) {
+	version.ShowShortVersionBanner()
+
+	// 未指定 -R 时, 把位置参数当作测试名正则
+	if regex == "" && len(args) > 0 {
+		regex = args[0]
+	}
+
+	configArg := cmake.ConfigArg{
+		Toolchain:             config.Conf.Toolchain,
+		Platform:              config.Conf.Platform,
+		BuildType:             config.Conf.BuildType,
+		Generator:             config.Conf.Generator,
+		NoWarnUnusedCli:       true,
+		ExportCompileCommands: true,
+		ProjectPath:           appPath,
+		BuildPath:             buildPath,
+		CXXFlags:              "-D_MD",
+	}
+
+	buildArg := cmake.BuildArg{
+		BuildPath: buildPath,
+		BuildType: config.Conf.BuildType,
+		IsMSVC:    configArg.Toolchain != nil && configArg.Toolchain.IsMSVC,
+	}
+
+	// 设置临时环境变量
+	dllPath := getDllPath()
+	logger.Log.Infof("Setting PATH environment variable to: %s", dllPath)
+	restore, err := utils.SetEnvTemp("PATH", dllPath)
+	if err != nil {
+		logger.Log.Errorf("Failed to set PATH environment variable: %v", err)
+		return
+	}
+	defer restore() // 确保在函数结束时恢复原始 PATH
+
+	err = cmake.Build(&configArg, &buildArg, rebuild, false)
+	if err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+
+	junitPath := filepath.Join(resultsPath(), "ctest.xml")
+	if err := os.MkdirAll(filepath.Dir(junitPath), 0755); err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+	os.Remove(junitPath)
+
+	// -repeat=N 在 ctest 中等同于 until-fail:N
+	ctestRepeat := repeat
+	if _, err := strconv.Atoi(repeat); err == nil {
+		ctestRepeat = "until-fail:" + repeat
+	}
+
+	testArg := cmake.TestArg{
+		BuildPath:   buildPath,
+		BuildType:   config.Conf.BuildType,
+		IsMSVC:      buildArg.IsMSVC,
+		Regex:       regex,
+		Label:       label,
+		Jobs:        jobs,
+		Timeout:     timeout,
+		Repeat:      ctestRepeat,
+		OutputJUnit: junitPath,
+	}
+
+	// ctest 在有测试失败时返回非零, 结果仍以 JUnit 文件为准
+	testErr := cmake.Test(&testArg, true)
+
+	cases, err := tester.ParseJUnitFile(junitPath)
+	if err != nil {
+		if testErr != nil {
+			logger.Log.Fatal(testErr.Error())
+		}
+		logger.Log.Fatalf("Failed to read CTest results: %s", err)
+	}
+
+	showResults(cases)
+	recordHistory(cases)
+	if report {
+		writeReport(cases)
+	}
+
+	exitOnFailure(cases, testErr != nil)
+}
@@ -0,0 +1,143 @@
+package test
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/logger/colors"
+	"github.com/zelviner/cgear/tester"
+)
+
+// gtest 随机种子的最大值
+const maxSeed = 99999
+
+// runRepeat 把测试重复运行 count 轮, 每轮使用新的随机种子, 并按稳定性对用例分类。
+// 每轮单独启动测试程序, 这样每个失败都能对应到复现它的随机种子。
+func runRepeat(programs []string, filter string, count int) {
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	var iterations []tester.Iteration
+	for i := 0; i < count; i++ {
+		iteration := tester.Iteration{}
+		if shuffle {
+			iteration.Seed = random.Intn(maxSeed) + 1
+		}
+
+		var plan []tester.Job
+		for _, program := range programs {
+			job := newJob(tester.Job{Program: program, Filter: filter})
+			job.Seed = iteration.Seed
+			plan = append(plan, job)
+		}
+
+		var failed int
+		tester.Run(framework, plan, jobs, filepath.Join(resultsPath(), "repeat"), func(result tester.JobResult) {
+			if result.Err != nil {
+				fmt.Println(colors.RedBold(fmt.Sprintf("==== %s [seed %d] ====", tester.ProgramName(result.Job.Program), iteration.Seed)))
+				os.Stdout.Write(result.Output)
+				showCrash(result.Crash)
+			}
+			for _, c := range result.Cases {
+				if c.Status == tester.StatusFailed {
+					failed++
+				}
+			}
+			iteration.Cases = append(iteration.Cases, result.Cases...)
+		})
+
+		logger.Log.Infof("Iteration %d/%d (seed %d): %d failed", i+1, count, iteration.Seed, failed)
+		iterations = append(iterations, iteration)
+	}
+
+	classifications := tester.Classify(iterations)
+	showClassifications(classifications)
+
+	var cases []tester.Case
+	for _, c := range classifications {
+		cases = append(cases, c.Case())
+	}
+
+	history := loadHistory()
+	history.Add(cases)
+	history.AddFlaky(classifications)
+	if err := history.Save(historyPath()); err != nil {
+		logger.Log.Warnf("Failed to save test history: %s", err)
+	}
+
+	if report {
+		writeReport(cases)
+	}
+
+	exitOnFailure(cases, false)
+}
+
+// showClassifications 打印不稳定和稳定失败的用例, 以及复现失败的命令
+func showClassifications(classifications []tester.Classification) {
+	var stable, flaky, broken []tester.Classification
+	for _, c := range classifications {
+		switch c.Class {
+		case tester.StablePass:
+			stable = append(stable, c)
+		case tester.Flaky:
+			flaky = append(flaky, c)
+		case tester.StableFail:
+			broken = append(broken, c)
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(colors.MagentaBold("STABILITY REPORT"))
+	fmt.Printf("    %-12s %d\n", colors.GreenBold(tester.StablePass), len(stable))
+	fmt.Printf("    %-12s %d\n", colors.RedBold(tester.StableFail), len(broken))
+	fmt.Printf("    %-12s %d\n", colors.YellowBold(tester.Flaky), len(flaky))
+
+	for _, group := range [][]tester.Classification{flaky, broken} {
+		for _, c := range group {
+			fmt.Println()
+			fmt.Printf("    %s %s (failed %d of %d runs)\n", colors.Bold(c.Name), c.Class, c.Failed, c.Passed+c.Failed)
+			if shuffle {
+				fmt.Printf("        seeds: %s\n", tester.JoinSeeds(c.FailingSeeds))
+				fmt.Printf("        reproduce: cgear test %s -shuffle -seed=%d\n", c.Name, c.FailingSeeds[0])
+			}
+		}
+	}
+	fmt.Println()
+}
+
+// isQuarantined 判断用例是否在配置的隔离列表中
+func isQuarantined(fullName string) bool {
+	for _, pattern := range config.Conf.Quarantine {
+		if tester.MatchFilter(pattern, fullName) {
+			return true
+		}
+	}
+	return false
+}
+
+// exitOnFailure 在有未隔离的用例失败, 或测试程序异常退出且没有隔离用例失败时以非零状态退出
+func exitOnFailure(cases []tester.Case, processFailed bool) {
+	var failed, quarantined []string
+	for _, c := range cases {
+		if c.Status != tester.StatusFailed {
+			continue
+		}
+		if isQuarantined(c.FullName()) {
+			quarantined = append(quarantined, c.FullName())
+		} else {
+			failed = append(failed, c.FullName())
+		}
+	}
+
+	for _, name := range quarantined {
+		logger.Log.Warnf("Quarantined test failed: %s", name)
+	}
+
+	if len(failed) > 0 || (processFailed && len(quarantined) == 0) {
+		os.Exit(1)
+	}
+}
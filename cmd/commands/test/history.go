@@ -0,0 +1,86 @@
+package test
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/logger/colors"
+	"github.com/zelviner/cgear/tester"
+)
+
+// historyPath 返回测试历史文件路径
+func historyPath() string {
+	return filepath.Join(appPath, ".cgear", "test-history.json")
+}
+
+// loadHistory 读取测试历史, 读取失败时返回空历史
+func loadHistory() *tester.History {
+	history, err := tester.LoadHistory(historyPath())
+	if err != nil {
+		logger.Log.Warnf("Failed to read test history: %s", err)
+		return &tester.History{}
+	}
+	return history
+}
+
+// recordHistory 把本次运行的结果追加到测试历史
+func recordHistory(cases []tester.Case) {
+	history := loadHistory()
+	history.Add(cases)
+	if err := history.Save(historyPath()); err != nil {
+		logger.Log.Warnf("Failed to save test history: %s", err)
+	}
+}
+
+// previouslyFailed 返回上次运行失败且匹配 filter 的用例
+func previouslyFailed(filter string) []tester.Case {
+	var failed []tester.Case
+	for _, c := range loadHistory().LastFailed() {
+		if tester.MatchFilter(filter, c.FullName()) {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
+
+// groupByProgram 按测试程序路径对用例分组
+func groupByProgram(cases []tester.Case) map[string][]string {
+	programs := make(map[string]string)
+	for _, program := range findTestPrograms() {
+		programs[tester.ProgramName(program)] = program
+	}
+
+	groups := make(map[string][]string)
+	for _, c := range cases {
+		program, ok := programs[c.Binary]
+		if !ok {
+			// CTest 的结果中没有测试程序名, 按命名约定推断
+			program = filepath.Join(testPath, getTestProgramName(c.Suite)+"_test.exe")
+		}
+		groups[program] = append(groups[program], c.FullName())
+	}
+
+	return groups
+}
+
+// showHistory 显示指定用例最近的运行结果和耗时
+func showHistory(fullName string) {
+	entries := loadHistory().Of(fullName)
+	if len(entries) == 0 {
+		logger.Log.Warnf("No history for '%s'", fullName)
+		return
+	}
+
+	fmt.Println()
+	fmt.Println(colors.MagentaBold(fullName))
+	var passed int
+	for _, entry := range entries {
+		if entry.Case.Status == tester.StatusPassed {
+			passed++
+		}
+		fmt.Printf("    %s  %-18s %.3fs  %s\n", entry.Time.Format("2006/01/02 15:04:05"), statusText(entry.Case.Status), entry.Case.Time, firstLine(entry.Case.Message))
+	}
+	fmt.Println()
+	logger.Log.Infof("Passed %d of the last %d runs", passed, len(entries))
+}
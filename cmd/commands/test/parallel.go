@@ -0,0 +1,97 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/logger/colors"
+	"github.com/zelviner/cgear/tester"
+)
+
+// runParallel 列出所有用例后按历史耗时把用例或 gtest 分片调度到 jobs 个 worker 上运行
+func runParallel(programs []string, filter string, failedTests []tester.Case) {
+	failedByProgram := groupByProgram(failedTests)
+
+	var testPrograms []tester.Program
+	for _, program := range programs {
+		if lastFailed {
+			if tests := failedByProgram[program]; len(tests) > 0 {
+				testPrograms = append(testPrograms, tester.Program{Path: program, Tests: tests})
+			}
+			continue
+		}
+
+		tests, err := tester.ListTests(framework, program, filter)
+		if err != nil {
+			logger.Log.Fatalf("Failed to list tests of %s: %s", tester.ProgramName(program), err)
+		}
+		testPrograms = append(testPrograms, tester.Program{Path: program, Tests: tests})
+	}
+
+	if lastFailed {
+		var tests []string
+		for _, c := range failedTests {
+			tests = append(tests, c.FullName())
+		}
+		filter = strings.Join(tests, ":")
+	}
+
+	history := loadHistory()
+	plan := tester.Plan(framework, testPrograms, filter, jobs, history.Durations())
+	for i := range plan {
+		plan[i] = newJob(plan[i])
+	}
+	if failedFirst {
+		failed := make(map[string]bool)
+		for _, c := range failedTests {
+			failed[c.FullName()] = true
+		}
+		sort.SliceStable(plan, func(i, j int) bool { return hasFailed(plan[i], failed) && !hasFailed(plan[j], failed) })
+	}
+	logger.Log.Infof("Running %d jobs on %d workers", len(plan), jobs)
+
+	var (
+		cases  []tester.Case
+		failed bool
+	)
+	tester.Run(framework, plan, jobs, filepath.Join(resultsPath(), "jobs"), func(result tester.JobResult) {
+		// 每个任务的输出在结束后整体打印, 避免交错
+		name := tester.ProgramName(result.Job.Program)
+		if result.Job.Shards > 0 {
+			name = fmt.Sprintf("%s [shard %d/%d]", name, result.Job.Shard+1, result.Job.Shards)
+		}
+
+		if result.Err != nil {
+			failed = true
+			fmt.Println(colors.RedBold("==== " + name + " ===="))
+		} else {
+			fmt.Println(colors.GreenBold("==== " + name + " ===="))
+		}
+		os.Stdout.Write(result.Output)
+		showCrash(result.Crash)
+
+		cases = append(cases, result.Cases...)
+	})
+
+	recordHistory(cases)
+	showResults(cases)
+	if report {
+		writeReport(cases)
+	}
+
+	exitOnFailure(cases, failed)
+}
+
+// hasFailed 判断任务中是否包含上次失败的用例
+func hasFailed(job tester.Job, failed map[string]bool) bool {
+	for _, test := range job.Tests {
+		if failed[test] {
+			return true
+		}
+	}
+	return false
+}
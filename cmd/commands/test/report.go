@@ -0,0 +1,86 @@
+package test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/logger/colors"
+	"github.com/zelviner/cgear/tester"
+)
+
+// 报告中列出的最慢测试数
+const slowestCount = 5
+
+// resultsPath 返回测试报告目录
+func resultsPath() string {
+	return filepath.Join(buildPath, "test-results")
+}
+
+// writeReport 把合并后的结果写入 JUnit XML 和 JSON 报告, 并打印汇总表
+func writeReport(cases []tester.Case) {
+	junitPath := filepath.Join(resultsPath(), "junit.xml")
+	if err := tester.WriteJUnit(junitPath, cases); err != nil {
+		logger.Log.Errorf("Failed to write JUnit report: %s", err)
+	} else {
+		logger.Log.Infof("JUnit report written to: %s", junitPath)
+	}
+
+	jsonPath := filepath.Join(resultsPath(), "summary.json")
+	if err := tester.WriteJSON(jsonPath, cases); err != nil {
+		logger.Log.Errorf("Failed to write JSON report: %s", err)
+	} else {
+		logger.Log.Infof("JSON report written to: %s", jsonPath)
+	}
+
+	printReport(tester.Summarize(cases))
+}
+
+// printReport 打印通过、失败、跳过和最慢的测试
+func printReport(summary tester.Summary) {
+	fmt.Println()
+	fmt.Println(colors.MagentaBold("TEST REPORT"))
+	fmt.Printf("    %-10s %d\n", colors.GreenBold("Passed"), summary.Passed)
+	fmt.Printf("    %-10s %d\n", colors.RedBold("Failed"), summary.Failed)
+	fmt.Printf("    %-10s %d\n", colors.YellowBold("Skipped"), summary.Skipped)
+	fmt.Printf("    %-10s %.3fs\n", colors.Bold("Time"), summary.Time)
+
+	if summary.Failed > 0 {
+		fmt.Println()
+		fmt.Println(colors.MagentaBold("FAILED TESTS"))
+		for _, c := range summary.Cases {
+			if c.Status == tester.StatusFailed {
+				fmt.Printf("    %-40s %s\n", c.FullName(), firstLine(c.Message))
+			}
+		}
+	}
+
+	var skipped []tester.Case
+	for _, c := range summary.Cases {
+		if c.Status == tester.StatusSkipped {
+			skipped = append(skipped, c)
+		}
+	}
+	if len(skipped) > 0 {
+		fmt.Println()
+		fmt.Println(colors.MagentaBold("SKIPPED TESTS"))
+		for _, c := range skipped {
+			fmt.Printf("    %-40s %s\n", c.FullName(), firstLine(c.Message))
+		}
+	}
+
+	if slowest := summary.Slowest(slowestCount); len(slowest) > 0 {
+		fmt.Println()
+		fmt.Println(colors.MagentaBold("SLOWEST TESTS"))
+		for i, c := range slowest {
+			fmt.Printf("    %d. %-37s %.3fs\n", i+1, c.FullName(), c.Time)
+		}
+	}
+	fmt.Println()
+}
+
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(message, "\n")
+	return line
+}
@@ -4,9 +4,10 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/zelviner/cgear/cmake"
 	"github.com/zelviner/cgear/cmd/commands"
@@ -14,47 +15,113 @@ import (
 	"github.com/zelviner/cgear/config"
 	"github.com/zelviner/cgear/logger"
 	"github.com/zelviner/cgear/logger/colors"
+	"github.com/zelviner/cgear/tester"
 	"github.com/zelviner/cgear/utils"
 )
 
 var CmdTest = &commands.Command{
-	UsageLine: "test [appname] [watchall] [-main=*.go] [-downdoc=true]  [-gendoc=true] [-vendor=true] [-e=folderToExclude] [-ex=extraPackageToWatch] [-tags=goBuildTags] [-runmode=BEEGO_RUNMODE]",
-	Short:     "Test the application by starting a local development server",
+	UsageLine: "test [Suite[.Case]] [-r] [-report] [-j=N] [-last-failed] [-failed-first] [-history=Suite.Case] [-repeat=N] [-shuffle] [-seed=N] [-ctest] [-R=regex] [-L=label] [-timeout=30s] [-binary-timeout=10m] [-affected] [-since=origin/main]",
+	Short:     "Build the project and run its unit tests",
 	Long: `
-Run command will supervise the filesystem of the application for any changes, and recompile/restart it.
-	`,
+Test builds the project and runs the test executables under bin/test.
+  The test framework (gtest, catch2 or doctest) is read from "test_framework" in cgear.json.
+
+  With -report every test executable writes a JUnit XML file, which is merged into
+  build/test-results/junit.xml and summarized in build/test-results/summary.json.
+  The JSON summary is generated from the XML results: Google Test accepts only one
+  --gtest_output, and Catch2 and doctest have no JSON reporter with the same content,
+  so JUnit XML is the one format all frameworks write.
+
+  {{"Example:"|bold}}
+    $ cgear test                       # List all test cases
+    $ cgear test Json                  # Run all cases of the Json suite
+    $ cgear test Json.parser           # Run a single case
+    $ cgear test -report               # Run all cases and write reports to build/test-results
+    $ cgear test -j 8                  # Run all cases on 8 workers
+    $ cgear test -last-failed          # Rerun the cases that failed last time
+    $ cgear test -history Json.parser  # Show recent results of a case
+    $ cgear test -repeat 20 -shuffle   # Detect flaky cases with random seeds
+    $ cgear test -timeout 30s          # Kill a test program when one case runs longer than 30s
+    $ cgear test -ctest -L json -j 4   # Run the tests registered in CTest
+    $ cgear test -affected             # Run only the test programs affected by changes since origin/main
+    $ cgear test -affected -since HEAD # Run only the test programs affected by uncommitted changes
+`,
 	PreRun: func(cmd *commands.Command, args []string) {},
 	Run:    RunTest,
 }
 
 var (
-	rebuild   bool // 是否重新构建
-	appPath   string
-	buildPath string
-	testPath  string
-	testInfos []string
+	rebuild     bool          // 是否重新构建
+	useCTest    bool          // 是否通过 ctest 运行测试
+	regex       string        // ctest -R
+	label       string        // ctest -L
+	jobs        int           // 并行数
+	timeout     time.Duration // 单个测试的超时时间
+	binTimeout  time.Duration // 单个测试程序的超时时间
+	repeat      string        // ctest --repeat
+	report      bool          // 是否生成测试报告
+	lastFailed  bool          // 只运行上次失败的用例
+	failedFirst bool          // 先运行上次失败的用例
+	historyOf   string        // 显示指定用例的历史结果
+	shuffle     bool          // 打乱用例的运行顺序
+	seed        int           // 打乱顺序使用的随机种子
+	affected    bool          // 只运行受改动影响的测试程序
+	since       string        // 计算改动的基准提交
+	appPath     string
+	buildPath   string
+	testPath    string
+	framework   tester.Framework // 项目使用的单元测试框架
 )
 
 func init() {
 	CmdTest.Flag.BoolVar(&rebuild, "r", false, "Clear the build folder in the project and rebuild, default false")
+	CmdTest.Flag.BoolVar(&useCTest, "ctest", false, "Register and run the tests through CTest, default false")
+	CmdTest.Flag.StringVar(&regex, "R", "", "Run only the CTest tests whose names match the regular expression")
+	CmdTest.Flag.StringVar(&label, "L", "", "Run only the CTest tests with the given label")
+	CmdTest.Flag.IntVar(&jobs, "j", 0, "Number of tests or test shards to run in parallel")
+	CmdTest.Flag.DurationVar(&timeout, "timeout", 0, "Timeout for each test, for example 30s. Applies to the whole test program when the framework does not report test progress")
+	CmdTest.Flag.DurationVar(&binTimeout, "binary-timeout", 0, "Timeout for each test program, for example 10m")
+	CmdTest.Flag.StringVar(&repeat, "repeat", "", "Repeat the tests N times to detect flaky tests, or until-fail:N with -ctest")
+	CmdTest.Flag.BoolVar(&report, "report", false, "Write JUnit XML and JSON reports to build/test-results, default false")
+	CmdTest.Flag.BoolVar(&lastFailed, "last-failed", false, "Rerun only the tests that failed in the last run, default false")
+	CmdTest.Flag.BoolVar(&failedFirst, "failed-first", false, "Run the tests that failed in the last run first, default false")
+	CmdTest.Flag.BoolVar(&shuffle, "shuffle", false, "Run the tests in a random order, default false")
+	CmdTest.Flag.IntVar(&seed, "seed", 0, "Random seed used with -shuffle, 0 means a random one")
+	CmdTest.Flag.BoolVar(&affected, "affected", false, "Run only the test programs that depend on files changed in git, default false")
+	CmdTest.Flag.StringVar(&since, "since", "origin/main", "Git revision the changes of -affected are compared against")
+	CmdTest.Flag.StringVar(&historyOf, "history", "", "Show recent results and durations of a test, for example Json.parser")
 	commands.AvailableCommands = append(commands.AvailableCommands, CmdTest)
 }
 
 func RunTest(cmd *commands.Command, args []string) int {
 
-	appPath := utils.GetCgearWorkPath()
+	appPath = utils.GetCgearWorkPath()
 	buildPath = filepath.Join(appPath, "build")
 	testPath = filepath.Join(appPath, "bin", "test")
 
-	if len(args) == 0 {
-		showTest()
-	} else {
-		if len(args) > 2 {
-			err := cmd.Flag.Parse(args[1:])
-			if err != nil {
-				logger.Log.Fatal("Parse args err" + err.Error())
-			}
+	var err error
+	framework, err = tester.Lookup(config.Conf.TestFramework)
+	if err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+
+	if len(args) > 1 {
+		err = cmd.Flag.Parse(args[1:])
+		if err != nil {
+			logger.Log.Fatal("Parse args err" + err.Error())
 		}
+	}
+
+	switch {
+	case historyOf != "":
+		showHistory(historyOf)
+	case useCTest:
+		runCTest(args)
+	case len(args) == 0 && !report && !lastFailed && !failedFirst && !affected && repeat == "":
+		showTest()
+	case len(args) == 0:
+		runTest("")
+	default:
 		runTest(args[0])
 	}
 
@@ -76,54 +143,77 @@ func showTest() {
 	}
 	defer restore() // 确保在函数结束时恢复原始 PATH
 
-	filepath.Walk(testPath, func(path string, info fs.FileInfo, err error) error {
+	for _, program := range findTestPrograms() {
+		tests, err := tester.ListTests(framework, program, "")
 		if err != nil {
-			return err
+			logger.Log.Fatal(err.Error())
 		}
-		if index := strings.Index(path, "_test.exe"); index != -1 {
-			cmd := exec.Command(path, "--gtest_list_tests")
-			bytes, err := cmd.Output()
-			if err != nil {
-				logger.Log.Fatal(err.Error())
-			}
-
-			testInfos = strings.Split(string(bytes), "\n")
-			for _, testInfo := range testInfos {
-				switch {
 
-				case strings.HasPrefix(testInfo, "Running"):
+		var suite string
+		for _, test := range tests {
+			testSuite, testCase := tester.SplitName(test)
+			if testSuite != suite {
+				suite = testSuite
+				fmt.Println(`    ├── ` + colors.RedBold(suite))
+			}
+			fmt.Println(`    │    └── ` + testCase)
+		}
+	}
 
-				case strings.Index(testInfo, ".") != -1:
-					testInfo = colors.RedBold(testInfo[:len(testInfo)-2])
-					fmt.Println(`    ├── ` + testInfo)
+	fmt.Println()
 
-				case strings.HasPrefix(testInfo, "  "):
-					fmt.Println(`    │    └── ` + testInfo[2:])
+}
 
-				}
-			}
+// showResults 以与 showTest 相同的树形结构打印测试结果
+func showResults(cases []tester.Case) {
+	fmt.Println()
 
+	suites, groups := tester.Suites(cases)
+	for _, suite := range suites {
+		fmt.Println(`    ├── ` + colors.RedBold(suite))
+		for _, c := range groups[suite] {
+			fmt.Printf("    │    └── %s %s (%.3fs)\n", c.Name, statusText(c.Status), c.Time)
 		}
-		return nil
-	})
+	}
 
 	fmt.Println()
+	summary := tester.Summarize(cases)
+	if summary.Failed > 0 {
+		logger.Log.Errorf("%d passed, %d failed, %d skipped", summary.Passed, summary.Failed, summary.Skipped)
+	} else {
+		logger.Log.Successf("%d passed, %d failed, %d skipped", summary.Passed, summary.Failed, summary.Skipped)
+	}
+}
 
+func statusText(status string) string {
+	switch status {
+	case tester.StatusPassed:
+		return colors.GreenBold("PASSED")
+	case tester.StatusFailed:
+		return colors.RedBold("FAILED")
+	case tester.StatusSkipped:
+		return colors.YellowBold("SKIPPED")
+	}
+	return status
 }
 
+// runTest 构建项目并运行测试, testName 为空时运行所有测试程序
 func runTest(testName string) {
 
 	var (
-		testProgram string
-		testExe     string
+		programs []string
+		filter   string
 	)
 
-	if index := strings.Index(testName, "."); index == -1 {
-
-		testProgram = getTestProgramName(testName) + "_test.exe"
-		testName += "*"
-	} else {
-		testProgram = getTestProgramName(testName[:index]) + "_test.exe"
+	switch index := strings.Index(testName, "."); {
+	case testName == "":
+		filter = "*"
+	case index == -1:
+		programs = append(programs, filepath.Join(testPath, getTestProgramName(testName)+"_test.exe"))
+		filter = testName + "*"
+	default:
+		programs = append(programs, filepath.Join(testPath, getTestProgramName(testName[:index])+"_test.exe"))
+		filter = testName
 	}
 
 	configArg := cmake.ConfigArg{
@@ -133,6 +223,7 @@ func runTest(testName string) {
 		Generator:             config.Conf.Generator,
 		NoWarnUnusedCli:       true,
 		ExportCompileCommands: true,
+		CodemodelQuery:        affected,
 		ProjectPath:           appPath,
 		BuildPath:             buildPath,
 		CXXFlags:              "-D_MD",
@@ -141,7 +232,7 @@ func runTest(testName string) {
 	buildArg := cmake.BuildArg{
 		BuildPath: buildPath,
 		BuildType: config.Conf.BuildType,
-		IsMSVC:    config.Conf.Toolchain.IsMSVC,
+		IsMSVC:    configArg.Toolchain != nil && configArg.Toolchain.IsMSVC,
 	}
 
 	// 设置临时环境变量
@@ -155,23 +246,129 @@ func runTest(testName string) {
 	}
 	defer restore() // 确保在函数结束时恢复原始 PATH
 
-	// testName := cases.Title(language.English).String(testName)
 	err = cmake.Build(&configArg, &buildArg, rebuild, false)
 	if err != nil {
 		logger.Log.Fatal(err.Error())
 	}
 
-	testExe = filepath.Join(testPath, testProgram)
+	if len(programs) == 0 {
+		programs = findTestPrograms()
+	}
 
-	arg := fmt.Sprintf("--gtest_filter=%s", testName)
-	c := exec.Command(testExe, arg)
-	c.Stdout = os.Stdout
-	c.Stderr = os.Stderr
-	err = c.Run()
-	if err != nil {
-		logger.Log.Fatal(err.Error())
+	if affected {
+		programs = affectedPrograms(programs)
+		showAffected(programs)
+		if len(programs) == 0 {
+			logger.Log.Success("No tests affected")
+			return
+		}
 	}
 
+	// 上次失败的用例
+	var failedTests []tester.Case
+	if lastFailed || failedFirst {
+		failedTests = previouslyFailed(filter)
+		if lastFailed && len(failedTests) == 0 {
+			logger.Log.Success("No failed tests in the last run")
+			return
+		}
+	}
+
+	if repeat != "" {
+		count, err := strconv.Atoi(repeat)
+		if err != nil || count < 1 {
+			logger.Log.Fatalf("Invalid repeat count '%s', until-fail:N is only supported with -ctest", repeat)
+		}
+		runRepeat(programs, filter, count)
+		return
+	}
+
+	if jobs > 1 {
+		runParallel(programs, filter, failedTests)
+		return
+	}
+
+	var (
+		cases  []tester.Case
+		failed bool
+	)
+	for i, job := range serialPlan(programs, filter, failedTests) {
+		xmlPath := filepath.Join(resultsPath(), "gtest", fmt.Sprintf("%s-%d.xml", tester.ProgramName(job.Program), i))
+		result := tester.RunJob(framework, job, xmlPath, os.Stdout)
+		if result.Err != nil {
+			logger.Log.Errorf("%s: %s", tester.ProgramName(job.Program), result.Err)
+			showCrash(result.Crash)
+			failed = true
+		}
+		cases = append(cases, result.Cases...)
+	}
+
+	recordHistory(cases)
+	if report {
+		writeReport(cases)
+	}
+
+	exitOnFailure(cases, failed)
+}
+
+// serialPlan 生成依次运行的任务: 指定 -last-failed 时只运行上次失败的用例,
+// 指定 -failed-first 时先运行上次失败的用例, 再运行其余用例
+func serialPlan(programs []string, filter string, failedTests []tester.Case) []tester.Job {
+	failedByProgram := groupByProgram(failedTests)
+
+	var plan []tester.Job
+	if lastFailed || failedFirst {
+		for _, program := range programs {
+			if tests := failedByProgram[program]; len(tests) > 0 {
+				plan = append(plan, newJob(tester.Job{Program: program, Tests: tests}))
+			}
+		}
+	}
+
+	if lastFailed {
+		return plan
+	}
+
+	for _, program := range programs {
+		programFilter := filter
+		if tests := failedByProgram[program]; failedFirst && len(tests) > 0 {
+			programFilter = excludeTests(filter, tests)
+		}
+		plan = append(plan, newJob(tester.Job{Program: program, Filter: programFilter}))
+	}
+
+	return plan
+}
+
+// newJob 把命令行指定的运行选项设置到任务上
+func newJob(job tester.Job) tester.Job {
+	job.Shuffle, job.Seed = shuffle, seed
+	job.Timeout, job.BinaryTimeout = timeout, binTimeout
+	return job
+}
+
+// excludeTests 在 gtest 过滤器中排除指定的用例
+func excludeTests(filter string, tests []string) string {
+	if strings.Contains(filter, "-") {
+		return filter + ":" + strings.Join(tests, ":")
+	}
+	return filter + "-" + strings.Join(tests, ":")
+}
+
+// findTestPrograms 查找 bin/test 下的所有测试程序
+func findTestPrograms() []string {
+	var programs []string
+	filepath.Walk(testPath, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(info.Name(), "_test.exe") {
+			programs = append(programs, path)
+		}
+		return nil
+	})
+
+	return programs
 }
 
 func getTestProgramName(testName string) string {
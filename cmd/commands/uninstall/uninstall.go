@@ -0,0 +1,56 @@
+package uninstall
+
+import (
+	"path/filepath"
+
+	"github.com/zelviner/cgear/cmd/commands"
+	"github.com/zelviner/cgear/cmd/commands/version"
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/deps"
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/utils"
+)
+
+var CmdUninstall = &commands.Command{
+	UsageLine: "uninstall <package> [-triplet=x64-windows]",
+	Short:     "Remove an installed package from CGEAR_HOME",
+	Long: `
+Uninstall removes exactly the files installed by a package from CGEAR_HOME/installed/<triplet>.
+  A package that another installed package depends on is not removed.
+
+  {{"Example:"|bold}}
+    $ cgear uninstall fmt
+`,
+	PreRun: func(cmd *commands.Command, args []string) { version.ShowShortVersionBanner() },
+	Run:    RunUninstall,
+}
+
+var triplet string // 安装目录名
+
+func init() {
+	CmdUninstall.Flag.StringVar(&triplet, "triplet", "", "Installed triplet, default the platform of the project")
+	commands.AvailableCommands = append(commands.AvailableCommands, CmdUninstall)
+}
+
+func RunUninstall(cmd *commands.Command, args []string) int {
+	if len(args) > 1 {
+		if err := cmd.Flag.Parse(args[1:]); err != nil {
+			logger.Log.Fatal("Parse args err" + err.Error())
+		}
+	}
+	if len(args) == 0 {
+		logger.Log.Fatal("Argument <package> is missing")
+	}
+
+	if triplet == "" {
+		triplet = deps.Triplet(config.Conf.Platform)
+	}
+	prefix := filepath.Join(utils.GetCgearInstalledPath(), triplet)
+
+	if err := deps.Uninstall(prefix, args[0]); err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+
+	logger.Log.Successf("Successfully uninstalled '%s'", args[0])
+	return 0
+}
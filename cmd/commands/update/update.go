@@ -0,0 +1,160 @@
+package update
+
+import (
+	"path/filepath"
+
+	"github.com/zelviner/cgear/cmd/commands"
+	"github.com/zelviner/cgear/cmd/commands/version"
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/deps"
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/utils"
+)
+
+var CmdUpdate = &commands.Command{
+	UsageLine: "update [package] [-latest] [-j=4]",
+	Short:     "Update the locked revisions of dependencies and reinstall them",
+	Long: `
+Update resolves the declared version of each dependency again, for example the newest commit
+  of a branch or the newest tag matching a version constraint, installs the new revision and
+  records it in cgear.lock.
+
+  With -latest, the declared version is first changed to the newest tag of the repository:
+  a tag is replaced with the newest tag, a constraint with ^<newest tag>. The new versions are
+  written to cgear.json or Cgearfile once they are installed. See 'cgear help outdated'.
+
+  {{"Example:"|bold}}
+    $ cgear update               # Update all dependencies
+    $ cgear update fmt           # Update only fmt
+    $ cgear update -latest fmt   # Move fmt to its newest tag, even a new major version
+    $ cgear update -j 2          # Build at most two libraries at the same time
+`,
+	PreRun: func(cmd *commands.Command, args []string) { version.ShowShortVersionBanner() },
+	Run:    RunUpdate,
+}
+
+var (
+	jobs   int  // 同时进行的构建数
+	latest bool // 把声明的版本改为最新的标签
+)
+
+func init() {
+	CmdUpdate.Flag.IntVar(&jobs, "j", 0, "Number of builds to run at the same time, default the number of CPUs")
+	CmdUpdate.Flag.BoolVar(&latest, "latest", false, "Change the declared versions to the newest tags before updating, default false")
+	commands.AvailableCommands = append(commands.AvailableCommands, CmdUpdate)
+}
+
+func RunUpdate(cmd *commands.Command, args []string) int {
+	if len(args) > 1 {
+		if err := cmd.Flag.Parse(args[1:]); err != nil {
+			logger.Log.Fatal("Parse args err" + err.Error())
+		}
+	}
+
+	dependencies := config.Conf.Dependencies
+	if len(dependencies) == 0 {
+		logger.Log.Fatal("No dependencies declared in cgear.json")
+	}
+	if err := deps.Validate(dependencies); err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+
+	lockPath := filepath.Join(utils.GetCgearWorkPath(), deps.LockFile)
+	lock, err := deps.ReadLock(lockPath)
+	if err != nil {
+		logger.Log.Fatalf("Failed to read %s: %s", deps.LockFile, err)
+	}
+
+	// 删除锁定的版本后重新解析
+	if len(args) > 0 {
+		found := lock.Find(args[0]) != nil
+		for _, dep := range dependencies {
+			found = found || dep.Name == args[0]
+		}
+		if !found {
+			logger.Log.Fatalf("Dependency '%s' is not declared in cgear.json", args[0])
+		}
+		lock.Remove(args[0])
+	} else {
+		lock.Packages = nil
+	}
+
+	installer := deps.NewInstaller()
+	installer.Jobs = jobs
+	bumped := false
+	if latest {
+		var name string
+		if len(args) > 0 {
+			name = args[0]
+		}
+		dependencies, bumped = bumpLatest(installer, dependencies, name)
+	}
+	installed, _, err := installer.Sync(dependencies, lock, false)
+	if err != nil {
+		logger.Log.Fatal(err.Error())
+	}
+
+	// 新的版本安装成功后才与锁文件一起写入清单
+	if bumped {
+		if err := config.SaveDependencies(utils.GetCgearWorkPath(), dependencies); err != nil {
+			logger.Log.Fatalf("Failed to save the new versions: %s", err)
+		}
+		config.Conf.Dependencies = dependencies
+	}
+	if err := lock.Save(lockPath); err != nil {
+		logger.Log.Fatalf("Failed to write %s: %s", deps.LockFile, err)
+	}
+
+	logger.Log.Successf("%d dependencies updated, %s written", installed, deps.LockFile)
+	return 0
+}
+
+// bumpLatest 返回把 name 的声明版本改为最新的标签后的依赖和是否有版本改变, name 为空时修改所有依赖。
+// 版本是约束时改为 ^<最新的标签>, 以压缩包声明或在来源中指定版本的依赖不修改。
+func bumpLatest(installer *deps.Installer, dependencies []config.Dependency, name string) ([]config.Dependency, bool) {
+	dependencies = append([]config.Dependency(nil), dependencies...)
+	found, changed := false, false
+	for i, dep := range dependencies {
+		if name != "" && dep.Name != name {
+			continue
+		}
+		found = true
+
+		if dep.URL != "" {
+			logger.Log.Warnf("Skipping '%s', change the url and sha256 of the archive to update it", dep.Name)
+			continue
+		}
+		if source, err := deps.ParseSource(dep.Source); err == nil && source.Ref != "" {
+			logger.Log.Warnf("Skipping '%s', its version is part of the source '%s'", dep.Name, dep.Source)
+			continue
+		}
+
+		outdated, err := installer.CheckOutdated(dep, "")
+		if err != nil {
+			logger.Log.Fatal(err.Error())
+		}
+		if outdated.Latest == "" {
+			logger.Log.Warnf("Skipping '%s', its repository has no version tags", dep.Name)
+			continue
+		}
+
+		version := outdated.Latest
+		if deps.IsConstraint(dep.Version) {
+			version = "^" + outdated.Latest
+		}
+		if version != dep.Version {
+			previous := dep.Version
+			if previous == "" {
+				previous = "default branch"
+			}
+			logger.Log.Infof("%s: %s -> %s", dep.Name, previous, version)
+			dependencies[i].Version = version
+			changed = true
+		}
+	}
+
+	if !found {
+		logger.Log.Fatalf("Dependency '%s' is not declared in cgear.json, -latest only changes declared versions", name)
+	}
+	return dependencies, changed
+}
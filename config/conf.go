@@ -27,6 +27,26 @@ type Config struct {
 	ProjectType         string     `json:"project_type" yaml:"project_type"`                 // 项目类型
 	ProjectPath         string     `json:"project_path" yaml:"project_path"`                 // 项目路径
 	RuntimeDependencies []string   `json:"runtime_dependencies" yaml:"runtime_dependencies"` // 运行时依赖动态库
+	TestFramework       string     `json:"test_framework" yaml:"test_framework"`             // 单元测试框架: gtest, catch2 或 doctest
+	Quarantine          []string   `json:"quarantine,omitempty" yaml:"quarantine,omitempty"` // 隔离的不稳定用例, 失败时只报告不影响结果
+
+	Dependencies []Dependency      `json:"dependencies,omitempty" yaml:"dependencies,omitempty"` // 第三方库依赖
+	Mirrors      map[string]string `json:"mirrors,omitempty" yaml:"mirrors,omitempty"`           // 依赖地址的镜像, 把地址前缀替换为内部服务器或本地目录
+}
+
+// Dependency 是项目声明的一个第三方库依赖
+type Dependency struct {
+	Name       string            `json:"name" yaml:"name"`                                 // 包名
+	Source     string            `json:"source,omitempty" yaml:"source,omitempty"`         // 来源, 例如 fmtlib:fmt
+	URL        string            `json:"url,omitempty" yaml:"url,omitempty"`               // 源码压缩包的地址, 代替 source
+	SHA256     string            `json:"sha256,omitempty" yaml:"sha256,omitempty"`         // 压缩包的 sha256, 使用 url 时必须指定
+	Version    string            `json:"version,omitempty" yaml:"version,omitempty"`       // git 标签或分支, 为空时使用默认分支; 使用 url 时只用于目录名
+	Patches    []string          `json:"patches,omitempty" yaml:"patches,omitempty"`       // 构建前应用的补丁, 相对项目目录
+	Options    map[string]string `json:"options,omitempty" yaml:"options,omitempty"`       // CMake 缓存变量, 例如 FMT_TEST: "OFF"
+	Components []string          `json:"components,omitempty" yaml:"components,omitempty"` // 要安装的组件, 为空时安装全部
+
+	BuildTypeOptions map[string]map[string]string `json:"build_type_options,omitempty" yaml:"build_type_options,omitempty"` // 按构建类型覆盖的缓存变量, 例如 Debug: {FMT_DEBUG_POSTFIX: d}
+	Build            []string                     `json:"build,omitempty" yaml:"build,omitempty"`                           // 代替 CMake 的构建命令, 在源码目录中依次执行
 }
 
 type Toolchain struct {
@@ -107,6 +127,15 @@ func LaodConfig() {
 
 }
 
+// ReadFile 读取指定的 cgear.json 或 Cgearfile, 不修改全局配置
+func ReadFile(path string) (*Config, error) {
+	conf := &Config{}
+	if filepath.Base(path) == "Cgearfile" {
+		return conf, parseYAML(path, conf)
+	}
+	return conf, parseJSON(path, conf)
+}
+
 func parseJSON(path string, v interface{}) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SaveDependencies 把 dependencies 写入 projectPath 中的 cgear.json 或 Cgearfile。
+// 只替换 dependencies 字段, 其他字段和它们的顺序保持不变; dependencies 为空时删除该字段。
+func SaveDependencies(projectPath string, dependencies []Dependency) error {
+	path := filepath.Join(projectPath, "cgear.json")
+	if _, err := os.Stat(path); err == nil {
+		return saveJSONDependencies(path, dependencies)
+	}
+	path = filepath.Join(projectPath, "Cgearfile")
+	if _, err := os.Stat(path); err == nil {
+		return saveYAMLDependencies(path, dependencies)
+	}
+	return fmt.Errorf("no cgear.json or Cgearfile in %s", projectPath)
+}
+
+func saveJSONDependencies(path string, dependencies []Dependency) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	// 按原来的顺序读取顶层的字段
+	type field struct {
+		key   string
+		value json.RawMessage
+	}
+	var fields []field
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return fmt.Errorf("%s is not a JSON object", path)
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		key, _ := token.(string)
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		fields = append(fields, field{key, value})
+	}
+
+	// 不转义 <、> 和 &, 版本约束中常用
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(dependencies); err != nil {
+		return err
+	}
+	value := json.RawMessage(bytes.TrimSpace(buf.Bytes()))
+
+	replaced := false
+	for i := 0; i < len(fields); i++ {
+		if fields[i].key != "dependencies" {
+			continue
+		}
+		if len(dependencies) == 0 {
+			fields = append(fields[:i], fields[i+1:]...)
+			i--
+		} else {
+			fields[i].value = value
+		}
+		replaced = true
+	}
+	if !replaced && len(dependencies) > 0 {
+		fields = append(fields, field{"dependencies", value})
+	}
+
+	var out bytes.Buffer
+	out.WriteString("{")
+	for i, f := range fields {
+		if i > 0 {
+			out.WriteString(",")
+		}
+		key, _ := json.Marshal(f.key)
+		out.WriteString("\n\t" + string(key) + ": ")
+		if err := json.Indent(&out, f.value, "\t", "\t"); err != nil {
+			return err
+		}
+	}
+	out.WriteString("\n}\n")
+	return os.WriteFile(path, out.Bytes(), 0644)
+}
+
+func saveYAMLDependencies(path string, dependencies []Dependency) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var fields yaml.MapSlice
+	if err := yaml.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	replaced := false
+	for i := 0; i < len(fields); i++ {
+		if fields[i].Key != "dependencies" {
+			continue
+		}
+		if len(dependencies) == 0 {
+			fields = append(fields[:i], fields[i+1:]...)
+			i--
+		} else {
+			fields[i].Value = dependencies
+		}
+		replaced = true
+	}
+	if !replaced && len(dependencies) > 0 {
+		fields = append(fields, yaml.MapItem{Key: "dependencies", Value: dependencies})
+	}
+
+	out, err := yaml.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, 0644)
+}
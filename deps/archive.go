@@ -0,0 +1,223 @@
+package deps
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// 支持的源码压缩包格式
+var archiveExts = []string{".tar.gz", ".tgz", ".tar.xz", ".txz", ".zip"}
+
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// ArchiveExt 返回地址指向的压缩包的扩展名, 不支持的格式返回空字符串
+func ArchiveExt(url string) string {
+	name := strings.ToLower(url)
+	if index := strings.IndexAny(name, "?#"); index != -1 {
+		name = name[:index]
+	}
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(name, ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// Download 下载 url 到 dest 并校验 sha256。dest 已存在且摘要一致时不再下载。
+// url 不是 http:// 或 https:// 地址时是本地文件, 例如镜像目录中的压缩包。
+func Download(url, sum, dest string, output io.Writer) error {
+	sum = strings.ToLower(sum)
+	if actual, err := fileSHA256(dest); err == nil && actual == sum {
+		return nil
+	}
+
+	if output != nil {
+		fmt.Fprintf(output, "Downloading %s\n", url)
+	}
+	body, err := open(url)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(dest), ".download-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, hash), body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != sum {
+		return fmt.Errorf("sha256 mismatch for %s: expected %s, got %s", url, sum, actual)
+	}
+	return os.Rename(f.Name(), dest)
+}
+
+// open 打开 http(s) 地址或本地文件
+func open(url string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return os.Open(strings.TrimPrefix(url, "file://"))
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Unpack 把压缩包解压到 dir, dir 中原有的内容会被删除。
+// 压缩包中只有一个顶层目录时 (例如 fmt-10.2.1/), 该目录的内容放在 dir 下。
+func Unpack(archive, dir string) error {
+	tmp := dir + ".unpack"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tmp, 0755); err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	var err error
+	switch ext := ArchiveExt(archive); ext {
+	case ".tar.gz", ".tgz":
+		var f *os.File
+		if f, err = os.Open(archive); err == nil {
+			err = extract(f, tmp)
+			f.Close()
+		}
+	case ".zip":
+		err = unzip(archive, tmp)
+	case ".tar.xz", ".txz":
+		// Go 标准库不支持 xz, 使用系统的 tar, Windows 10 以后也自带
+		var output []byte
+		if output, err = exec.Command("tar", "-xJf", archive, "-C", tmp).CombinedOutput(); err != nil {
+			err = fmt.Errorf("tar: %s", strings.TrimSpace(string(output)))
+		}
+	default:
+		err = fmt.Errorf("unsupported archive '%s', use %s", filepath.Base(archive), strings.Join(archiveExts, ", "))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unpack %s: %w", filepath.Base(archive), err)
+	}
+
+	root := tmp
+	if entries, err := os.ReadDir(tmp); err == nil && len(entries) == 1 && entries[0].IsDir() {
+		root = filepath.Join(tmp, entries[0].Name())
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return os.Rename(root, dir)
+}
+
+// unzip 把 zip 文件解压到 dir, 拒绝写到 dir 之外的路径
+func unzip(archive, dir string) error {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, file := range r.File {
+		name := path.Clean(strings.ReplaceAll(file.Name, `\`, "/"))
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("invalid path '%s' in archive", file.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			return err
+		}
+		mode := file.Mode().Perm()
+		if mode == 0 {
+			mode = 0644
+		}
+		dst, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+		if err != nil {
+			src.Close()
+			return err
+		}
+		_, err = io.Copy(dst, src)
+		src.Close()
+		dst.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyPatches 在 dir 中依次应用补丁, 补丁使用 git diff 的格式 (-p1)。相对路径以当前目录为基准。
+func ApplyPatches(dir string, patches []string, output io.Writer) error {
+	for _, patch := range patches {
+		path, err := filepath.Abs(patch)
+		if err != nil {
+			return err
+		}
+		if output != nil {
+			fmt.Fprintf(output, "Applying %s\n", path)
+		}
+
+		// dir 不是 git 仓库时, 不向上查找仓库, 使补丁中的路径相对 dir
+		cmd := exec.Command("git", "apply", "--whitespace=nowarn", path)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_CEILING_DIRECTORIES="+filepath.Dir(dir))
+		if result, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to apply patch %s: %s", patch, strings.TrimSpace(string(result)))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package deps
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	"github.com/zelviner/cgear/config"
+)
+
+// buildOptions 返回依赖以 buildType 构建时的缓存变量: options 被 build_type_options 中同名的变量覆盖
+func buildOptions(dep config.Dependency, buildType string) map[string]string {
+	overrides := dep.BuildTypeOptions[buildType]
+	if len(overrides) == 0 {
+		return dep.Options
+	}
+
+	options := make(map[string]string, len(dep.Options)+len(overrides))
+	for name, value := range dep.Options {
+		options[name] = value
+	}
+	for name, value := range overrides {
+		options[name] = value
+	}
+	return options
+}
+
+// BuildHash 返回依赖的构建类型选项、补丁内容和构建命令的摘要, 它们改变后需要重新构建。
+// 都没有声明时返回空字符串, 以兼容没有该字段的安装记录。
+func BuildHash(dep config.Dependency) string {
+	if len(dep.BuildTypeOptions) == 0 && len(dep.Patches) == 0 && len(dep.Build) == 0 {
+		return ""
+	}
+
+	hash := sha256.New()
+	for _, buildType := range sortedKeys(dep.BuildTypeOptions) {
+		options := dep.BuildTypeOptions[buildType]
+		for _, name := range sortedKeys(options) {
+			fmt.Fprintf(hash, "option[%s]=%s=%s\n", buildType, name, options[name])
+		}
+	}
+	for _, patch := range dep.Patches {
+		fmt.Fprintf(hash, "patch=%s\n", patchDigest(patch))
+	}
+	for _, command := range dep.Build {
+		fmt.Fprintf(hash, "build=%s\n", command)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// patchDigest 返回补丁内容的 sha256, 读取失败时返回补丁的路径, 应用补丁时会报告错误
+func patchDigest(patch string) string {
+	if sum, err := fileSHA256(patch); err == nil {
+		return sum
+	}
+	return patch
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// runBuild 在源码目录中依次执行依赖声明的构建命令, 代替 CMake 构建不使用 CMake 的库。
+// 命令把文件安装到 CGEAR_INSTALL_DIR, 之后被复制到 prefix, 返回安装的文件。
+// 命令可以使用的环境变量:
+//
+//	CGEAR_SOURCE_DIR   源码目录
+//	CGEAR_BUILD_DIR    构建目录, 每次构建前清空
+//	CGEAR_INSTALL_DIR  安装目录
+//	CGEAR_PREFIX_PATH  已安装的依赖所在的目录
+//	CGEAR_BUILD_TYPE   Debug 或 Release
+//
+// 以及构建类型的缓存变量, 和工具链的 CC、CXX。
+func (in *Installer) runBuild(dep config.Dependency, sourcePath, buildType, prefix string, output io.Writer) ([]string, error) {
+	buildPath := filepath.Join(sourcePath, "build", buildType)
+	stagePath := filepath.Join(buildPath, "install")
+	if err := os.RemoveAll(buildPath); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(stagePath, 0755); err != nil {
+		return nil, err
+	}
+
+	environ := append(os.Environ(),
+		"CGEAR_SOURCE_DIR="+sourcePath,
+		"CGEAR_BUILD_DIR="+buildPath,
+		"CGEAR_INSTALL_DIR="+stagePath,
+		"CGEAR_PREFIX_PATH="+in.Prefix(),
+		"CGEAR_BUILD_TYPE="+buildType,
+	)
+	if in.Toolchain != nil && !in.Toolchain.IsMSVC {
+		if in.Toolchain.Compiler.C != "" {
+			environ = append(environ, "CC="+in.Toolchain.Compiler.C)
+		}
+		if in.Toolchain.Compiler.CXX != "" {
+			environ = append(environ, "CXX="+in.Toolchain.Compiler.CXX)
+		}
+	}
+	options := buildOptions(dep, buildType)
+	for _, name := range sortedKeys(options) {
+		environ = append(environ, name+"="+options[name])
+	}
+
+	if output == nil {
+		output = io.Discard
+	}
+	for _, command := range dep.Build {
+		fmt.Fprintf(output, "$ %s\n", command)
+		cmd := shellCommand(command)
+		cmd.Dir = sourcePath
+		cmd.Env = environ
+		cmd.Stdout = output
+		cmd.Stderr = output
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("'%s' failed: %w", command, err)
+		}
+	}
+
+	files, err := copyTree(stagePath, prefix)
+	if err != nil {
+		return nil, err
+	}
+	relocate(prefix, files, stagePath)
+
+	// 返回相对 Installer.Prefix 的路径, Debug 版本在 debug/ 下
+	if prefix != in.Prefix() {
+		base, err := filepath.Rel(in.Prefix(), prefix)
+		if err != nil {
+			return nil, err
+		}
+		for i, file := range files {
+			files[i] = filepath.ToSlash(filepath.Join(base, file))
+		}
+	}
+	return files, nil
+}
+
+// shellCommand 返回以系统的 shell 执行 command 的命令
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
+
+// copyTree 把 src 下的文件复制到 dst, 保留指向 dst 之内的符号链接, 返回复制的文件, 以 / 分隔并相对 dst
+func copyTree(src, dst string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if err := checkParent(dst, target); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		os.Remove(target)
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := checkLink(dst, target, link); err != nil {
+				return err
+			}
+			if err := os.Symlink(link, target); err != nil {
+				return err
+			}
+		} else if err := copyFile(path, target, info.Mode().Perm()); err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	return files, err
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
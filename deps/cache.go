@@ -0,0 +1,371 @@
+package deps
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/utils"
+)
+
+// CacheEntry 描述二进制缓存中一个构建好的包, 保存为 <key>.json, 文件保存为 <key>.tar.gz
+type CacheEntry struct {
+	Key       string            `json:"key"`
+	Name      string            `json:"name"`
+	Revision  string            `json:"revision"`
+	Triplet   string            `json:"triplet"`
+	BuildType string            `json:"build_type"`
+	Toolchain string            `json:"toolchain"`
+	Options   map[string]string `json:"options,omitempty"`
+	Prefix    string            `json:"prefix"` // 构建时的安装路径
+	Files     []string          `json:"files"`  // 安装的文件, 相对安装路径
+	Size      int64             `json:"size"`   // 压缩包的大小
+	Created   time.Time         `json:"created"`
+}
+
+// CacheKey 根据包、提交、工具链、平台、构建类型、选项、补丁和构建命令计算缓存的键。
+// requires 是构建时已安装的依赖, 以 name@revision 表示, 它们改变后需要重新构建。
+func CacheKey(dep config.Dependency, revision, toolchain, triplet, buildType string, requires []string) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "name=%s\nrevision=%s\ntoolchain=%s\ntriplet=%s\nbuild_type=%s\n", dep.Name, revision, toolchain, triplet, buildType)
+
+	options := buildOptions(dep, buildType)
+	for _, name := range sortedKeys(options) {
+		fmt.Fprintf(hash, "option=%s=%s\n", name, options[name])
+	}
+	for _, component := range dep.Components {
+		fmt.Fprintf(hash, "component=%s\n", component)
+	}
+	for _, patch := range dep.Patches {
+		fmt.Fprintf(hash, "patch=%s\n", patchDigest(patch))
+	}
+	for _, command := range dep.Build {
+		fmt.Fprintf(hash, "build=%s\n", command)
+	}
+
+	sorted := append([]string(nil), requires...)
+	sort.Strings(sorted)
+	for _, require := range sorted {
+		fmt.Fprintf(hash, "requires=%s\n", require)
+	}
+
+	return dep.Name + "-" + strings.ToLower(buildType) + "-" + hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// Cache 是构建好的包的二进制缓存
+type Cache struct {
+	Store Store
+}
+
+// NewCache 打开 location 处的缓存, 见 OpenStore
+func NewCache(location string) *Cache {
+	return &Cache{Store: OpenStore(location)}
+}
+
+// Entries 返回缓存中所有的包, 按包名和创建时间排序
+func (c *Cache) Entries() ([]*CacheEntry, error) {
+	names, err := c.Store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*CacheEntry
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		entry, err := c.entry(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			continue // 不是缓存的记录或者正在被删除
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Name != entries[j].Name {
+			return entries[i].Name < entries[j].Name
+		}
+		return entries[i].Created.Before(entries[j].Created)
+	})
+	return entries, nil
+}
+
+func (c *Cache) entry(key string) (*CacheEntry, error) {
+	r, err := c.Store.Get(key + ".json")
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	entry := &CacheEntry{}
+	if err := json.NewDecoder(r).Decode(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Restore 把 key 对应的包解压到 prefix, 缓存中没有时返回 nil。
+// 包的构建路径与 prefix 不同时, 替换 CMake 配置和 pkg-config 文件中的旧路径。
+func (c *Cache) Restore(key, prefix string) (*CacheEntry, error) {
+	entry, err := c.entry(key)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := c.Store.Get(key + ".tar.gz")
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	if err := extract(r, prefix); err != nil {
+		return nil, err
+	}
+	if entry.Prefix != "" && entry.Prefix != prefix {
+		relocate(prefix, entry.Files, entry.Prefix)
+	}
+	return entry, nil
+}
+
+// Save 把 prefix 下 entry.Files 中的文件压缩后上传, 再上传 entry 的记录。
+// 文件不都在 prefix 下时不缓存, 返回错误。
+func (c *Cache) Save(entry *CacheEntry, prefix string) error {
+	for _, file := range entry.Files {
+		if filepath.IsAbs(filepath.FromSlash(file)) || strings.HasPrefix(file, "../") {
+			return fmt.Errorf("'%s' is installed outside of %s", file, prefix)
+		}
+	}
+
+	f, err := os.CreateTemp("", "cgear-cache-*.tar.gz")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := archive(f, prefix, entry.Files); err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := c.Store.Put(entry.Key+".tar.gz", f); err != nil {
+		return err
+	}
+
+	entry.Prefix = prefix
+	entry.Size = info.Size()
+	entry.Created = time.Now()
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return c.Store.Put(entry.Key+".json", strings.NewReader(string(data)))
+}
+
+// Remove 删除 key 对应的包, 先删除记录, 以免其他进程读到没有文件的记录
+func (c *Cache) Remove(key string) error {
+	if err := c.Store.Delete(key + ".json"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := c.Store.Delete(key + ".tar.gz"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// archive 把 prefix 下的 files 写成 tar.gz, 保留符号链接
+func archive(w io.Writer, prefix string, files []string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	for _, file := range files {
+		path := filepath.Join(prefix, filepath.FromSlash(file))
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = file
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if info.Mode().IsRegular() {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(tw, f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// extract 把 tar.gz 解压到 prefix, 拒绝写到 prefix 之外的路径和指向 prefix 之外的符号链接
+func extract(r io.Reader, prefix string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("invalid path '%s' in cache archive", header.Name)
+		}
+		target := filepath.Join(prefix, filepath.FromSlash(name))
+		if err := checkParent(prefix, target); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		os.Remove(target)
+
+		switch header.Typeflag {
+		case tar.TypeSymlink:
+			if err := checkLink(prefix, target, header.Linkname); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// 可能含有安装路径的文本文件
+var relocatable = map[string]bool{".cmake": true, ".pc": true, ".la": true, ".prl": true}
+
+// relocate 把 files 中 CMake 配置和 pkg-config 文件里的旧安装路径替换为 prefix
+func relocate(prefix string, files []string, old string) {
+	for _, file := range files {
+		if !relocatable[path.Ext(file)] {
+			continue
+		}
+
+		target := filepath.Join(prefix, filepath.FromSlash(file))
+		data, err := os.ReadFile(target)
+		if err != nil {
+			continue
+		}
+		content := strings.ReplaceAll(string(data), filepath.ToSlash(old), filepath.ToSlash(prefix))
+		content = strings.ReplaceAll(content, old, prefix)
+		if content != string(data) {
+			os.WriteFile(target, []byte(content), 0644)
+		}
+	}
+}
+
+// CacheStats 是本机使用缓存的统计
+type CacheStats struct {
+	Hits    int       `json:"hits"`    // 从缓存恢复的构建
+	Misses  int       `json:"misses"`  // 缓存中没有, 从源码构建
+	Uploads int       `json:"uploads"` // 上传到缓存的构建
+	Errors  int       `json:"errors"`  // 读取或上传失败的次数
+	Since   time.Time `json:"since"`   // 开始统计的时间
+}
+
+// CacheStatsPath 返回本机缓存统计文件的路径
+func CacheStatsPath() string {
+	return filepath.Join(utils.GetCgearHomePath(), "cache-stats.json")
+}
+
+// ReadCacheStats 读取 path 中保存的统计, 文件不存在时返回空的统计
+func ReadCacheStats(path string) (*CacheStats, error) {
+	stats := &CacheStats{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return stats, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stats, json.Unmarshal(data, stats)
+}
+
+// AddCacheStats 把 stats 累加到 path 中保存的统计
+func AddCacheStats(path string, stats CacheStats) error {
+	total, err := ReadCacheStats(path)
+	if err != nil {
+		return err
+	}
+	if total.Since.IsZero() {
+		total.Since = time.Now()
+	}
+	total.Hits += stats.Hits
+	total.Misses += stats.Misses
+	total.Uploads += stats.Uploads
+	total.Errors += stats.Errors
+
+	data, err := json.MarshalIndent(total, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,124 @@
+package deps
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// List 返回安装在 prefix 下的所有包, 按包名排序
+func List(prefix string) ([]*Record, error) {
+	paths, err := filepath.Glob(filepath.Join(prefix, ".cgear", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*Record
+	for _, path := range paths {
+		record, err := ReadRecord(prefix, strings.TrimSuffix(filepath.Base(path), ".json"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		records = append(records, record)
+	}
+
+	sort.Slice(records, func(i, j int) bool { return records[i].Name < records[j].Name })
+	return records, nil
+}
+
+// Dependents 返回安装在 prefix 下、依赖 name 的包
+func Dependents(prefix, name string) ([]string, error) {
+	records, err := List(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var dependents []string
+	for _, record := range records {
+		for _, dep := range record.Dependencies {
+			if dep == name {
+				dependents = append(dependents, record.Name)
+				break
+			}
+		}
+	}
+	return dependents, nil
+}
+
+// Uninstall 删除 name 安装的文件和它的记录, 以及因此变空的目录。
+// 有其他已安装的包依赖 name 时拒绝删除。记录中 prefix 之外的路径和其他包的记录中也有的文件不会被删除,
+// 例如复制到共用的 bin 目录中的同名 DLL。
+func Uninstall(prefix, name string) error {
+	record, err := ReadRecord(prefix, name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("package '%s' is not installed", name)
+		}
+		return err
+	}
+
+	dependents, err := Dependents(prefix, name)
+	if err != nil {
+		return err
+	}
+	if len(dependents) > 0 {
+		return fmt.Errorf("package '%s' is required by %s", name, strings.Join(dependents, ", "))
+	}
+
+	records, err := List(prefix)
+	if err != nil {
+		return err
+	}
+	shared := make(map[string]bool)
+	for _, other := range records {
+		if other.Name == name {
+			continue
+		}
+		for _, file := range other.Files {
+			shared[file] = true
+		}
+	}
+
+	dirs := make(map[string]bool)
+	for _, file := range record.Files {
+		path := filepath.FromSlash(file)
+		if shared[file] || filepath.IsAbs(path) || !within(prefix, filepath.Join(prefix, path)) {
+			continue
+		}
+		path = filepath.Join(prefix, path)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		dirs[filepath.Dir(path)] = true
+	}
+
+	// 从最深的目录开始删除空目录, 不删除 prefix 本身
+	var sorted []string
+	for dir := range dirs {
+		for ; dir != prefix && strings.HasPrefix(dir, prefix+string(filepath.Separator)); dir = filepath.Dir(dir) {
+			sorted = append(sorted, dir)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	for _, dir := range sorted {
+		os.Remove(dir) // 目录不为空时删除失败, 忽略错误
+	}
+
+	return os.Remove(recordPath(prefix, name))
+}
+
+// FilesUnder 返回 dirs 下的所有文件, 以相对 prefix 的路径表示
+func FilesUnder(prefix string, dirs ...string) []string {
+	var files []string
+	for _, dir := range dirs {
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				files = append(files, filepath.ToSlash(relativeTo(prefix, path)))
+			}
+			return nil
+		})
+	}
+	return files
+}
@@ -0,0 +1,609 @@
+package deps
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/zelviner/cgear/cmake"
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/env"
+	"github.com/zelviner/cgear/logger"
+	"github.com/zelviner/cgear/ui/progress"
+	"github.com/zelviner/cgear/utils"
+)
+
+// 包名只能包含字母、数字、点、下划线和连字符
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// BuildTypes 是每个依赖都会构建和安装的构建类型
+var BuildTypes = []string{"Debug", "Release"}
+
+// Triplet 返回平台对应的安装目录名, 例如 x64-windows。platform 为空时使用当前机器的架构。
+func Triplet(platform string) string {
+	if platform == "" {
+		switch runtime.GOARCH {
+		case "386":
+			platform = "x86"
+		case "amd64":
+			platform = "x64"
+		default:
+			platform = runtime.GOARCH
+		}
+	}
+
+	system := runtime.GOOS
+	if system == "darwin" {
+		system = "osx"
+	}
+	return platform + "-" + system
+}
+
+// Validate 检查依赖声明: 包名合法且不重复, 来源不为空
+func Validate(dependencies []config.Dependency) error {
+	names := make(map[string]bool)
+	for i, dep := range dependencies {
+		if !namePattern.MatchString(dep.Name) {
+			return fmt.Errorf("dependency %d: invalid name '%s'", i+1, dep.Name)
+		}
+		if names[dep.Name] {
+			return fmt.Errorf("dependency '%s' is declared more than once", dep.Name)
+		}
+		names[dep.Name] = true
+
+		for buildType := range dep.BuildTypeOptions {
+			if buildType != "Debug" && buildType != "Release" {
+				return fmt.Errorf("dependency '%s': unknown build type '%s' in build_type_options, use Debug or Release", dep.Name, buildType)
+			}
+		}
+		if len(dep.Build) > 0 && len(dep.Components) > 0 {
+			return fmt.Errorf("dependency '%s': components cannot be used with build commands", dep.Name)
+		}
+
+		if dep.URL != "" {
+			if err := validateArchive(dep); err != nil {
+				return fmt.Errorf("dependency '%s': %w", dep.Name, err)
+			}
+			continue
+		}
+
+		if dep.Source == "" {
+			return fmt.Errorf("dependency '%s' has no source or url", dep.Name)
+		}
+		source, err := ParseSource(dep.Source)
+		if err != nil {
+			return fmt.Errorf("dependency '%s': %w", dep.Name, err)
+		}
+		if _, err := source.Revision(dep.Version); err != nil {
+			return fmt.Errorf("dependency '%s': %w", dep.Name, err)
+		}
+		if IsConstraint(dep.Version) {
+			if _, err := ParseConstraint(dep.Version); err != nil {
+				return fmt.Errorf("dependency '%s': %w", dep.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateArchive 检查以 url 声明的依赖: 地址指向支持的压缩包, 并给出了 sha256
+func validateArchive(dep config.Dependency) error {
+	switch {
+	case dep.Source != "":
+		return fmt.Errorf("source and url cannot be used together")
+	case !strings.HasPrefix(dep.URL, "http://") && !strings.HasPrefix(dep.URL, "https://"):
+		return fmt.Errorf("url '%s' is not an http:// or https:// address", dep.URL)
+	case ArchiveExt(dep.URL) == "":
+		return fmt.Errorf("url '%s' is not a %s archive", dep.URL, strings.Join(archiveExts, ", "))
+	case dep.SHA256 == "":
+		return fmt.Errorf("url requires the sha256 of the archive")
+	case IsConstraint(dep.Version):
+		return fmt.Errorf("version constraint '%s' requires a git source", dep.Version)
+	case !sha256Pattern.MatchString(dep.SHA256):
+		return fmt.Errorf("invalid sha256 '%s'", dep.SHA256)
+	}
+	return nil
+}
+
+// Installer 下载、构建并安装依赖
+type Installer struct {
+	Toolchain *config.Toolchain // 编译工具链
+	Platform  string            // 编译架构
+	Generator string            // 生成器
+	PkgPath   string            // 源码目录, 即 CGEAR_HOME/pkg
+	Installed string            // 安装根目录, 即 CGEAR_HOME/installed
+	LogPath   string            // 构建日志目录, 即 CGEAR_HOME/logs, 为空时不保存日志
+	Jobs      int               // 同时进行的构建数, 小于 1 时为 CPU 核数
+	Cache     *Cache            // 二进制缓存, 为 nil 时总是从源码构建
+	Mirrors   map[string]string // 下载地址前缀的替换, 例如把 https://github.com/ 换成内部服务器
+	Vendor    *Vendor           // 不为 nil 时只从 vendor 目录安装, 不访问网络
+	Force     bool              // 即使已安装相同的版本也重新安装, 不从缓存恢复
+	ShowInfo  bool              // 是否显示下载的输出和构建的进度
+
+	Stats CacheStats // 本次安装使用缓存的统计
+
+	progress  *progress.Progress
+	once      sync.Once
+	slots     chan struct{} // 构建的并发限制
+	mu        sync.Mutex
+	toolchain string // 工具链的标识, 见 toolchainID
+}
+
+// NewInstaller 使用项目的配置和 CGEAR_HOME 下的目录创建 Installer。
+// 镜像取自项目的 mirrors, CGEAR_MIRRORS 环境变量中相同的前缀优先。
+func NewInstaller() *Installer {
+	mirrors := make(map[string]string)
+	for from, to := range config.Conf.Mirrors {
+		mirrors[from] = to
+	}
+	for from, to := range ParseMirrors(os.Getenv("CGEAR_MIRRORS")) {
+		mirrors[from] = to
+	}
+
+	return &Installer{
+		Toolchain: config.Conf.Toolchain,
+		Platform:  config.Conf.Platform,
+		Generator: config.Conf.Generator,
+		PkgPath:   utils.GetCgearPkgPath(),
+		Installed: utils.GetCgearInstalledPath(),
+		LogPath:   filepath.Join(utils.GetCgearHomePath(), "logs"),
+		Cache:     NewCache(utils.GetCgearCachePath()),
+		Mirrors:   mirrors,
+		ShowInfo:  true,
+	}
+}
+
+// Prefix 返回依赖的安装路径
+func (in *Installer) Prefix() string {
+	return filepath.Join(in.Installed, Triplet(in.Platform))
+}
+
+// LogFile 返回依赖以 buildType 构建时的日志文件, 没有日志目录时返回空字符串
+func (in *Installer) LogFile(name, buildType string) string {
+	if in.LogPath == "" {
+		return ""
+	}
+	return filepath.Join(in.LogPath, Triplet(in.Platform), name+"-"+strings.ToLower(buildType)+".log")
+}
+
+// Install 安装一个依赖。revision 不为空时检出该提交, 否则检出声明的版本当前指向的提交。
+// 每个构建类型并行构建, 声明了构建命令时依次构建, 同时进行的构建不超过 Jobs 个。
+// 已经以相同的声明和提交安装时跳过, 返回已有的记录和 false。
+func (in *Installer) Install(dep config.Dependency, revision string) (*Record, bool, error) {
+	url, ref, err := in.resolve(dep, revision)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !in.Force {
+		if record, err := ReadRecord(in.Prefix(), dep.Name); err == nil && record.Satisfies(dep) && isRevision(ref, record.Revision) {
+			in.report(dep.Name, progress.Skipped, "up to date at "+shortRevision(record.Revision))
+			return record, false, nil
+		}
+	}
+
+	sourcePath, resolved, err := in.checkout(dep, url, ref, nil)
+	if err != nil {
+		in.report(dep.Name, progress.Failed, "download failed")
+		return nil, false, fmt.Errorf("failed to download %s: %w", dep.Name, err)
+	}
+	if err := ApplyPatches(sourcePath, dep.Patches, nil); err != nil {
+		in.report(dep.Name, progress.Failed, "patch failed")
+		return nil, false, fmt.Errorf("%s: %w", dep.Name, err)
+	}
+
+	record := NewRecord(dep)
+	record.URL, record.Revision = url, resolved
+	record.BuildTypes = BuildTypes
+	if record.Requires, record.Dependencies, err = readPackageRequires(sourcePath); err != nil {
+		return nil, false, err
+	}
+
+	// 依赖的包改变后缓存的构建不能再使用
+	var requires []string
+	for _, name := range record.Dependencies {
+		if installed, err := ReadRecord(in.Prefix(), name); err == nil {
+			requires = append(requires, name+"@"+installed.Revision)
+		}
+	}
+
+	files := make([][]string, len(BuildTypes))
+	errs := make([]error, len(BuildTypes))
+	var wg sync.WaitGroup
+	for i, buildType := range BuildTypes {
+		// 构建命令可能在源码目录中生成文件, 各个构建类型依次执行
+		if len(dep.Build) > 0 {
+			files[i], errs[i] = in.buildVariant(dep, sourcePath, buildType, resolved, requires)
+			continue
+		}
+		wg.Add(1)
+		go func(i int, buildType string) {
+			defer wg.Done()
+			files[i], errs[i] = in.buildVariant(dep, sourcePath, buildType, resolved, requires)
+		}(i, buildType)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, false, err
+	}
+
+	for _, f := range files {
+		record.Files = append(record.Files, f...)
+	}
+	record.Files = uniqueStrings(record.Files)
+	record.Hash = HashFiles(in.Prefix(), record.Files)
+
+	return record, true, WriteRecord(in.Prefix(), record)
+}
+
+// Sync 安装 dependencies 以及它们的传递依赖并更新 lock。
+// 互不依赖的包并行安装, 一个包在它依赖的包都安装成功后才开始构建。
+// 锁文件中有与声明一致的记录时安装记录的提交, 否则解析声明的版本并写入 lock。
+// locked 为 true 时所有依赖都必须已经锁定, 安装的文件与锁文件不一致时给出警告, 不修改 lock。
+// 返回安装的包数和已是最新而跳过的包数, 都包括传递依赖。
+func (in *Installer) Sync(dependencies []config.Dependency, lock *Lock, locked bool) (installed, upToDate int, err error) {
+	graph, err := in.Graph(dependencies, lock, locked)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// 并行构建前确定工具链, 避免每个构建各自选择
+	if in.Toolchain == nil {
+		env.SetToolchain()
+		in.Toolchain = config.Conf.Toolchain
+	}
+
+	requires := make(map[string][]string)
+	for name, pkg := range graph.Packages {
+		requires[name] = pkg.Requires
+	}
+
+	if in.ShowInfo {
+		in.progress = progress.New(os.Stdout)
+	}
+	var (
+		mu      sync.Mutex
+		records = make(map[string]*Record)
+	)
+	err = Schedule(graph.Order, requires, func(name string) error {
+		pkg := graph.Packages[name]
+		record, done, err := in.Install(pkg.Dependency, pkg.Revision)
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		records[name] = record
+		if done {
+			installed++
+		} else {
+			upToDate++
+		}
+		return nil
+	})
+	if in.progress != nil {
+		in.progress.Stop()
+		in.progress = nil
+	}
+	if in.Cache != nil && in.Stats != (CacheStats{}) {
+		if err := AddCacheStats(CacheStatsPath(), in.Stats); err != nil {
+			logger.Log.Warnf("Failed to save the cache statistics: %s", err)
+		}
+	}
+	if err != nil {
+		return installed, upToDate, err
+	}
+
+	for _, name := range graph.Order {
+		record := records[name]
+		if locked {
+			if entry := lock.Find(name); entry.Hash != "" && record.Hash != entry.Hash {
+				logger.Log.Warnf("The files installed for '%s' differ from %s, the build may not be reproducible", name, LockFile)
+			}
+			continue
+		}
+		lock.Set(record.Locked())
+	}
+
+	if !locked {
+		lock.Retain(graph.Dependencies())
+	}
+	return installed, upToDate, nil
+}
+
+// ReadManifest 读取 dir 中的 cgear.json 或 Cgearfile, 都不存在时返回 nil
+func ReadManifest(dir string) (*config.Config, error) {
+	for _, name := range []string{"cgear.json", "Cgearfile"} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		conf, err := config.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return conf, nil
+	}
+	return nil, nil
+}
+
+// resolve 返回依赖的下载地址和要检出的版本。
+// git 仓库的版本为 revision, 为空时为声明的版本当前指向的提交, 或满足版本约束的最新标签;
+// 压缩包的版本为它的 sha256。
+// 从 vendor 目录安装时, 版本为保存的提交。
+func (in *Installer) resolve(dep config.Dependency, revision string) (string, string, error) {
+	if in.Vendor != nil {
+		return in.resolveVendored(dep, revision)
+	}
+	if dep.URL != "" {
+		return dep.URL, strings.ToLower(dep.SHA256), nil
+	}
+
+	source, err := ParseSource(dep.Source)
+	if err != nil {
+		return "", "", err
+	}
+	version, err := source.Revision(dep.Version)
+	if err != nil {
+		return "", "", err
+	}
+
+	ref := revision
+	switch {
+	case ref != "":
+	case IsConstraint(version):
+		tag, err := in.newestTag(source.URL, version)
+		if err != nil {
+			return "", "", err
+		}
+		ref = tag.Commit
+	default:
+		ref = version
+		if resolved, err := Resolve(in.mirror(source.URL), version); err == nil {
+			ref = resolved
+		}
+	}
+	return source.URL, ref, nil
+}
+
+// checkout 把依赖的源码放到 CGEAR_HOME/pkg 下, 返回源码目录和检出的版本。
+// git 仓库检出到 pkg/<name>, 压缩包校验后解压到 pkg/<name>-<version>。
+func (in *Installer) checkout(dep config.Dependency, url, ref string, output io.Writer) (string, string, error) {
+	if in.Vendor != nil {
+		return in.unvendor(dep, output)
+	}
+
+	if dep.URL == "" {
+		sourcePath := filepath.Join(in.PkgPath, dep.Name)
+		revision, err := in.fetch(url, ref, sourcePath, output)
+		return sourcePath, revision, err
+	}
+
+	sourcePath := in.archivePath(dep, ref)
+	archive := sourcePath + ArchiveExt(url)
+	if err := Download(in.mirror(url), ref, archive, output); err != nil {
+		return "", "", err
+	}
+	return sourcePath, ref, Unpack(archive, sourcePath)
+}
+
+// archivePath 返回以压缩包声明的依赖解压的目录, 没有版本时以 sha256 的前 12 位区分
+func (in *Installer) archivePath(dep config.Dependency, sum string) string {
+	version := dep.Version
+	if version == "" {
+		version = sum[:12]
+	}
+	return filepath.Join(in.PkgPath, dep.Name+"-"+version)
+}
+
+// fetch 把 url 的 ref 检出到 dir, git 的输出写入 output。
+// dir 已经检出了 ref 指向的提交时直接使用, 例如生成依赖图时已经下载过, 只丢弃之前应用的补丁。
+func (in *Installer) fetch(url, ref, dir string, output io.Writer) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		if head, err := git(dir, nil, "rev-parse", "HEAD"); err == nil && isRevision(ref, strings.TrimSpace(head)) {
+			if _, err := git(dir, nil, "reset", "-q", "--hard"); err == nil {
+				if _, err := git(dir, nil, "clean", "-q", "-f", "-d", "-x"); err == nil {
+					return strings.TrimSpace(head), nil
+				}
+			}
+		}
+	}
+	return Fetch(in.mirror(url), ref, dir, output)
+}
+
+// report 更新安装进度, 没有显示进度时忽略
+func (in *Installer) report(name string, state progress.State, text string) {
+	if in.progress != nil {
+		in.progress.Set(name, state, text)
+	}
+}
+
+// count 增加缓存统计中的一个计数
+func (in *Installer) count(counter *int) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	*counter++
+}
+
+// toolchainID 返回工具链的标识: 名称、编译器和编译器的版本, 用于缓存的键
+func (in *Installer) toolchainID() string {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.toolchain != "" || in.Toolchain == nil {
+		return in.toolchain
+	}
+
+	tc := in.Toolchain
+	id := tc.Name + "|" + tc.Compiler.C + "|" + tc.Compiler.CXX
+	if !tc.IsMSVC && tc.Compiler.CXX != "" {
+		if output, err := exec.Command(tc.Compiler.CXX, "--version").Output(); err == nil {
+			id += "|" + strings.TrimSpace(strings.SplitN(string(output), "\n", 2)[0])
+		}
+	}
+	in.toolchain = id
+	return id
+}
+
+// acquire 占用一个构建的位置, 返回释放它的函数
+func (in *Installer) acquire() func() {
+	in.once.Do(func() {
+		jobs := in.Jobs
+		if jobs < 1 {
+			jobs = runtime.NumCPU()
+		}
+		in.slots = make(chan struct{}, jobs)
+	})
+
+	in.slots <- struct{}{}
+	return func() { <-in.slots }
+}
+
+func shortRevision(revision string) string {
+	if len(revision) > 10 {
+		return revision[:10]
+	}
+	return revision
+}
+
+// buildVariant 占用一个构建的位置, 以 buildType 构建并安装依赖, 输出写入日志文件。
+// 缓存中有相同的构建时直接恢复, 否则构建后上传到缓存。
+func (in *Installer) buildVariant(dep config.Dependency, sourcePath, buildType, revision string, requires []string) ([]string, error) {
+	release := in.acquire()
+	defer release()
+
+	task := dep.Name + " (" + buildType + ")"
+	logFile := in.LogFile(dep.Name, buildType)
+
+	var key string
+	if in.Cache != nil {
+		key = CacheKey(dep, revision, in.toolchainID(), Triplet(in.Platform), buildType, requires)
+		if !in.Force {
+			in.report(task, progress.Running, "restoring "+key)
+			entry, err := in.Cache.Restore(key, in.Prefix())
+			switch {
+			case err != nil:
+				in.count(&in.Stats.Errors)
+			case entry != nil:
+				in.count(&in.Stats.Hits)
+				in.report(task, progress.Done, "restored from cache "+shortRevision(revision))
+				return entry.Files, nil
+			default:
+				in.count(&in.Stats.Misses)
+			}
+		}
+	}
+	in.report(task, progress.Running, "building "+shortRevision(revision))
+
+	var output io.Writer
+	if logFile != "" {
+		if err := os.MkdirAll(filepath.Dir(logFile), 0755); err != nil {
+			return nil, err
+		}
+		f, err := os.Create(logFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		output = f
+	}
+
+	files, err := in.build(dep, sourcePath, buildType, output)
+	if err != nil {
+		in.report(task, progress.Failed, "failed, see "+logFile)
+		if logFile != "" {
+			return nil, fmt.Errorf("failed to build %s (%s), see %s: %w", dep.Name, buildType, logFile, err)
+		}
+		return nil, fmt.Errorf("failed to build %s (%s): %w", dep.Name, buildType, err)
+	}
+
+	text := "installed " + shortRevision(revision)
+	if in.Cache != nil {
+		entry := &CacheEntry{
+			Key:       key,
+			Name:      dep.Name,
+			Revision:  revision,
+			Triplet:   Triplet(in.Platform),
+			BuildType: buildType,
+			Toolchain: in.toolchainID(),
+			Options:   buildOptions(dep, buildType),
+			Files:     files,
+		}
+		if err := in.Cache.Save(entry, in.Prefix()); err != nil {
+			in.count(&in.Stats.Errors)
+			text += ", not cached: " + err.Error()
+			if output != nil {
+				fmt.Fprintf(output, "Failed to upload to the cache %s: %s\n", in.Cache.Store, err)
+			}
+		} else {
+			in.count(&in.Stats.Uploads)
+		}
+	}
+
+	in.report(task, progress.Done, text)
+	return files, nil
+}
+
+// build 以 buildType 构建依赖并安装, 返回安装的文件。Debug 版本安装到 <triplet>/debug 下。
+// 依赖声明了构建命令时执行这些命令, 否则使用 CMake。
+func (in *Installer) build(dep config.Dependency, sourcePath, buildType string, output io.Writer) ([]string, error) {
+	prefix := in.Prefix()
+	if buildType == "Debug" {
+		prefix = filepath.Join(prefix, "debug")
+	}
+	if len(dep.Build) > 0 {
+		return in.runBuild(dep, sourcePath, buildType, prefix, output)
+	}
+
+	buildPath := filepath.Join(sourcePath, "build", buildType)
+	configArg := cmake.ConfigArg{
+		Toolchain:       in.Toolchain,
+		Platform:        in.Platform,
+		BuildType:       buildType,
+		Generator:       in.Generator,
+		NoWarnUnusedCli: true,
+		ProjectPath:     sourcePath,
+		BuildPath:       buildPath,
+		InstallPrefix:   prefix,
+		CacheVariables:  cacheVariables(buildOptions(dep, buildType), prefix),
+	}
+
+	buildArg := cmake.BuildArg{
+		BuildPath: buildPath,
+		BuildType: buildType,
+		IsMSVC:    in.Toolchain != nil && in.Toolchain.IsMSVC,
+		Output:    output,
+	}
+
+	if err := cmake.Build(&configArg, &buildArg, true, false); err != nil {
+		return nil, err
+	}
+
+	components := dep.Components
+	if len(components) == 0 {
+		components = []string{""}
+	}
+	for _, component := range components {
+		if err := cmake.Install(buildPath, buildType, component, output); err != nil {
+			return nil, err
+		}
+	}
+
+	return InstalledFiles(buildPath, in.Prefix())
+}
+
+// cacheVariables 在依赖的选项中加入 CMAKE_PREFIX_PATH, 使依赖能找到先安装的传递依赖
+func cacheVariables(options map[string]string, prefix string) map[string]string {
+	variables := map[string]string{"CMAKE_PREFIX_PATH": filepath.ToSlash(prefix)}
+	for name, value := range options {
+		variables[name] = value
+	}
+	return variables
+}
@@ -0,0 +1,117 @@
+package deps
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Fetch 把 url 仓库的 ref (标签、分支或提交, 为空时为默认分支) 检出到 dir, 返回检出的提交。
+// dir 中原有的内容会被删除。git 的输出写入 output, 为 nil 时丢弃。
+func Fetch(url, ref, dir string, output io.Writer) (string, error) {
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	if _, err := git(dir, output, "init", "-q"); err != nil {
+		return "", err
+	}
+
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	if _, err := git(dir, output, "fetch", "--depth=1", url, ref); err == nil {
+		if _, err := git(dir, output, "checkout", "-q", "--detach", "FETCH_HEAD"); err != nil {
+			return "", err
+		}
+	} else {
+		// 服务器不允许按提交浅获取, 或者 ref 是缩写的提交时, 获取完整的历史再检出
+		if _, err := git(dir, output, "fetch", "--tags", url, "+refs/heads/*:refs/remotes/origin/*"); err != nil {
+			return "", err
+		}
+		if _, err := git(dir, output, "checkout", "-q", "--detach", ref); err != nil {
+			if _, err := git(dir, output, "checkout", "-q", "--detach", "origin/"+ref); err != nil {
+				return "", fmt.Errorf("revision '%s' not found in %s", ref, url)
+			}
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".gitmodules")); err == nil {
+		if _, err := git(dir, output, "submodule", "update", "--init", "--recursive", "--depth=1"); err != nil {
+			return "", err
+		}
+	}
+
+	revision, err := git(dir, nil, "rev-parse", "HEAD")
+	return strings.TrimSpace(revision), err
+}
+
+// Resolve 通过 git ls-remote 查询 url 仓库中 ref (标签或分支, 为空时为默认分支) 指向的提交
+func Resolve(url, ref string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	output, err := git("", nil, "ls-remote", url, ref, ref+"^{}")
+	if err != nil {
+		return "", err
+	}
+
+	// 附注标签以 ^{} 结尾的一行是标签指向的提交, 优先于标签对象本身, 标签优先于分支
+	best, rank := "", 0
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		name, r := fields[1], 0
+		switch {
+		case name == "refs/tags/"+ref+"^{}":
+			r = 4
+		case name == "refs/tags/"+ref:
+			r = 3
+		case name == "refs/heads/"+ref || name == ref:
+			r = 2
+		}
+		if r > rank {
+			best, rank = fields[0], r
+		}
+	}
+
+	if best == "" {
+		return "", fmt.Errorf("revision '%s' not found in %s", ref, url)
+	}
+	return best, nil
+}
+
+// isRevision 判断 ref 是否为 revision 或它的缩写
+func isRevision(ref, revision string) bool {
+	if len(ref) < 7 || !strings.HasPrefix(revision, ref) {
+		return false
+	}
+	return strings.Trim(ref, "0123456789abcdef") == ""
+}
+
+// git 在 dir 中运行 git 命令, 返回标准输出。标准错误写入 output, 出错时包含在错误信息中。
+func git(dir string, output io.Writer, args ...string) (string, error) {
+	var stderr strings.Builder
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	if output != nil {
+		cmd.Stderr = io.MultiWriter(output, &stderr)
+	}
+
+	stdout, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return string(stdout), nil
+}
@@ -0,0 +1,144 @@
+package deps
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// InstalledFiles 读取构建目录中 CMake 生成的 install_manifest*.txt, 返回安装的文件。
+// 文件以相对 prefix 的路径返回, 路径使用 / 分隔。有文件安装到 prefix 之外时返回错误,
+// 这些文件不属于 cgear 管理的目录, 不能记录下来由 Uninstall 删除。
+func InstalledFiles(buildPath, prefix string) ([]string, error) {
+	manifests, err := filepath.Glob(filepath.Join(buildPath, "install_manifest*.txt"))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, manifest := range manifests {
+		f, err := os.Open(manifest)
+		if err != nil {
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			path := filepath.FromSlash(line)
+			if !within(prefix, path) {
+				f.Close()
+				return nil, fmt.Errorf("'%s' is installed outside of %s, set CMAKE_INSTALL_PREFIX instead of absolute install destinations", line, prefix)
+			}
+			files = append(files, filepath.ToSlash(relativeTo(prefix, path)))
+		}
+		f.Close()
+	}
+	return files, nil
+}
+
+// HashFiles 计算安装的文件集合的摘要: 按路径排序后依次计算路径和内容的 sha256
+func HashFiles(prefix string, files []string) string {
+	sorted := append([]string(nil), files...)
+	sort.Strings(sorted)
+
+	hash := sha256.New()
+	for _, file := range sorted {
+		path := filepath.FromSlash(file)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(prefix, path)
+		}
+
+		io.WriteString(hash, file+"\x00")
+		if f, err := os.Open(path); err == nil {
+			content := sha256.New()
+			io.Copy(content, f)
+			f.Close()
+			io.WriteString(hash, hex.EncodeToString(content.Sum(nil)))
+		}
+		io.WriteString(hash, "\n")
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// relativeTo 返回 path 相对 dir 的路径, path 不在 dir 下时原样返回
+func relativeTo(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return rel
+}
+
+// within 判断 path 是否为 dir 或在 dir 之下
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// checkLink 检查在 dir 中的 target 处创建的、指向 linkname 的符号链接不会指向 dir 之外。
+// 拒绝绝对路径, 相对路径以链接所在的目录为基准。
+func checkLink(dir, target, linkname string) error {
+	name := filepath.FromSlash(linkname)
+	if filepath.IsAbs(name) || strings.HasPrefix(linkname, "/") || filepath.VolumeName(name) != "" {
+		return fmt.Errorf("symbolic link '%s' points to the absolute path '%s'", filepath.ToSlash(relativeTo(dir, target)), linkname)
+	}
+	if !within(dir, filepath.Join(filepath.Dir(target), name)) {
+		return fmt.Errorf("symbolic link '%s' points outside of the directory: '%s'", filepath.ToSlash(relativeTo(dir, target)), linkname)
+	}
+	return nil
+}
+
+// checkParent 检查 target 所在的目录在解析符号链接后仍在 dir 之下, 避免通过之前创建的链接写到 dir 之外
+func checkParent(dir, target string) error {
+	root, err := realPath(dir)
+	if err != nil {
+		return err
+	}
+	parent, err := realPath(filepath.Dir(target))
+	if err != nil {
+		return err
+	}
+	if !within(root, parent) {
+		return fmt.Errorf("'%s' is written through a symbolic link outside of the directory", filepath.ToSlash(relativeTo(dir, target)))
+	}
+	return nil
+}
+
+// realPath 解析 path 中的符号链接。从已经存在的最深一级目录开始解析, 其下尚未创建的部分不含链接。
+func realPath(path string) (string, error) {
+	rest := ""
+	for {
+		if real, err := filepath.EvalSymlinks(path); err == nil {
+			return filepath.Join(real, rest), nil
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return "", fmt.Errorf("cannot resolve '%s'", path)
+		}
+		rest = filepath.Join(filepath.Base(path), rest)
+		path = parent
+	}
+}
+
+// uniqueStrings 去除重复的字符串, 保持原来的顺序
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,343 @@
+package deps
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/logger"
+)
+
+// Package 是依赖图中的一个包
+type Package struct {
+	Dependency config.Dependency // 采用的声明
+	Revision   string            // 解析出的提交
+	Requires   []string          // 该包自己声明的依赖
+	DeclaredBy string            // 声明该包的包, 由项目声明时为空
+	Path       string            // 源码目录, 已经以锁定的提交安装的包没有下载源码时为空
+}
+
+// Graph 是项目的依赖以及它们传递依赖组成的图
+type Graph struct {
+	Roots    []string            // 项目直接声明的包
+	Packages map[string]*Package // 所有的包
+	Order    []string            // 安装顺序, 被依赖的包在前
+}
+
+// Dependencies 按安装顺序返回图中所有包的声明
+func (g *Graph) Dependencies() []config.Dependency {
+	dependencies := make([]config.Dependency, 0, len(g.Order))
+	for _, name := range g.Order {
+		dependencies = append(dependencies, g.Packages[name].Dependency)
+	}
+	return dependencies
+}
+
+// Graph 下载 dependencies 以及它们在 cgear.json、Cgearfile 或 vcpkg.json 中声明的依赖, 生成依赖图。
+// 锁文件中有与声明一致的记录时使用记录的提交, locked 为 true 时所有的包都必须已经锁定。
+// 已经以锁定的提交安装的包不再下载, 它的依赖取自安装记录。
+// 项目的声明优先于传递依赖的声明, 两个包以不同的来源或版本声明同一个包时返回错误。
+func (in *Installer) Graph(dependencies []config.Dependency, lock *Lock, locked bool) (*Graph, error) {
+	type request struct {
+		dep    config.Dependency
+		parent string
+	}
+
+	graph := &Graph{Packages: make(map[string]*Package)}
+	var queue []request
+	for _, dep := range dependencies {
+		graph.Roots = append(graph.Roots, dep.Name)
+		queue = append(queue, request{dep: dep})
+	}
+
+	// 按层次遍历, 保证项目的声明先于传递依赖的声明被采用
+	var found []string
+	for len(queue) > 0 {
+		req := queue[0]
+		queue = queue[1:]
+
+		if pkg, ok := graph.Packages[req.dep.Name]; ok {
+			if pkg.DeclaredBy != "" && !sameDeclaration(pkg.Dependency, req.dep) {
+				return nil, fmt.Errorf("'%s' is required as %s by %s and as %s by %s, declare the version to use in the dependencies of the project",
+					req.dep.Name, describe(pkg.Dependency), pkg.DeclaredBy, describe(req.dep), req.parent)
+			}
+			continue
+		}
+
+		var revision string
+		entry := lock.Find(req.dep.Name)
+		switch {
+		case entry != nil && entry.Matches(req.dep):
+			revision = entry.Revision
+		case locked:
+			return nil, fmt.Errorf("'%s' is not locked or has changed since %s was written, run 'cgear install' or 'cgear update %s'", req.dep.Name, LockFile, req.dep.Name)
+		}
+
+		var (
+			sourcePath, resolved string
+			declared             []config.Dependency
+			requires             []string
+		)
+		if record := in.installedAt(req.dep, revision); record != nil {
+			resolved, declared, requires = record.Revision, record.Requires, record.Dependencies
+		} else {
+			url, ref, err := in.resolve(req.dep, revision)
+			if err != nil {
+				return nil, err
+			}
+
+			var output io.Writer
+			if in.ShowInfo {
+				logger.Log.Infof("Resolving %s from %s", req.dep.Name, url)
+				output = os.Stdout
+			}
+			sourcePath, resolved, err = in.checkout(req.dep, url, ref, output)
+			if err != nil {
+				return nil, fmt.Errorf("failed to download %s: %w", req.dep.Name, err)
+			}
+
+			declared, requires, err = readPackageRequires(sourcePath)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if err := Validate(declared); err != nil {
+			return nil, fmt.Errorf("dependencies of '%s': %w", req.dep.Name, err)
+		}
+
+		graph.Packages[req.dep.Name] = &Package{Dependency: req.dep, Revision: resolved, Requires: requires, DeclaredBy: req.parent, Path: sourcePath}
+		found = append(found, req.dep.Name)
+		for _, dep := range declared {
+			queue = append(queue, request{dep: dep, parent: req.dep.Name})
+		}
+	}
+
+	// vcpkg.json 只声明包名, 来源必须由项目或其他包声明
+	for _, name := range found {
+		for _, require := range graph.Packages[name].Requires {
+			if graph.Packages[require] == nil {
+				return nil, fmt.Errorf("'%s' requires '%s' which has no source, add it to the dependencies of the project", name, require)
+			}
+		}
+	}
+
+	order, err := graph.sort(found)
+	if err != nil {
+		return nil, err
+	}
+	graph.Order = order
+	return graph, nil
+}
+
+// sort 按深度优先的后序排列 names, 存在环时返回环上的路径
+func (g *Graph) sort(names []string) ([]string, error) {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	state := make(map[string]int)
+	var order, stack []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			for i := range stack {
+				if stack[i] == name {
+					return fmt.Errorf("dependency cycle: %s -> %s", strings.Join(stack[i:], " -> "), name)
+				}
+			}
+		}
+
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, require := range g.Packages[name].Requires {
+			if err := visit(require); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = visited
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// WriteText 以树的形式输出依赖图, 已经输出过的包不再展开, 以 (*) 标记
+func (g *Graph) WriteText(w io.Writer, root string) {
+	fmt.Fprintln(w, root)
+
+	printed := make(map[string]bool)
+	var write func(names []string, indent string)
+	write = func(names []string, indent string) {
+		for i, name := range names {
+			branch, next := "├── ", "│   "
+			if i == len(names)-1 {
+				branch, next = "└── ", "    "
+			}
+
+			pkg := g.Packages[name]
+			line := indent + branch + name + " " + describe(pkg.Dependency) + " " + shortRevision(pkg.Revision)
+			if printed[name] && len(pkg.Requires) > 0 {
+				fmt.Fprintln(w, line+" (*)")
+				continue
+			}
+			fmt.Fprintln(w, line)
+			printed[name] = true
+			write(pkg.Requires, indent+next)
+		}
+	}
+	write(g.Roots, "")
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Install order: "+strings.Join(g.Order, ", "))
+}
+
+// WriteDOT 以 Graphviz 的 DOT 格式输出依赖图
+func (g *Graph) WriteDOT(w io.Writer, root string) {
+	fmt.Fprintln(w, "digraph dependencies {")
+	fmt.Fprintf(w, "  %q [shape=box];\n", root)
+	for _, name := range g.Order {
+		pkg := g.Packages[name]
+		fmt.Fprintf(w, "  %q [label=%q];\n", name, name+"\n"+describe(pkg.Dependency)+"\n"+shortRevision(pkg.Revision))
+	}
+	for _, name := range g.Roots {
+		fmt.Fprintf(w, "  %q -> %q;\n", root, name)
+	}
+	for _, name := range g.Order {
+		for _, require := range g.Packages[name].Requires {
+			fmt.Fprintf(w, "  %q -> %q;\n", name, require)
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// sameDeclaration 判断两个声明是否指向同一个仓库的同一个版本
+func sameDeclaration(a, b config.Dependency) bool {
+	if a.URL != "" || b.URL != "" {
+		return a.URL == b.URL && strings.EqualFold(a.SHA256, b.SHA256)
+	}
+
+	sa, errA := ParseSource(a.Source)
+	sb, errB := ParseSource(b.Source)
+	if errA != nil || errB != nil {
+		return a.Source == b.Source && a.Version == b.Version
+	}
+	ra, _ := sa.Revision(a.Version)
+	rb, _ := sb.Revision(b.Version)
+	return strings.TrimSuffix(sa.URL, ".git") == strings.TrimSuffix(sb.URL, ".git") && ra == rb
+}
+
+// describe 返回声明的来源和版本, 用于输出
+func describe(dep config.Dependency) string {
+	source := dep.Source
+	if dep.URL != "" {
+		source = dep.URL
+	}
+	if dep.Version == "" {
+		return source
+	}
+	return source + "@" + dep.Version
+}
+
+// installedAt 返回已经以 revision 安装且与声明一致的包的记录, 记录中没有保存依赖的声明时返回 nil。
+// 只声明包名的 vcpkg.json 依赖没有保存声明, 这样的包仍然需要下载。
+func (in *Installer) installedAt(dep config.Dependency, revision string) *Record {
+	if revision == "" || in.Force {
+		return nil
+	}
+	record, err := ReadRecord(in.Prefix(), dep.Name)
+	if err != nil || record.Revision != revision || !record.Satisfies(dep) || len(record.Requires) != len(record.Dependencies) {
+		return nil
+	}
+	return record
+}
+
+// readPackageRequires 读取源码目录 dir 中的包声明的依赖, 补丁的相对路径转换为该源码目录下的绝对路径
+func readPackageRequires(dir string) ([]config.Dependency, []string, error) {
+	declared, names, err := readRequires(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, dep := range declared {
+		patches := make([]string, len(dep.Patches))
+		for j, patch := range dep.Patches {
+			patches[j] = patch
+			if !filepath.IsAbs(patch) {
+				patches[j] = filepath.Join(dir, patch)
+			}
+		}
+		declared[i].Patches = patches
+	}
+	return declared, names, nil
+}
+
+// readRequires 读取 dir 中的包声明的依赖。
+// cgear.json 或 Cgearfile 中的依赖带有来源; 没有它们时读取 vcpkg.json, 其中只有包名。
+func readRequires(dir string) ([]config.Dependency, []string, error) {
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if manifest != nil {
+		var names []string
+		for _, dep := range manifest.Dependencies {
+			names = append(names, dep.Name)
+		}
+		return manifest.Dependencies, names, nil
+	}
+
+	names, err := readVcpkgManifest(filepath.Join(dir, "vcpkg.json"))
+	return nil, names, err
+}
+
+// readVcpkgManifest 读取 vcpkg.json 中的依赖名称, 文件不存在时返回 nil。
+// 只在构建时使用的 host 依赖和 vcpkg 自己的辅助端口被忽略。
+func readVcpkgManifest(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest struct {
+		Dependencies []json.RawMessage `json:"dependencies"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var names []string
+	for _, raw := range manifest.Dependencies {
+		var dep struct {
+			Name string `json:"name"`
+			Host bool   `json:"host"`
+		}
+		if err := json.Unmarshal(raw, &dep.Name); err != nil {
+			if err := json.Unmarshal(raw, &dep); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", path, err)
+			}
+		}
+		if dep.Name == "" || dep.Host || strings.HasPrefix(dep.Name, "vcpkg-") {
+			continue
+		}
+		names = append(names, dep.Name)
+	}
+	return names, nil
+}
@@ -0,0 +1,117 @@
+package deps
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/zelviner/cgear/config"
+)
+
+// LockFile 是锁文件的文件名, 位于项目目录
+const LockFile = "cgear.lock"
+
+// 锁文件的格式版本
+const lockVersion = 1
+
+// Lock 记录每个依赖解析出的确切版本
+type Lock struct {
+	Version  int      `json:"version"`
+	Packages []Locked `json:"packages"`
+}
+
+// Locked 是一个依赖在锁文件中的记录
+type Locked struct {
+	Name     string            `json:"name"`
+	Source   string            `json:"source"`
+	URL      string            `json:"url"`
+	Version  string            `json:"version,omitempty"` // 声明的版本
+	Revision string            `json:"revision"`          // 解析出的提交
+	Options  map[string]string `json:"options,omitempty"`
+	Hash     string            `json:"hash"` // 安装的文件集合的摘要
+}
+
+// ReadLock 读取锁文件, 文件不存在时返回空的锁
+func ReadLock(path string) (*Lock, error) {
+	lock := &Lock{Version: lockVersion}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lock, json.Unmarshal(data, lock)
+}
+
+// Save 按包名排序后写入锁文件
+func (l *Lock) Save(path string) error {
+	l.Version = lockVersion
+	sort.Slice(l.Packages, func(i, j int) bool { return l.Packages[i].Name < l.Packages[j].Name })
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Find 返回包名为 name 的记录, 没有时返回 nil
+func (l *Lock) Find(name string) *Locked {
+	for i := range l.Packages {
+		if l.Packages[i].Name == name {
+			return &l.Packages[i]
+		}
+	}
+	return nil
+}
+
+// Set 添加或替换一个记录
+func (l *Lock) Set(locked Locked) {
+	if existing := l.Find(locked.Name); existing != nil {
+		*existing = locked
+		return
+	}
+	l.Packages = append(l.Packages, locked)
+}
+
+// Remove 删除包名为 name 的记录
+func (l *Lock) Remove(name string) {
+	for i := range l.Packages {
+		if l.Packages[i].Name == name {
+			l.Packages = append(l.Packages[:i], l.Packages[i+1:]...)
+			return
+		}
+	}
+}
+
+// Retain 只保留 dependencies 中声明的包
+func (l *Lock) Retain(dependencies []config.Dependency) {
+	declared := make(map[string]bool)
+	for _, dep := range dependencies {
+		declared[dep.Name] = true
+	}
+
+	var packages []Locked
+	for _, locked := range l.Packages {
+		if declared[locked.Name] {
+			packages = append(packages, locked)
+		}
+	}
+	l.Packages = packages
+}
+
+// Matches 判断记录是否仍然对应依赖的声明, 来源、版本或选项改变后需要重新解析。
+// 压缩包的地址或 sha256 改变后也需要重新解析。
+func (l *Locked) Matches(dep config.Dependency) bool {
+	if dep.URL != "" && (l.URL != dep.URL || l.Revision != strings.ToLower(dep.SHA256)) {
+		return false
+	}
+	return l.Source == dep.Source && l.Version == dep.Version && sameOptions(l.Options, dep.Options)
+}
+
+func sameOptions(a, b map[string]string) bool {
+	return len(a) == 0 && len(b) == 0 || reflect.DeepEqual(a, b)
+}
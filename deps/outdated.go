@@ -0,0 +1,102 @@
+package deps
+
+import (
+	"fmt"
+
+	"github.com/zelviner/cgear/config"
+)
+
+// Outdated 是一个依赖锁定的版本和可以更新到的版本
+type Outdated struct {
+	Name     string `json:"name"`
+	Source   string `json:"source"`
+	Version  string `json:"version,omitempty"`  // 声明的版本或版本约束
+	Revision string `json:"revision,omitempty"` // 锁定的提交, 没有锁定时为空
+	Current  string `json:"current,omitempty"`  // 锁定的提交上的标签
+	Wanted   string `json:"wanted,omitempty"`   // 满足版本约束的最新标签
+	Latest   string `json:"latest,omitempty"`   // 最新的正式版本标签
+}
+
+// IsOutdated 判断锁定的提交是否落后于满足约束的最新标签或最新的标签
+func (o *Outdated) IsOutdated() bool {
+	return o.Wanted != "" && o.Wanted != o.Current || o.Latest != "" && o.Latest != o.Current
+}
+
+// CheckOutdated 查询依赖的远程仓库的标签, 与锁定的提交 revision 比较。
+// 声明的版本是约束时按约束查找; 是版本号的标签时查找与它兼容的标签, 即 ^version。
+// 以压缩包声明的依赖没有标签, 只返回声明的版本。
+func (in *Installer) CheckOutdated(dep config.Dependency, revision string) (*Outdated, error) {
+	outdated := &Outdated{Name: dep.Name, Source: dep.Source, Version: dep.Version, Revision: revision}
+	if dep.URL != "" {
+		outdated.Source = dep.URL
+		return outdated, nil
+	}
+
+	source, err := ParseSource(dep.Source)
+	if err != nil {
+		return nil, err
+	}
+	// 版本也可以写在来源中, 例如 fmtlib/fmt@10.2.1
+	if outdated.Version, err = source.Revision(dep.Version); err != nil {
+		return nil, err
+	}
+	tags, err := RemoteTags(in.mirror(source.URL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the tags of %s: %w", dep.Name, err)
+	}
+
+	// 一个提交上有多个标签时取版本号最大的
+	var current []Tag
+	for _, tag := range tags {
+		if revision != "" && tag.Commit == revision {
+			current = append(current, tag)
+		}
+	}
+	if tag := NewestTag(current, func(Semver) bool { return true }); tag != nil {
+		outdated.Current = tag.Name
+	} else if len(current) > 0 {
+		outdated.Current = current[0].Name
+	}
+
+	if constraint := compatible(outdated.Version); constraint != nil {
+		if tag := NewestTag(tags, constraint.Match); tag != nil {
+			outdated.Wanted = tag.Name
+		}
+	}
+	if tag := NewestTag(tags, nil); tag != nil {
+		outdated.Latest = tag.Name
+	}
+	return outdated, nil
+}
+
+// compatible 返回声明的版本对应的约束: 版本约束本身, 或与版本号的标签兼容的约束。其他版本返回 nil。
+func compatible(version string) Constraint {
+	if !IsConstraint(version) {
+		if _, ok := ParseSemver(version); !ok {
+			return nil
+		}
+		version = "^" + version
+	}
+	constraint, err := ParseConstraint(version)
+	if err != nil {
+		return nil
+	}
+	return constraint
+}
+
+// newestTag 返回 url 仓库中满足版本约束的最新标签
+func (in *Installer) newestTag(url, version string) (*Tag, error) {
+	constraint, err := ParseConstraint(version)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := RemoteTags(in.mirror(url))
+	if err != nil {
+		return nil, err
+	}
+	tag := NewestTag(tags, constraint.Match)
+	if tag == nil {
+		return nil, fmt.Errorf("no tag of %s matches '%s'", url, version)
+	}
+	return tag, nil
+}
@@ -0,0 +1,309 @@
+package deps
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Prebuilt 是一个预编译的库: 头文件目录和 Release、Debug 版本的库目录与运行时库目录
+type Prebuilt struct {
+	Name     string // 包名, 也是 find_package 的包名和导入目标的命名空间
+	Source   string // 库所在的目录, 记录在安装记录中
+	Version  string // 版本, 为空时不生成版本文件
+	Include  string // 头文件目录, 为空时没有头文件
+	Lib      string // Release 版本的库目录 (.lib、.a、.so、.dylib), 为空时是只有头文件的库
+	DebugLib string // Debug 版本的库目录, 为空时 Debug 也使用 Release 版本的库
+	Bin      string // Release 版本的运行时库目录 (.dll)
+	DebugBin string // Debug 版本的运行时库目录, 为空时把 Release 版本的运行时库也复制到 debug/bin
+}
+
+// prebuiltLib 是预编译的库中的一个库文件, 路径相对安装路径
+type prebuiltLib struct {
+	name     string // 导入目标名, 即去掉 lib 前缀和扩展名的文件名
+	kind     string // STATIC、SHARED 或 UNKNOWN
+	location string // 库文件, Windows 的动态库是 .dll
+	implib   string // Windows 动态库的导入库
+}
+
+// prebuiltTarget 是为预编译的库生成的一个导入目标
+type prebuiltTarget struct {
+	release prebuiltLib
+	debug   *prebuiltLib // 为 nil 时 Debug 也使用 Release 版本
+}
+
+// InstallPrebuilt 把预编译的库安装到 prefix, 并生成 CMake 包配置和 pkg-config 文件:
+//
+//	include/<name>/                    头文件
+//	lib/<name>/、debug/lib/<name>/      Release 和 Debug 版本的库
+//	bin/、debug/bin/                    运行时库, 运行项目时加入 PATH
+//	lib/cmake/<name>/<name>Config.cmake 每个库一个导入目标 <name>::<库名>, 以及包含所有库的 <name>::<name>
+//	lib/pkgconfig/<name>.pc、debug/lib/pkgconfig/<name>.pc
+//
+// 已安装的同名包先被卸载。
+func InstallPrebuilt(prefix string, p *Prebuilt) (*Record, error) {
+	switch {
+	case !namePattern.MatchString(p.Name):
+		return nil, fmt.Errorf("invalid name '%s'", p.Name)
+	case p.Lib == "" && p.DebugLib != "":
+		return nil, fmt.Errorf("the debug libraries of '%s' require the release libraries", p.Name)
+	case p.Include == "" && p.Lib == "":
+		return nil, fmt.Errorf("'%s' has neither headers nor libraries", p.Name)
+	}
+	if _, err := ReadRecord(prefix, p.Name); err == nil {
+		if err := Uninstall(prefix, p.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	record := &Record{Name: p.Name, Source: p.Source, URL: p.Source, Version: p.Version, BuildTypes: BuildTypes, Time: time.Now()}
+	install := func(src, dst string) ([]string, error) {
+		if src == "" {
+			return nil, nil
+		}
+		files, err := copyTree(src, filepath.Join(prefix, filepath.FromSlash(dst)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy %s: %w", src, err)
+		}
+		for i := range files {
+			files[i] = dst + "/" + files[i]
+		}
+		record.Files = append(record.Files, files...)
+		return files, nil
+	}
+
+	if _, err := install(p.Include, "include/"+p.Name); err != nil {
+		return nil, err
+	}
+	dlls, err := install(p.Bin, "bin")
+	if err != nil {
+		return nil, err
+	}
+	// Debug 运行时 PATH 中只有 debug/bin, 没有 Debug 版本的运行时库时复制 Release 版本的,
+	// 它们没有对应的 Debug 版本的库, 不会出现在包配置的 Debug 配置中
+	debugBin := p.DebugBin
+	if debugBin == "" {
+		debugBin = p.Bin
+	}
+	debugDLLs, err := install(debugBin, "debug/bin")
+	if err != nil {
+		return nil, err
+	}
+	libs, err := install(p.Lib, "lib/"+p.Name)
+	if err != nil {
+		return nil, err
+	}
+	debugLibs, err := install(p.DebugLib, "debug/lib/"+p.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Debug 版本的库常以 d、_d 或 -d 结尾, 与 Release 版本的类型不同时不使用
+	debug := make(map[string]prebuiltLib)
+	for _, lib := range scanLibs(debugLibs, debugDLLs) {
+		debug[lib.name] = lib
+	}
+	var targets []prebuiltTarget
+	for _, lib := range scanLibs(libs, dlls) {
+		target := prebuiltTarget{release: lib}
+		for _, suffix := range []string{"", "d", "_d", "-d"} {
+			if d, ok := debug[lib.name+suffix]; ok && d.kind == lib.kind {
+				target.debug = &d
+				break
+			}
+		}
+		targets = append(targets, target)
+	}
+	if p.Lib != "" && len(targets) == 0 {
+		return nil, fmt.Errorf("no libraries found in %s", p.Lib)
+	}
+
+	generated := map[string]string{
+		"lib/cmake/" + p.Name + "/" + p.Name + "Config.cmake": packageConfig(p, targets),
+		"lib/pkgconfig/" + p.Name + ".pc":                     pkgConfig(p, targets, false),
+		"debug/lib/pkgconfig/" + p.Name + ".pc":               pkgConfig(p, targets, true),
+	}
+	if p.Version != "" {
+		generated["lib/cmake/"+p.Name+"/"+p.Name+"ConfigVersion.cmake"] = strings.Replace(packageVersion, "{{ .Version }}", p.Version, -1)
+	}
+	for file, content := range generated {
+		path := filepath.Join(prefix, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return nil, err
+		}
+		record.Files = append(record.Files, file)
+	}
+
+	sort.Strings(record.Files)
+	record.Files = uniqueStrings(record.Files)
+	record.Hash = HashFiles(prefix, record.Files)
+	return record, WriteRecord(prefix, record)
+}
+
+// scanLibs 返回 files 中的库文件, dlls 中与导入库同名的 .dll 是它的运行时库。同名的库只取文件名最短的一个。
+func scanLibs(files, dlls []string) []prebuiltLib {
+	runtime := make(map[string]string)
+	for _, dll := range dlls {
+		if base := path.Base(dll); strings.EqualFold(path.Ext(base), ".dll") {
+			runtime[strings.ToLower(strings.TrimSuffix(base, path.Ext(base)))] = dll
+		}
+	}
+
+	libs := make(map[string]prebuiltLib)
+	for _, file := range files {
+		base := path.Base(file)
+		lower := strings.ToLower(base)
+		var stem string
+		lib := prebuiltLib{location: file}
+		switch {
+		case strings.HasSuffix(lower, ".dll.a") || strings.HasSuffix(lower, ".lib"):
+			stem = base[:strings.LastIndex(lower, ".")]
+			stem = strings.TrimSuffix(stem, ".dll")
+			lib.kind = "STATIC"
+			if strings.HasSuffix(lower, ".dll.a") {
+				lib.kind = "UNKNOWN"
+			}
+			// MinGW 的 libfoo.dll.a 对应 libfoo.dll
+			if dll, ok := runtime[strings.ToLower(stem)]; ok {
+				lib = prebuiltLib{kind: "SHARED", location: dll, implib: file}
+			}
+		case strings.HasSuffix(lower, ".a"):
+			stem, lib.kind = strings.TrimSuffix(base, ".a"), "STATIC"
+		case strings.HasSuffix(lower, ".dylib"):
+			stem, lib.kind = strings.TrimSuffix(base, ".dylib"), "SHARED"
+		case strings.HasSuffix(lower, ".so") || strings.Contains(lower, ".so."):
+			stem, lib.kind = base[:strings.Index(lower, ".so")], "SHARED"
+		default:
+			continue
+		}
+
+		lib.name = stem
+		if len(stem) > 3 && strings.HasPrefix(stem, "lib") {
+			lib.name = stem[3:]
+		}
+		if existing, ok := libs[lib.name]; !ok || len(base) < len(path.Base(existing.linked())) {
+			libs[lib.name] = lib
+		}
+	}
+
+	var sorted []prebuiltLib
+	for _, lib := range libs {
+		sorted = append(sorted, lib)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+	return sorted
+}
+
+// linked 返回链接时使用的文件: 导入库或库文件本身
+func (l prebuiltLib) linked() string {
+	if l.implib != "" {
+		return l.implib
+	}
+	return l.location
+}
+
+// packageConfig 生成 <name>Config.cmake。Release 版本的库也是其他构建类型的默认值。
+func packageConfig(p *Prebuilt, targets []prebuiltTarget) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by cgear for the prebuilt library %s\n", p.Name)
+	b.WriteString("get_filename_component(_IMPORT_PREFIX \"${CMAKE_CURRENT_LIST_DIR}/../../..\" ABSOLUTE)\n")
+
+	include := ""
+	if p.Include != "" {
+		include = "${_IMPORT_PREFIX}/include/" + p.Name
+	}
+
+	var names []string
+	umbrella := true
+	for _, t := range targets {
+		name := p.Name + "::" + t.release.name
+		names = append(names, name)
+		umbrella = umbrella && t.release.name != p.Name
+
+		properties := [][2]string{{"IMPORTED_LOCATION", t.release.location}, {"IMPORTED_IMPLIB", t.release.implib}}
+		if t.debug != nil {
+			properties = append(properties, [2]string{"IMPORTED_LOCATION_DEBUG", t.debug.location}, [2]string{"IMPORTED_IMPLIB_DEBUG", t.debug.implib})
+		}
+		fmt.Fprintf(&b, "\nif(NOT TARGET %s)\n  add_library(%s %s IMPORTED)\n  set_target_properties(%s PROPERTIES\n", name, name, t.release.kind, name)
+		if include != "" {
+			fmt.Fprintf(&b, "    INTERFACE_INCLUDE_DIRECTORIES \"%s\"\n", include)
+		}
+		for _, property := range properties {
+			if property[1] != "" {
+				fmt.Fprintf(&b, "    %s \"${_IMPORT_PREFIX}/%s\"\n", property[0], property[1])
+			}
+		}
+		b.WriteString("  )\nendif()\n")
+	}
+
+	// 包名的目标包含所有库, 只有头文件的库只有这一个目标
+	if umbrella {
+		name := p.Name + "::" + p.Name
+		fmt.Fprintf(&b, "\nif(NOT TARGET %s)\n  add_library(%s INTERFACE IMPORTED)\n", name, name)
+		if include != "" || len(names) > 0 {
+			fmt.Fprintf(&b, "  set_target_properties(%s PROPERTIES\n", name)
+			if include != "" {
+				fmt.Fprintf(&b, "    INTERFACE_INCLUDE_DIRECTORIES \"%s\"\n", include)
+			}
+			if len(names) > 0 {
+				fmt.Fprintf(&b, "    INTERFACE_LINK_LIBRARIES \"%s\"\n", strings.Join(names, ";"))
+			}
+			b.WriteString("  )\n")
+		}
+		b.WriteString("endif()\n")
+	}
+
+	b.WriteString("\nunset(_IMPORT_PREFIX)\n")
+	return b.String()
+}
+
+// pkgConfig 生成 pkg-config 文件, debug 为 true 时链接 Debug 版本的库
+func pkgConfig(p *Prebuilt, targets []prebuiltTarget, debug bool) string {
+	prefix := "${pcfiledir}/../.."
+	if debug {
+		prefix = "${pcfiledir}/../../.."
+	}
+	version := p.Version
+	if version == "" {
+		version = "0"
+	}
+
+	var libs []string
+	for _, t := range targets {
+		lib := t.release
+		if debug && t.debug != nil {
+			lib = *t.debug
+		}
+		libs = append(libs, "${prefix}/"+lib.linked())
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "prefix=%s\nincludedir=${prefix}/include/%s\n\n", prefix, p.Name)
+	fmt.Fprintf(&b, "Name: %s\nDescription: Prebuilt library %s installed by cgear\nVersion: %s\n", p.Name, p.Name, version)
+	if p.Include != "" {
+		b.WriteString("Cflags: -I${includedir}\n")
+	}
+	if len(libs) > 0 {
+		fmt.Fprintf(&b, "Libs: %s\n", strings.Join(libs, " "))
+	}
+	return b.String()
+}
+
+// packageVersion 是 <name>ConfigVersion.cmake, 请求的版本不高于安装的版本时兼容
+var packageVersion = `set(PACKAGE_VERSION "{{ .Version }}")
+if(PACKAGE_FIND_VERSION VERSION_GREATER PACKAGE_VERSION)
+  set(PACKAGE_VERSION_COMPATIBLE FALSE)
+else()
+  set(PACKAGE_VERSION_COMPATIBLE TRUE)
+  if(PACKAGE_FIND_VERSION STREQUAL PACKAGE_VERSION)
+    set(PACKAGE_VERSION_EXACT TRUE)
+  endif()
+endif()
+`
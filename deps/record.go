@@ -0,0 +1,91 @@
+package deps
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/zelviner/cgear/config"
+)
+
+// Record 记录一个已安装的依赖, 保存在 installed/<triplet>/.cgear/<name>.json
+type Record struct {
+	Name         string              `json:"name"`
+	Source       string              `json:"source"`
+	URL          string              `json:"url"`
+	Version      string              `json:"version,omitempty"`
+	Revision     string              `json:"revision"` // 安装的提交
+	Options      map[string]string   `json:"options,omitempty"`
+	Components   []string            `json:"components,omitempty"`
+	BuildHash    string              `json:"build_hash,omitempty"`   // 构建类型选项、补丁和构建命令的摘要, 见 BuildHash
+	BuildTypes   []string            `json:"build_types"`            // 安装的构建类型
+	Dependencies []string            `json:"dependencies,omitempty"` // 该包自己声明的依赖
+	Requires     []config.Dependency `json:"requires,omitempty"`     // 依赖的完整声明, 生成依赖图时代替下载源码
+	Files        []string            `json:"files"`                  // 安装的文件, 相对安装路径
+	Hash         string              `json:"hash"`                   // 文件集合的摘要
+	Time         time.Time           `json:"time"`                   // 安装时间
+}
+
+// NewRecord 根据依赖的声明生成安装记录
+func NewRecord(dep config.Dependency) *Record {
+	return &Record{
+		Name:       dep.Name,
+		Source:     dep.Source,
+		Version:    dep.Version,
+		Options:    dep.Options,
+		Components: dep.Components,
+		BuildHash:  BuildHash(dep),
+		Time:       time.Now(),
+	}
+}
+
+// Locked 返回记录在锁文件中的形式
+func (r *Record) Locked() Locked {
+	return Locked{
+		Name:     r.Name,
+		Source:   r.Source,
+		URL:      r.URL,
+		Version:  r.Version,
+		Revision: r.Revision,
+		Options:  r.Options,
+		Hash:     r.Hash,
+	}
+}
+
+// Satisfies 判断已安装的依赖是否与声明一致, 不一致时需要重新安装
+func (r *Record) Satisfies(dep config.Dependency) bool {
+	return r.Source == dep.Source && r.Version == dep.Version && sameOptions(r.Options, dep.Options) &&
+		r.BuildHash == BuildHash(dep) &&
+		(len(r.Components) == 0 && len(dep.Components) == 0 || reflect.DeepEqual(r.Components, dep.Components))
+}
+
+func recordPath(prefix, name string) string {
+	return filepath.Join(prefix, ".cgear", name+".json")
+}
+
+// ReadRecord 读取安装在 prefix 下的依赖的记录
+func ReadRecord(prefix, name string) (*Record, error) {
+	data, err := os.ReadFile(recordPath(prefix, name))
+	if err != nil {
+		return nil, err
+	}
+
+	record := &Record{}
+	return record, json.Unmarshal(data, record)
+}
+
+// WriteRecord 保存依赖的安装记录
+func WriteRecord(prefix string, record *Record) error {
+	path := recordPath(prefix, record.Name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,43 @@
+package deps
+
+import (
+	"errors"
+	"sync"
+)
+
+// Schedule 并行执行 names 中的任务。一个任务在 requires 中它依赖的任务都成功后才开始,
+// 依赖的任务失败时不再执行。requires 不能有环。返回所有失败的任务的错误。
+func Schedule(names []string, requires map[string][]string, run func(name string) error) error {
+	done := make(map[string]chan struct{}, len(names))
+	succeeded := make(map[string]*bool, len(names))
+	for _, name := range names {
+		done[name] = make(chan struct{})
+		succeeded[name] = new(bool)
+	}
+
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			defer close(done[name])
+
+			for _, require := range requires[name] {
+				if ch, ok := done[require]; ok {
+					<-ch
+					if !*succeeded[require] {
+						return
+					}
+				}
+			}
+
+			if errs[i] = run(name); errs[i] == nil {
+				*succeeded[name] = true
+			}
+		}(i, name)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
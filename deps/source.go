@@ -0,0 +1,123 @@
+package deps
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// author:repository, 旧的 GitHub 写法, 使用 SSH 地址
+	githubPattern = regexp.MustCompile(`^([A-Za-z0-9_.-]+):([A-Za-z0-9_.-]+)$`)
+	// author/repository[@version], GitHub 的简写
+	shorthandPattern = regexp.MustCompile(`^([A-Za-z0-9_.-]+)/([A-Za-z0-9_.-]+)(?:@(.+))?$`)
+	// user@host:path, scp 风格的 SSH 地址
+	scpPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+@[A-Za-z0-9_.-]+:.+$`)
+	// host/group/repository, 例如 gitlab.com/group/repo
+	hostPattern = regexp.MustCompile(`^[A-Za-z0-9-]+(\.[A-Za-z0-9-]+)+/[^@#]+$`)
+)
+
+// Source 是解析后的依赖来源
+type Source struct {
+	URL string // git 仓库地址或本地路径
+	Ref string // 来源中指定的标签、分支或提交, 可以为空
+}
+
+// ParseSource 解析依赖来源, 支持以下写法, 都可以用 #branch 或 #<sha> 指定版本:
+//
+//	author:repository              GitHub 仓库, 使用 SSH 地址
+//	author/repository@v1.2.3       GitHub 仓库, 使用 HTTPS 地址
+//	gitlab.com/group/repository    其他主机上的仓库, 使用 HTTPS 地址
+//	https://、http://、ssh://、git://、file:// 开头的地址, 以及 git@host:path
+//	本地路径, 相对路径以当前目录为基准, 不是以 . 开头的相对路径必须已经存在
+func ParseSource(spec string) (Source, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return Source{}, fmt.Errorf("empty source")
+	}
+
+	var source Source
+	if index := strings.LastIndex(spec, "#"); index != -1 {
+		spec, source.Ref = spec[:index], spec[index+1:]
+		if source.Ref == "" {
+			return Source{}, fmt.Errorf("empty revision after '#'")
+		}
+	}
+
+	switch {
+	case strings.Contains(spec, "://") || scpPattern.MatchString(spec):
+		source.URL = spec
+
+	case isLocalPath(spec):
+		path, err := filepath.Abs(spec)
+		if err != nil {
+			return Source{}, err
+		}
+		source.URL = path
+
+	case githubPattern.MatchString(spec):
+		match := githubPattern.FindStringSubmatch(spec)
+		source.URL = "git@github.com:" + match[1] + "/" + match[2]
+
+	case hostPattern.MatchString(spec) && strings.Count(spec, "/") >= 2:
+		source.URL = "https://" + spec
+
+	case shorthandPattern.MatchString(spec):
+		match := shorthandPattern.FindStringSubmatch(spec)
+		if match[3] != "" {
+			if source.Ref != "" {
+				return Source{}, fmt.Errorf("source '%s' has both a version and a revision", spec)
+			}
+			source.Ref = match[3]
+		}
+		source.URL = "https://github.com/" + match[1] + "/" + strings.TrimSuffix(match[2], ".git") + ".git"
+
+	case isDir(spec):
+		path, err := filepath.Abs(spec)
+		if err != nil {
+			return Source{}, err
+		}
+		source.URL = path
+
+	default:
+		return Source{}, fmt.Errorf("invalid source '%s', for example: google/googletest@v1.14.0 or https://host/repo.git#main", spec)
+	}
+
+	return source, nil
+}
+
+// Revision 返回要检出的版本: 来源中的版本和声明的 version 不能互相矛盾
+func (s Source) Revision(version string) (string, error) {
+	switch {
+	case s.Ref == "":
+		return version, nil
+	case version == "" || version == s.Ref:
+		return s.Ref, nil
+	}
+	return "", fmt.Errorf("source revision '%s' conflicts with version '%s'", s.Ref, version)
+}
+
+// Name 返回仓库的名称, 即地址最后一段去掉 .git
+func (s Source) Name() string {
+	name := strings.TrimRight(filepath.ToSlash(s.URL), "/")
+	if index := strings.LastIndexAny(name, "/:"); index != -1 {
+		name = name[index+1:]
+	}
+	return strings.TrimSuffix(name, ".git")
+}
+
+// isLocalPath 判断来源是否为本地路径
+func isLocalPath(spec string) bool {
+	if filepath.IsAbs(spec) || strings.HasPrefix(spec, ".") || strings.HasPrefix(spec, "/") || strings.HasPrefix(spec, `\`) {
+		return true
+	}
+	// Windows 盘符, 例如 C:\libs\fmt 或 C:/libs/fmt
+	return len(spec) >= 3 && spec[1] == ':' && (spec[2] == '\\' || spec[2] == '/')
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
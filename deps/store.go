@@ -0,0 +1,184 @@
+package deps
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Store 是二进制缓存的存储位置, 像一个目录一样按文件名读写
+type Store interface {
+	Get(name string) (io.ReadCloser, error) // 文件不存在时返回 os.ErrNotExist
+	Put(name string, r io.Reader) error
+	Delete(name string) error
+	List() ([]string, error) // 返回所有文件名
+	String() string
+}
+
+// OpenStore 打开缓存位置: http:// 或 https:// 开头时为支持 GET、PUT 和 DELETE 的 HTTP 服务器,
+// 否则为目录, 例如本地目录或网络共享
+func OpenStore(location string) Store {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return &httpStore{base: strings.TrimRight(location, "/")}
+	}
+	return dirStore(location)
+}
+
+// dirStore 把缓存保存在目录中
+type dirStore string
+
+func (d dirStore) Get(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(string(d), name))
+}
+
+// Put 先写入临时文件再重命名, 其他进程不会读到写了一半的文件
+func (d dirStore) Put(name string, r io.Reader) error {
+	if err := os.MkdirAll(string(d), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(string(d), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(string(d), name))
+}
+
+func (d dirStore) Delete(name string) error {
+	return os.Remove(filepath.Join(string(d), name))
+}
+
+func (d dirStore) List() ([]string, error) {
+	entries, err := os.ReadDir(string(d))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+func (d dirStore) String() string {
+	return string(d)
+}
+
+// 目录列表页面中的链接
+var hrefPattern = regexp.MustCompile(`href="([^"?#]+)"`)
+
+// httpStore 把缓存保存在 HTTP 服务器上。列出文件时读取服务器的目录列表页面。
+type httpStore struct {
+	base string
+}
+
+func (h *httpStore) do(method, name string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, h.base+"/"+url.PathEscape(name), body)
+	if err != nil {
+		return nil, err
+	}
+	// 上传文件时给出长度, 有的服务器不接受分块传输
+	if f, ok := body.(*os.File); ok {
+		if info, err := f.Stat(); err == nil {
+			req.ContentLength = info.Size()
+		}
+	}
+	return http.DefaultClient.Do(req)
+}
+
+func (h *httpStore) Get(name string) (io.ReadCloser, error) {
+	resp, err := h.do(http.MethodGet, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, os.ErrNotExist
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s/%s: %s", h.base, name, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func (h *httpStore) Put(name string, r io.Reader) error {
+	resp, err := h.do(http.MethodPut, name, r)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("PUT %s/%s: %s", h.base, name, resp.Status)
+	}
+	return nil
+}
+
+func (h *httpStore) Delete(name string) error {
+	resp, err := h.do(http.MethodDelete, name, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return os.ErrNotExist
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("DELETE %s/%s: %s", h.base, name, resp.Status)
+	}
+	return nil
+}
+
+func (h *httpStore) List() ([]string, error) {
+	resp, err := http.Get(h.base + "/")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s/: %s", h.base, resp.Status)
+	}
+
+	page, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, match := range hrefPattern.FindAllStringSubmatch(string(page), -1) {
+		name, err := url.PathUnescape(path.Base(match[1]))
+		if err != nil || strings.HasSuffix(match[1], "/") || strings.HasPrefix(name, ".") {
+			continue
+		}
+		names = append(names, name)
+	}
+	return uniqueStrings(names), nil
+}
+
+func (h *httpStore) String() string {
+	return h.base
+}
@@ -0,0 +1,277 @@
+package deps
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zelviner/cgear/config"
+)
+
+// VendorDir 是项目中保存依赖源码的目录, 由 cgear vendor 生成
+const VendorDir = "vendor"
+
+// VendorIndex 是 vendor 目录中记录每个包的文件
+const VendorIndex = "vendor.json"
+
+// Vendor 记录 vendor 目录中每个包的源码
+type Vendor struct {
+	Dir      string     `json:"-"`
+	Packages []Vendored `json:"packages"`
+}
+
+// Vendored 是 vendor 目录中的一个包。git 仓库保存为检出的源码的 tar.gz, 压缩包保存原来的文件。
+type Vendored struct {
+	Name     string            `json:"name"`
+	Source   string            `json:"source,omitempty"`
+	URL      string            `json:"url,omitempty"`
+	Version  string            `json:"version,omitempty"`
+	Revision string            `json:"revision"` // 保存的提交, 压缩包为它的 sha256
+	Options  map[string]string `json:"options,omitempty"`
+	File     string            `json:"file"`   // 相对 vendor 目录
+	SHA256   string            `json:"sha256"` // 文件的摘要
+}
+
+// Matches 判断保存的包是否仍然对应依赖的声明
+func (v *Vendored) Matches(dep config.Dependency) bool {
+	locked := Locked{Name: v.Name, Source: v.Source, URL: v.URL, Version: v.Version, Revision: v.Revision, Options: v.Options}
+	return locked.Matches(dep)
+}
+
+// ReadVendor 读取 dir 中的 vendor.json
+func ReadVendor(dir string) (*Vendor, error) {
+	data, err := os.ReadFile(filepath.Join(dir, VendorIndex))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s not found, run 'cgear vendor' first", filepath.Join(dir, VendorIndex))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	vendor := &Vendor{Dir: dir}
+	if err := json.Unmarshal(data, vendor); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Join(dir, VendorIndex), err)
+	}
+	return vendor, nil
+}
+
+// Find 返回包名为 name 的包, 没有时返回 nil
+func (v *Vendor) Find(name string) *Vendored {
+	for i := range v.Packages {
+		if v.Packages[i].Name == name {
+			return &v.Packages[i]
+		}
+	}
+	return nil
+}
+
+// Save 按包名排序后写入 vendor.json
+func (v *Vendor) Save() error {
+	sort.Slice(v.Packages, func(i, j int) bool { return v.Packages[i].Name < v.Packages[j].Name })
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(v.Dir, VendorIndex), append(data, '\n'), 0644)
+}
+
+// WriteVendor 把依赖图中每个包的源码保存到 dir, 删除不再需要的文件。
+// 已经保存过相同提交的包不再重新打包。
+func (in *Installer) WriteVendor(graph *Graph, dir string) (*Vendor, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	previous, err := ReadVendor(dir)
+	if err != nil {
+		previous = &Vendor{Dir: dir}
+	}
+
+	vendor := &Vendor{Dir: dir}
+	keep := map[string]bool{VendorIndex: true}
+	for _, name := range graph.Order {
+		pkg := graph.Packages[name]
+		dep := pkg.Dependency
+		vendored := Vendored{Name: name, Source: dep.Source, URL: dep.URL, Version: dep.Version, Revision: pkg.Revision, Options: dep.Options}
+
+		if dep.URL != "" {
+			vendored.File = dep.Name + "-" + pkg.Revision[:12] + ArchiveExt(dep.URL)
+		} else {
+			vendored.File = dep.Name + "-" + shortRevision(pkg.Revision) + ".tar.gz"
+		}
+		keep[vendored.File] = true
+		path := filepath.Join(dir, vendored.File)
+
+		if old := previous.Find(name); old != nil && old.File == vendored.File {
+			if sum, err := fileSHA256(path); err == nil && sum == old.SHA256 {
+				vendored.SHA256 = sum
+				vendor.Packages = append(vendor.Packages, vendored)
+				continue
+			}
+		}
+
+		// 生成依赖图时没有下载已经安装的包
+		if pkg.Path == "" {
+			url, ref, err := in.resolve(dep, pkg.Revision)
+			if err != nil {
+				return nil, err
+			}
+			if pkg.Path, _, err = in.checkout(dep, url, ref, nil); err != nil {
+				return nil, fmt.Errorf("failed to download %s: %w", name, err)
+			}
+		}
+
+		if dep.URL != "" {
+			// 压缩包已经下载并校验过, 放在解压目录旁边
+			err = Download(pkg.Path+ArchiveExt(dep.URL), pkg.Revision, path, nil)
+		} else {
+			err = archiveSource(pkg.Path, path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to vendor %s: %w", name, err)
+		}
+		if vendored.SHA256, err = fileSHA256(path); err != nil {
+			return nil, err
+		}
+		vendor.Packages = append(vendor.Packages, vendored)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && !keep[entry.Name()] {
+			os.Remove(filepath.Join(dir, entry.Name()))
+		}
+	}
+	return vendor, vendor.Save()
+}
+
+// archiveSource 把 sourcePath 中检出的源码, 包括子模块, 打包到 dest, 不包含 .git。
+// 文件都放在以 sourcePath 的目录名命名的顶层目录下, 解压时去掉该目录。
+func archiveSource(sourcePath, dest string) error {
+	parent, base := filepath.Dir(sourcePath), filepath.Base(sourcePath)
+
+	var files []string
+	err := filepath.Walk(sourcePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Name() == ".git" {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(parent, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		files = append(files, base+"/")
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(dest), ".vendor-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	err = archive(f, parent, files)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), dest)
+}
+
+// resolveVendored 返回 vendor 目录中保存的包的地址和提交, 包必须与声明一致, 且与锁定的提交相同
+func (in *Installer) resolveVendored(dep config.Dependency, revision string) (string, string, error) {
+	vendored := in.Vendor.Find(dep.Name)
+	switch {
+	case vendored == nil || !vendored.Matches(dep):
+		return "", "", fmt.Errorf("'%s' is not in %s or has changed since, run 'cgear vendor'", dep.Name, in.Vendor.Dir)
+	case revision != "" && revision != vendored.Revision:
+		return "", "", fmt.Errorf("'%s' is locked at %s but %s has %s, run 'cgear vendor'",
+			dep.Name, shortRevision(revision), in.Vendor.Dir, shortRevision(vendored.Revision))
+	}
+
+	if dep.URL != "" {
+		return dep.URL, vendored.Revision, nil
+	}
+	source, err := ParseSource(dep.Source)
+	if err != nil {
+		return "", "", err
+	}
+	return source.URL, vendored.Revision, nil
+}
+
+// unvendor 把 vendor 目录中保存的包放到 CGEAR_HOME/pkg 下, 返回源码目录和保存的提交
+func (in *Installer) unvendor(dep config.Dependency, output io.Writer) (string, string, error) {
+	vendored := in.Vendor.Find(dep.Name)
+	if vendored == nil {
+		return "", "", fmt.Errorf("'%s' is not in %s, run 'cgear vendor'", dep.Name, in.Vendor.Dir)
+	}
+	path := filepath.Join(in.Vendor.Dir, vendored.File)
+	if output != nil {
+		fmt.Fprintf(output, "Unpacking %s\n", path)
+	}
+
+	if dep.URL != "" {
+		sourcePath := in.archivePath(dep, vendored.Revision)
+		if err := Download(path, vendored.Revision, sourcePath+ArchiveExt(dep.URL), nil); err != nil {
+			return "", "", err
+		}
+		return sourcePath, vendored.Revision, Unpack(sourcePath+ArchiveExt(dep.URL), sourcePath)
+	}
+
+	if sum, err := fileSHA256(path); err != nil || sum != vendored.SHA256 {
+		return "", "", fmt.Errorf("%s is missing or damaged, run 'cgear vendor' again", path)
+	}
+	sourcePath := filepath.Join(in.PkgPath, dep.Name)
+	return sourcePath, vendored.Revision, Unpack(path, sourcePath)
+}
+
+// mirror 按 Mirrors 替换地址的前缀, 有多个前缀匹配时使用最长的
+func (in *Installer) mirror(url string) string {
+	best := ""
+	for prefix := range in.Mirrors {
+		if strings.HasPrefix(url, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return url
+	}
+	return in.Mirrors[best] + strings.TrimPrefix(url, best)
+}
+
+// ParseMirrors 解析 CGEAR_MIRRORS 环境变量, 格式为逗号分隔的 from=to
+func ParseMirrors(value string) map[string]string {
+	mirrors := make(map[string]string)
+	for _, item := range strings.Split(value, ",") {
+		from, to, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if ok && from != "" {
+			mirrors[from] = to
+		}
+	}
+	return mirrors
+}
@@ -0,0 +1,216 @@
+package deps
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 标签中的版本号, 例如 v1.2.3、1.2、release-1.8.0、curl-8_5_0 和 2.0.0-rc1
+var semverPattern = regexp.MustCompile(`^(?:[A-Za-z]+[-_])?[vV]?(\d+)(?:[._](\d+))?(?:[._](\d+))?(?:-([0-9A-Za-z.-]+))?$`)
+
+// Semver 是从标签中解析出的版本号
+type Semver struct {
+	Major, Minor, Patch int
+	Pre                 string // 预发布标识, 例如 rc1, 为空时是正式版本
+	Parts               int    // 标签中给出的部分数, 例如 1.2 为 2
+}
+
+// ParseSemver 解析标签中的版本号, 不是版本号的标签返回 false
+func ParseSemver(tag string) (Semver, bool) {
+	match := semverPattern.FindStringSubmatch(tag)
+	if match == nil {
+		return Semver{}, false
+	}
+
+	v := Semver{Pre: match[4], Parts: 1}
+	v.Major, _ = strconv.Atoi(match[1])
+	if match[2] != "" {
+		v.Minor, _ = strconv.Atoi(match[2])
+		v.Parts = 2
+	}
+	if match[3] != "" {
+		v.Patch, _ = strconv.Atoi(match[3])
+		v.Parts = 3
+	}
+	return v, true
+}
+
+// Compare 比较两个版本号, 预发布版本小于对应的正式版本
+func (v Semver) Compare(o Semver) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return d
+		}
+	}
+	switch {
+	case v.Pre == o.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case o.Pre == "":
+		return -1
+	}
+	return strings.Compare(v.Pre, o.Pre)
+}
+
+func (v Semver) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// IsConstraint 判断声明的版本是否为版本约束, 例如 ^1.2、~1.2.3 或 >=1.0, <2.0, 而不是标签或分支
+func IsConstraint(version string) bool {
+	return version != "" && strings.ContainsRune("^~<>=", rune(version[0]))
+}
+
+// comparator 是约束中的一个条件
+type comparator struct {
+	op string
+	v  Semver
+}
+
+// 运算符与版本之间的空格, 例如 >= 1.0
+var operatorSpace = regexp.MustCompile(`([\^~<>=]+)\s+`)
+
+// Constraint 是版本约束, 所有条件都满足时匹配
+type Constraint []comparator
+
+// ParseConstraint 解析以逗号或空格分隔的条件:
+//
+//	^1.2.3  与 1.2.3 兼容, 即 >=1.2.3, <2.0.0; 主版本为 0 时为 >=0.2.3, <0.3.0
+//	~1.2.3  只更新补丁版本, 即 >=1.2.3, <1.3.0; ~1 为 >=1.0.0, <2.0.0
+//	>=、>、<=、<、= 与版本比较
+func ParseConstraint(s string) (Constraint, error) {
+	var c Constraint
+	terms := operatorSpace.ReplaceAllString(s, "$1")
+	for _, term := range strings.FieldsFunc(terms, func(r rune) bool { return r == ',' || r == ' ' }) {
+		op := term[:len(term)-len(strings.TrimLeft(term, "^~<>="))]
+		v, ok := ParseSemver(term[len(op):])
+		if !ok {
+			return nil, fmt.Errorf("invalid version constraint '%s'", s)
+		}
+
+		switch op {
+		case "^":
+			upper := Semver{Major: v.Major + 1}
+			if v.Major == 0 && v.Parts > 1 {
+				upper = Semver{Minor: v.Minor + 1}
+			}
+			c = append(c, comparator{">=", v}, comparator{"<", upper})
+		case "~":
+			upper := Semver{Major: v.Major, Minor: v.Minor + 1}
+			if v.Parts == 1 {
+				upper = Semver{Major: v.Major + 1}
+			}
+			c = append(c, comparator{">=", v}, comparator{"<", upper})
+		case ">=", ">", "<=", "<", "=":
+			c = append(c, comparator{op, v})
+		default:
+			return nil, fmt.Errorf("invalid operator '%s' in version constraint '%s'", op, s)
+		}
+	}
+	if len(c) == 0 {
+		return nil, fmt.Errorf("empty version constraint")
+	}
+	return c, nil
+}
+
+// Match 判断版本是否满足约束。预发布版本只在约束中的版本也是预发布版本时匹配。
+func (c Constraint) Match(v Semver) bool {
+	if v.Pre != "" {
+		allowed := false
+		for _, cmp := range c {
+			allowed = allowed || cmp.v.Pre != ""
+		}
+		if !allowed {
+			return false
+		}
+	}
+
+	for _, cmp := range c {
+		d := v.Compare(cmp.v)
+		var ok bool
+		switch cmp.op {
+		case ">=":
+			ok = d >= 0
+		case ">":
+			ok = d > 0
+		case "<=":
+			ok = d <= 0
+		case "<":
+			ok = d < 0
+		case "=":
+			ok = d == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Tag 是远程仓库中的一个标签
+type Tag struct {
+	Name   string
+	Commit string // 标签指向的提交
+}
+
+// RemoteTags 通过 git ls-remote --tags 列出 url 仓库的标签
+func RemoteTags(url string) ([]Tag, error) {
+	output, err := git("", nil, "ls-remote", "--tags", url)
+	if err != nil {
+		return nil, err
+	}
+
+	// 附注标签以 ^{} 结尾的一行是标签指向的提交
+	commits := make(map[string]string)
+	var names []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], "refs/tags/") {
+			continue
+		}
+		name := strings.TrimPrefix(fields[1], "refs/tags/")
+		if peeled := strings.TrimSuffix(name, "^{}"); peeled != name {
+			commits[peeled] = fields[0]
+			continue
+		}
+		if _, ok := commits[name]; !ok {
+			names = append(names, name)
+			commits[name] = fields[0]
+		}
+	}
+
+	tags := make([]Tag, 0, len(names))
+	for _, name := range names {
+		tags = append(tags, Tag{Name: name, Commit: commits[name]})
+	}
+	return tags, nil
+}
+
+// NewestTag 返回版本号最大且满足 match 的标签, match 为 nil 时只排除预发布版本。没有时返回 nil。
+func NewestTag(tags []Tag, match func(Semver) bool) *Tag {
+	sorted := append([]Tag(nil), tags...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		vi, _ := ParseSemver(sorted[i].Name)
+		vj, _ := ParseSemver(sorted[j].Name)
+		return vi.Compare(vj) > 0
+	})
+
+	for i, tag := range sorted {
+		v, ok := ParseSemver(tag.Name)
+		if !ok {
+			continue
+		}
+		if match == nil && v.Pre == "" || match != nil && match(v) {
+			return &sorted[i]
+		}
+	}
+	return nil
+}
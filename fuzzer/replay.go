@@ -0,0 +1,85 @@
+package fuzzer
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// 每次启动 fuzz 程序回放的最大输入数, 避免命令行过长
+const replayBatch = 200
+
+// Result 是一个输入的回放结果
+type Result struct {
+	Input  string  // 输入文件路径
+	Passed bool    // 是否正常返回
+	Time   float64 // 耗时（秒）, 批量回放时为平均值
+	Output []byte  // 失败时的输出
+}
+
+// Inputs 返回目录中的所有输入文件, 按路径排序, 不存在的目录被忽略
+func Inputs(dirs ...string) []string {
+	var inputs []string
+	for _, dir := range dirs {
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if !info.IsDir() {
+				inputs = append(inputs, path)
+			}
+			return nil
+		})
+	}
+
+	sort.Strings(inputs)
+	return inputs
+}
+
+// Replay 用 fuzz 程序逐个执行输入, 不进行变异。
+// libFuzzer 和 cgear 生成的独立入口都把命令行中的文件当作输入各执行一次。
+// 输入分批回放, 某一批失败时再逐个回放该批输入以找出导致失败的输入。
+func Replay(program string, inputs []string) []Result {
+	var results []Result
+	for start := 0; start < len(inputs); start += replayBatch {
+		end := start + replayBatch
+		if end > len(inputs) {
+			end = len(inputs)
+		}
+		batch := inputs[start:end]
+
+		elapsed, _, err := run(program, batch)
+		if err == nil {
+			for _, input := range batch {
+				results = append(results, Result{Input: input, Passed: true, Time: elapsed / float64(len(batch))})
+			}
+			continue
+		}
+
+		for _, input := range batch {
+			elapsed, output, err := run(program, []string{input})
+			result := Result{Input: input, Passed: err == nil, Time: elapsed}
+			if err != nil {
+				result.Output = output
+			}
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
+
+func run(program string, inputs []string) (float64, []byte, error) {
+	var output bytes.Buffer
+
+	cmd := exec.Command(program, inputs...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	started := time.Now()
+	err := cmd.Run()
+	return time.Since(started).Seconds(), output.Bytes(), err
+}
@@ -0,0 +1,312 @@
+package mutator
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 变异算子
+const (
+	Comparison = "comparison" // 翻转比较运算符, 例如 < 改为 >=
+	Logical    = "logical"    // 交换 && 和 ||
+	Constant   = "constant"   // 替换整数常量和布尔常量
+	Deletion   = "deletion"   // 删除语句
+)
+
+// Mutant 是对源文件的一处修改
+type Mutant struct {
+	ID          int    `json:"id"`
+	File        string `json:"file"` // 相对项目目录的路径
+	Line        int    `json:"line"`
+	Column      int    `json:"column"`
+	Operator    string `json:"operator"`
+	Original    string `json:"original"`
+	Replacement string `json:"replacement"`
+	Offset      int    `json:"-"` // 在文件中的字节偏移
+}
+
+// 比较运算符和逻辑运算符的替换
+var replacements = map[string]string{
+	"==": "!=",
+	"!=": "==",
+	"<":  ">=",
+	">":  "<=",
+	"<=": ">",
+	">=": "<",
+	"&&": "||",
+	"||": "&&",
+}
+
+// 删除语句时跳过的语句, 删除它们通常只会导致编译失败
+var keepStatements = []string{"return", "using", "typedef", "case", "default", "else", "while", "friend", "static_assert"}
+
+// Generate 在 C++ 源码中查找可以变异的位置。
+// 注释、字符串、字符常量和预处理指令中的内容不会被变异。
+// 只有两侧都有空格的 <、>、&& 和 || 才被看作二元运算符, 以避开模板参数和右值引用。
+func Generate(file string, src []byte) []Mutant {
+	s := scanner{src: src, file: file, stmtStart: -1}
+	s.scan()
+
+	// 删除语句的变异在语句结束时才加入, 按位置重新排序
+	sort.SliceStable(s.mutants, func(i, j int) bool { return s.mutants[i].Offset < s.mutants[j].Offset })
+	for i := range s.mutants {
+		s.mutants[i].ID = i + 1
+		s.mutants[i].Line, s.mutants[i].Column = position(src, s.mutants[i].Offset)
+	}
+	return s.mutants
+}
+
+// Apply 返回应用变异后的源码
+func Apply(src []byte, m Mutant) []byte {
+	var out bytes.Buffer
+	out.Write(src[:m.Offset])
+	out.WriteString(m.Replacement)
+	out.Write(src[m.Offset+len(m.Original):])
+	return out.Bytes()
+}
+
+type scanner struct {
+	src     []byte
+	file    string
+	mutants []Mutant
+
+	depth     int // 花括号深度
+	parens    int // 圆括号深度
+	stmtStart int // 当前语句第一个字符的偏移, -1 表示还没有遇到语句
+}
+
+func (s *scanner) add(offset int, operator, original, replacement string) {
+	s.mutants = append(s.mutants, Mutant{File: s.file, Operator: operator, Original: original, Replacement: replacement, Offset: offset})
+}
+
+func (s *scanner) scan() {
+	src := s.src
+	atLineStart := true
+
+	for i := 0; i < len(src); {
+		c := src[i]
+
+		switch {
+		case c == '\n':
+			atLineStart = true
+			i++
+			continue
+
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+			continue
+
+		// 预处理指令, 支持反斜杠续行
+		case c == '#' && atLineStart:
+			for i < len(src) && !(src[i] == '\n' && src[i-1] != '\\') {
+				i++
+			}
+			continue
+
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := bytes.Index(src[i+2:], []byte("*/"))
+			if end == -1 {
+				return
+			}
+			i += end + 4
+			continue
+		}
+
+		atLineStart = false
+		if s.stmtStart == -1 && c != ';' && c != '{' && c != '}' {
+			s.stmtStart = i
+		}
+
+		switch {
+		case c == '"' || c == '\'':
+			i = skipQuoted(src, i)
+
+		case isIdentStart(c):
+			start := i
+			for i < len(src) && isIdentChar(src[i]) {
+				i++
+			}
+			switch word := string(src[start:i]); word {
+			case "true":
+				s.add(start, Constant, word, "false")
+			case "false":
+				s.add(start, Constant, word, "true")
+			}
+			// 原始字符串 R"(...)" 及带前缀的字符串
+			if i < len(src) && src[i] == '"' {
+				i = skipQuoted(src, i)
+			}
+
+		case isDigit(c):
+			start := i
+			for i < len(src) && (isIdentChar(src[i]) || src[i] == '.' || src[i] == '\'') {
+				i++
+			}
+			s.constant(start, string(src[start:i]))
+
+		case c == '(':
+			s.parens++
+			i++
+
+		case c == ')':
+			s.parens--
+			i++
+
+		case c == '{':
+			s.depth++
+			s.stmtStart = -1
+			i++
+
+		case c == '}':
+			s.depth--
+			s.stmtStart = -1
+			i++
+
+		case c == ';':
+			if s.parens == 0 {
+				s.statement(i)
+				s.stmtStart = -1
+			}
+			i++
+
+		default:
+			i += s.operator(i)
+		}
+	}
+}
+
+// operator 检查 i 处的运算符, 返回运算符的长度
+func (s *scanner) operator(i int) int {
+	src := s.src
+	spaced := func(start, end int) bool {
+		return start > 0 && end < len(src) && isSpace(src[start-1]) && isSpace(src[end])
+	}
+
+	if i+1 < len(src) {
+		op := string(src[i : i+2])
+		switch op {
+		case "==", "!=", "<=", ">=":
+			// 排除 <<=、>>= 和 <=>
+			if (op == "<=" || op == ">=") && (i+2 < len(src) && src[i+2] == '>' || i > 0 && src[i-1] == src[i]) {
+				return 2
+			}
+			s.add(i, Comparison, op, replacements[op])
+			return 2
+		case "&&", "||":
+			if spaced(i, i+2) {
+				s.add(i, Logical, op, replacements[op])
+			}
+			return 2
+		case "<<", ">>", "->", "::":
+			return 2
+		}
+	}
+
+	if (src[i] == '<' || src[i] == '>') && spaced(i, i+1) {
+		op := string(src[i])
+		s.add(i, Comparison, op, replacements[op])
+	}
+	return 1
+}
+
+// constant 替换十进制整数常量: 0 改为 1, 其他值改为 0
+func (s *scanner) constant(offset int, literal string) {
+	digits := strings.TrimRight(literal, "uUlL")
+	if _, err := strconv.ParseUint(digits, 10, 64); err != nil {
+		return
+	}
+
+	suffix := literal[len(digits):]
+	if strings.TrimLeft(digits, "0") == "" {
+		s.add(offset, Constant, literal, "1"+suffix)
+	} else {
+		s.add(offset, Constant, literal, "0"+suffix)
+	}
+}
+
+// statement 在花括号内删除以 end 处的分号结尾的单行语句。
+// 只删除包含调用、赋值或自增自减的语句, 跳过声明。
+func (s *scanner) statement(end int) {
+	if s.depth == 0 || s.stmtStart == -1 {
+		return
+	}
+
+	text := string(s.src[s.stmtStart : end+1])
+	if strings.Contains(text, "\n") {
+		return
+	}
+
+	for _, keyword := range keepStatements {
+		if text == keyword+";" || strings.HasPrefix(text, keyword+" ") || strings.HasPrefix(text, keyword+"(") {
+			return
+		}
+	}
+
+	if !strings.ContainsAny(text, "(=") && !strings.Contains(text, "++") && !strings.Contains(text, "--") {
+		return
+	}
+
+	// 带初始值的声明, 例如 "int count = 0;", 删除后通常无法编译
+	if lhs, _, ok := strings.Cut(text, "="); ok && !strings.Contains(lhs, "(") && strings.ContainsAny(strings.TrimRight(lhs, " +-*/%&|^<>!"), " \t") {
+		return
+	}
+
+	s.add(s.stmtStart, Deletion, text, "")
+}
+
+// skipQuoted 跳过字符串或字符常量, 返回结束引号之后的位置
+func skipQuoted(src []byte, i int) int {
+	quote := src[i]
+
+	// 原始字符串 R"delim( ... )delim"
+	if quote == '"' && i > 0 && src[i-1] == 'R' {
+		open := bytes.IndexByte(src[i:], '(')
+		if open != -1 {
+			delim := ")" + string(src[i+1:i+open]) + "\""
+			if end := bytes.Index(src[i+open:], []byte(delim)); end != -1 {
+				return i + open + end + len(delim)
+			}
+		}
+	}
+
+	for i++; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case quote, '\n':
+			return i + 1
+		}
+	}
+	return i
+}
+
+// position 返回偏移对应的行号和列号, 从 1 开始
+func position(src []byte, offset int) (int, int) {
+	line := bytes.Count(src[:offset], []byte("\n")) + 1
+	column := offset - bytes.LastIndexByte(src[:offset], '\n')
+	return line, column
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
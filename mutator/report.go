@@ -0,0 +1,156 @@
+package mutator
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// 变异体的状态
+const (
+	Killed   = "killed"   // 测试失败或超时, 变异被发现
+	Survived = "survived" // 测试全部通过, 变异没有被发现
+	Invalid  = "invalid"  // 变异后无法编译, 不计入得分
+)
+
+// Result 是一个变异体的测试结果
+type Result struct {
+	Mutant
+	Status string `json:"status"`
+}
+
+// FileReport 是一个源文件的变异结果
+type FileReport struct {
+	File     string   `json:"file"`
+	Killed   int      `json:"killed"`
+	Survived []Result `json:"survived"` // 存活的变异体, 按行号排列
+	Invalid  int      `json:"invalid"`
+}
+
+// Report 是一次变异测试的报告
+type Report struct {
+	Time     time.Time    `json:"time"`
+	Score    float64      `json:"score"` // 被发现的变异体占有效变异体的比例
+	Total    int          `json:"total"`
+	Killed   int          `json:"killed"`
+	Survived int          `json:"survived"`
+	Invalid  int          `json:"invalid"`
+	Files    []FileReport `json:"files"`
+	Results  []Result     `json:"results"`
+}
+
+// NewReport 汇总变异体的测试结果
+func NewReport(results []Result) *Report {
+	report := &Report{Time: time.Now(), Total: len(results), Results: results}
+
+	files := make(map[string]*FileReport)
+	var names []string
+	for _, result := range results {
+		file, ok := files[result.File]
+		if !ok {
+			file = &FileReport{File: result.File}
+			files[result.File] = file
+			names = append(names, result.File)
+		}
+
+		switch result.Status {
+		case Killed:
+			report.Killed++
+			file.Killed++
+		case Survived:
+			report.Survived++
+			file.Survived = append(file.Survived, result)
+		case Invalid:
+			report.Invalid++
+			file.Invalid++
+		}
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		file := files[name]
+		sort.SliceStable(file.Survived, func(i, j int) bool { return file.Survived[i].Line < file.Survived[j].Line })
+		report.Files = append(report.Files, *file)
+	}
+
+	if valid := report.Killed + report.Survived; valid > 0 {
+		report.Score = float64(report.Killed) / float64(valid)
+	}
+	return report
+}
+
+// WriteJSON 把报告写入 JSON 文件
+func (r *Report) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// WriteHTML 把报告写入 HTML 文件
+func (r *Report) WriteHTML(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return htmlReport.Execute(file, r)
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": func(v float64) string { return fmt.Sprintf("%.1f%%", v*100) },
+	"replacement": func(s string) string {
+		if s == "" {
+			return "(deleted)"
+		}
+		return s
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Mutation Report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #f4f4f4; }
+code { white-space: pre; }
+.score { font-size: 1.5em; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Mutation Report</h1>
+<p class="score">Mutation score: {{percent .Score}}</p>
+<p>{{.Total}} mutants: {{.Killed}} killed, {{.Survived}} survived, {{.Invalid}} did not compile. Generated at {{.Time.Format "2006-01-02 15:04:05"}}.</p>
+
+<h2>Files</h2>
+<table>
+<tr><th>File</th><th>Killed</th><th>Survived</th><th>Invalid</th></tr>
+{{range .Files}}<tr><td>{{.File}}</td><td>{{.Killed}}</td><td>{{len .Survived}}</td><td>{{.Invalid}}</td></tr>
+{{end}}</table>
+
+<h2>Surviving mutants</h2>
+{{range .Files}}{{if .Survived}}<h3>{{.File}}</h3>
+<table>
+<tr><th>Line</th><th>Operator</th><th>Original</th><th>Mutant</th></tr>
+{{range .Survived}}<tr><td>{{.Line}}:{{.Column}}</td><td>{{.Operator}}</td><td><code>{{.Original}}</code></td><td><code>{{replacement .Replacement}}</code></td></tr>
+{{end}}</table>
+{{end}}{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,100 @@
+package tester
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+// catch2 适配 Catch2 v3
+type catch2 struct{}
+
+func (catch2) Name() string { return "catch2" }
+
+func (catch2) ListArgs(filter string) []string {
+	return append([]string{"--list-tests", "--reporter", "xml"}, catch2Spec(filter, nil)...)
+}
+
+func (catch2) ParseList(program string, output []byte) []string {
+	var run struct {
+		Tests []struct {
+			Name string `xml:"Name"`
+		} `xml:"MatchingTests>TestCase"`
+	}
+
+	if err := xml.Unmarshal(output, &run); err != nil {
+		return nil
+	}
+
+	var tests []string
+	for _, test := range run.Tests {
+		tests = append(tests, program+"."+strings.TrimSpace(test.Name))
+	}
+	return tests
+}
+
+func (catch2) RunArgs(job Job, junitPath string) ([]string, []string) {
+	args := catch2Spec(job.Filter, job.Tests)
+
+	if junitPath != "" {
+		args = append(args, "--reporter", "junit::out="+junitPath, "--reporter", "console")
+	}
+
+	if job.Shuffle {
+		args = append(args, "--order", "rand")
+	}
+	if job.Seed > 0 {
+		args = append(args, "--rng-seed", strconv.Itoa(job.Seed))
+	}
+
+	if job.Shards > 0 {
+		args = append(args, "--shard-count", strconv.Itoa(job.Shards), "--shard-index", strconv.Itoa(job.Shard))
+	}
+
+	return args, nil
+}
+
+func (catch2) Normalize(program string, cases []Case) []Case { return setSuite(program, cases) }
+
+func (catch2) Shardable() bool { return true }
+
+func (catch2) ParseProgress(line string) (Progress, bool) { return Progress{}, false }
+
+// catch2Spec 把 gtest 过滤器或用例列表转换为 Catch2 的测试规格:
+// ',' 分隔的各项之间为或关系, 同一项中以空格分隔的条件为与关系, '~' 表示排除
+func catch2Spec(filter string, tests []string) []string {
+	var positive, negative []string
+	if len(tests) > 0 {
+		positive = tests
+	} else {
+		positive, negative = splitFilter(filter)
+	}
+
+	var excludes string
+	for _, pattern := range negative {
+		excludes += " ~" + catch2Quote(casePattern(pattern))
+	}
+
+	var specs []string
+	for _, pattern := range positive {
+		name := casePattern(pattern)
+		if name == "*" && excludes == "" {
+			return nil
+		}
+		specs = append(specs, catch2Quote(name)+excludes)
+	}
+
+	if len(specs) == 0 {
+		if excludes == "" {
+			return nil
+		}
+		specs = append(specs, strings.TrimSpace(excludes))
+	}
+
+	return []string{strings.Join(specs, ",")}
+}
+
+func catch2Quote(name string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `,`, `\,`, `[`, `\[`, `]`, `\]`)
+	return `"` + replacer.Replace(name) + `"`
+}
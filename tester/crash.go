@@ -0,0 +1,152 @@
+package tester
+
+import (
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// Crash 描述测试程序的崩溃或超时
+type Crash struct {
+	Test     string  // 崩溃或超时时正在运行的用例, 未知时为空
+	Reason   string  // 例如 "segmentation fault" 或 "timed out after 30s"
+	TimedOut bool    // 是否因超时被结束
+	Frames   []Frame // 从输出中找到的崩溃调用栈
+}
+
+func (c *Crash) Error() string {
+	if c.Test == "" {
+		return c.Reason
+	}
+	return c.Reason + " while running " + c.Test
+}
+
+// Frame 是崩溃调用栈中的一帧
+type Frame struct {
+	Module   string // 可执行文件或动态库路径
+	Offset   string // 模块内的偏移, 例如 0x1a2b
+	Function string // 符号化后的函数名
+	Location string // 符号化后的 file:line
+}
+
+func (f Frame) String() string {
+	if f.Function == "" {
+		return fmt.Sprintf("%s+%s", f.Module, f.Offset)
+	}
+	if f.Location == "" {
+		return fmt.Sprintf("%s+%s in %s", f.Module, f.Offset, f.Function)
+	}
+	return fmt.Sprintf("%s+%s in %s at %s", f.Module, f.Offset, f.Function, f.Location)
+}
+
+var framePatterns = []*regexp.Regexp{
+	// AddressSanitizer 等未符号化的调用栈, 例如 "#0 0x55d4c in  (/path/json_test+0x1a2b)"
+	regexp.MustCompile(`\(([^()\s]*[/\\][^()\s]*)\+(0x[0-9a-fA-F]+)\)`),
+	// glibc backtrace_symbols 的输出, 例如 "/path/json_test(+0x1a2b) [0x55d4c1a2b]"
+	regexp.MustCompile(`([^()\s\[\]]+)\(\+(0x[0-9a-fA-F]+)\)`),
+}
+
+// ParseFrames 从测试程序的输出中找出未符号化的调用栈
+func ParseFrames(output []byte) []Frame {
+	var frames []Frame
+	for _, line := range strings.Split(string(output), "\n") {
+		for _, pattern := range framePatterns {
+			if match := pattern.FindStringSubmatch(line); match != nil {
+				frames = append(frames, Frame{Module: match[1], Offset: match[2]})
+				break
+			}
+		}
+	}
+
+	return frames
+}
+
+// Symbolize 在 Linux 上使用 llvm-symbolizer 或 addr2line 把调用栈中的地址转换为函数名和源码位置。
+// 只处理带有调试信息的模块, 无法符号化的帧保持不变。
+func Symbolize(frames []Frame) []Frame {
+	if runtime.GOOS != "linux" {
+		return frames
+	}
+
+	byModule := make(map[string][]int)
+	for i, frame := range frames {
+		byModule[frame.Module] = append(byModule[frame.Module], i)
+	}
+
+	for module, indexes := range byModule {
+		if !hasDebugInfo(module) {
+			continue
+		}
+
+		var offsets []string
+		for _, i := range indexes {
+			offsets = append(offsets, frames[i].Offset)
+		}
+
+		symbols, err := symbolize(module, offsets)
+		if err != nil {
+			continue
+		}
+		for j, i := range indexes {
+			if j < len(symbols) {
+				frames[i].Function, frames[i].Location = symbols[j][0], symbols[j][1]
+			}
+		}
+	}
+
+	return frames
+}
+
+// hasDebugInfo 判断 ELF 文件是否包含 DWARF 调试信息
+func hasDebugInfo(module string) bool {
+	file, err := elf.Open(module)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	return file.Section(".debug_info") != nil
+}
+
+// symbolize 返回每个偏移对应的函数名和源码位置
+func symbolize(module string, offsets []string) ([][2]string, error) {
+	var cmd *exec.Cmd
+	if path, err := exec.LookPath("llvm-symbolizer"); err == nil {
+		cmd = exec.Command(path, append([]string{"--obj=" + module, "--demangle", "--no-inlines"}, offsets...)...)
+	} else if path, err := exec.LookPath("addr2line"); err == nil {
+		cmd = exec.Command(path, append([]string{"-e", module, "-f", "-C"}, offsets...)...)
+	} else {
+		return nil, fmt.Errorf("neither llvm-symbolizer nor addr2line was found")
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	// 两个工具都为每个地址输出函数名和位置两行, llvm-symbolizer 还会输出空行分隔
+	var lines []string
+	for _, line := range strings.Split(string(bytes.ReplaceAll(out, []byte("\r\n"), []byte("\n"))), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	var symbols [][2]string
+	for i := 0; i+1 < len(lines); i += 2 {
+		function, location := lines[i], lines[i+1]
+		if function == "??" {
+			function = ""
+		}
+		if strings.HasPrefix(location, "??") {
+			location = ""
+		}
+		symbols = append(symbols, [2]string{function, location})
+	}
+
+	return symbols, nil
+}
@@ -0,0 +1,80 @@
+package tester
+
+import (
+	"strconv"
+	"strings"
+)
+
+// doctest 适配 doctest
+type doctest struct{}
+
+func (doctest) Name() string { return "doctest" }
+
+func (doctest) ListArgs(filter string) []string {
+	return append([]string{"--list-test-cases", "--no-version"}, doctestFilter(filter, nil)...)
+}
+
+func (doctest) ParseList(program string, output []byte) []string {
+	var tests []string
+	for _, line := range strings.Split(strings.ReplaceAll(string(output), "\r\n", "\n"), "\n") {
+		name := strings.TrimSpace(line)
+		if name == "" || strings.HasPrefix(name, "[doctest]") || strings.HasPrefix(name, "===") {
+			continue
+		}
+		tests = append(tests, program+"."+name)
+	}
+	return tests
+}
+
+func (doctest) RunArgs(job Job, junitPath string) ([]string, []string) {
+	args := doctestFilter(job.Filter, job.Tests)
+
+	// doctest 只能把结果写入一个输出, 生成 JUnit 时控制台只显示测试程序自身的输出
+	if junitPath != "" {
+		args = append(args, "--reporters=junit", "--out="+junitPath)
+	}
+
+	if job.Shuffle {
+		args = append(args, "--order-by=rand")
+	}
+	if job.Seed > 0 {
+		args = append(args, "--rand-seed="+strconv.Itoa(job.Seed))
+	}
+
+	return args, nil
+}
+
+func (doctest) Normalize(program string, cases []Case) []Case { return setSuite(program, cases) }
+
+func (doctest) Shardable() bool { return false }
+
+func (doctest) ParseProgress(line string) (Progress, bool) { return Progress{}, false }
+
+// doctestFilter 把 gtest 过滤器或用例列表转换为 doctest 的 --test-case 和 --test-case-exclude
+func doctestFilter(filter string, tests []string) []string {
+	var positive, negative []string
+	if len(tests) > 0 {
+		positive = tests
+	} else {
+		positive, negative = splitFilter(filter)
+	}
+
+	escape := strings.NewReplacer(`\`, `\\`, `,`, `\,`)
+	join := func(patterns []string) string {
+		var names []string
+		for _, pattern := range patterns {
+			names = append(names, escape.Replace(casePattern(pattern)))
+		}
+		return strings.Join(names, ",")
+	}
+
+	var args []string
+	if len(positive) > 0 && !(len(positive) == 1 && casePattern(positive[0]) == "*") {
+		args = append(args, "--test-case="+join(positive))
+	}
+	if len(negative) > 0 {
+		args = append(args, "--test-case-exclude="+join(negative))
+	}
+
+	return args
+}
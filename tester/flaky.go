@@ -0,0 +1,138 @@
+package tester
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 用例的稳定性分类
+const (
+	StablePass = "stable-pass"
+	StableFail = "stable-fail"
+	Flaky      = "flaky"
+)
+
+// Iteration 是重复运行中的一轮结果
+type Iteration struct {
+	Seed  int    // 本轮使用的随机种子
+	Cases []Case // 本轮的用例结果
+}
+
+// Classification 是一个用例在多轮运行中的稳定性
+type Classification struct {
+	Name         string  // Suite.Case 形式的测试名
+	Binary       string  // 所属测试程序
+	Class        string  // 稳定性分类
+	Passed       int     // 通过的轮数
+	Failed       int     // 失败的轮数
+	FailingSeeds []int   // 失败时使用的随机种子
+	Time         float64 // 平均耗时（秒）
+}
+
+// FlakyTest 是测试历史中记录的不稳定用例
+type FlakyTest struct {
+	Name         string    `json:"name"`          // Suite.Case 形式的测试名
+	Binary       string    `json:"binary"`        // 所属测试程序
+	Passed       int       `json:"passed"`        // 通过的轮数
+	Failed       int       `json:"failed"`        // 失败的轮数
+	FailingSeeds []int     `json:"failing_seeds"` // 失败时使用的随机种子
+	Time         time.Time `json:"time"`          // 检测时间
+}
+
+// Classify 根据多轮运行的结果把用例分为稳定通过、稳定失败和不稳定三类, 跳过的用例不参与分类
+func Classify(iterations []Iteration) []Classification {
+	var (
+		names   []string
+		results = make(map[string]*Classification)
+	)
+
+	for _, iteration := range iterations {
+		for _, c := range iteration.Cases {
+			if c.Status == StatusSkipped {
+				continue
+			}
+
+			result, ok := results[c.FullName()]
+			if !ok {
+				result = &Classification{Name: c.FullName(), Binary: c.Binary}
+				results[c.FullName()] = result
+				names = append(names, c.FullName())
+			}
+
+			result.Time += c.Time
+			if c.Status == StatusFailed {
+				result.Failed++
+				result.FailingSeeds = append(result.FailingSeeds, iteration.Seed)
+			} else {
+				result.Passed++
+			}
+		}
+	}
+
+	var classifications []Classification
+	for _, name := range names {
+		result := results[name]
+		switch {
+		case result.Failed == 0:
+			result.Class = StablePass
+		case result.Passed == 0:
+			result.Class = StableFail
+		default:
+			result.Class = Flaky
+		}
+		result.Time /= float64(result.Passed + result.Failed)
+		classifications = append(classifications, *result)
+	}
+
+	return classifications
+}
+
+// Case 把分类结果折算为一个用例结果, 只要有一轮失败即记为失败
+func (c Classification) Case() Case {
+	suite, name := SplitName(c.Name)
+	result := Case{Suite: suite, Name: name, Status: StatusPassed, Time: c.Time, Binary: c.Binary}
+	if c.Failed > 0 {
+		result.Status = StatusFailed
+		result.Message = fmt.Sprintf("%s: failed %d of %d runs, seeds %s", c.Class, c.Failed, c.Passed+c.Failed, JoinSeeds(c.FailingSeeds))
+	}
+
+	return result
+}
+
+// JoinSeeds 把随机种子列表格式化为逗号分隔的字符串
+func JoinSeeds(seeds []int) string {
+	var text []string
+	for _, seed := range seeds {
+		text = append(text, fmt.Sprint(seed))
+	}
+	return strings.Join(text, ",")
+}
+
+// AddFlaky 在测试历史中记录不稳定的用例, 同名的旧记录会被替换
+func (h *History) AddFlaky(classifications []Classification) {
+	for _, c := range classifications {
+		if c.Class != Flaky {
+			continue
+		}
+
+		flaky := FlakyTest{
+			Name:         c.Name,
+			Binary:       c.Binary,
+			Passed:       c.Passed,
+			Failed:       c.Failed,
+			FailingSeeds: c.FailingSeeds,
+			Time:         time.Now(),
+		}
+
+		replaced := false
+		for i := range h.Flaky {
+			if h.Flaky[i].Name == c.Name {
+				h.Flaky[i], replaced = flaky, true
+			}
+		}
+		if !replaced {
+			h.Flaky = append(h.Flaky, flaky)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package tester
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Framework 封装不同单元测试框架在列出、过滤、运行和报告上的差异。
+// 过滤器统一使用 gtest 的语法 (Suite.Case 通配符, ':' 分隔, '-' 之后为排除项),
+// 由各框架转换为自己的命令行参数。
+type Framework interface {
+	// Name 返回框架名称
+	Name() string
+
+	// ListArgs 返回列出匹配 filter 的用例时的参数
+	ListArgs(filter string) []string
+
+	// ParseList 解析列出用例的输出, 返回 Suite.Case 形式的测试名
+	ParseList(program string, output []byte) []string
+
+	// RunArgs 返回运行任务时的参数和额外的环境变量, 结果以 JUnit XML 写入 junitPath
+	RunArgs(job Job, junitPath string) (args []string, env []string)
+
+	// Normalize 把从 JUnit XML 解析出的结果转换为 Suite.Case 形式
+	Normalize(program string, cases []Case) []Case
+
+	// Shardable 报告框架是否支持把一个测试程序拆分为多个分片
+	Shardable() bool
+
+	// ParseProgress 解析测试程序输出的一行, 返回用例开始或结束的进度。
+	// 框架不输出逐个用例的进度时总是返回 false。
+	ParseProgress(line string) (Progress, bool)
+}
+
+// 支持的测试框架
+var (
+	GoogleTest Framework = googleTest{}
+	Catch2     Framework = catch2{}
+	Doctest    Framework = doctest{}
+)
+
+// Frameworks 返回所有支持的测试框架名称
+func Frameworks() []string {
+	return []string{GoogleTest.Name(), Catch2.Name(), Doctest.Name()}
+}
+
+// Lookup 根据名称查找测试框架, 名称为空时返回 GoogleTest
+func Lookup(name string) (Framework, error) {
+	switch strings.ToLower(name) {
+	case "", "gtest", "googletest":
+		return GoogleTest, nil
+	case "catch2", "catch":
+		return Catch2, nil
+	case "doctest":
+		return Doctest, nil
+	}
+
+	return nil, fmt.Errorf("unsupported test framework '%s', available: %s", name, strings.Join(Frameworks(), ", "))
+}
+
+// ListTests 列出测试程序中匹配 filter 的测试用例
+func ListTests(framework Framework, program string, filter string) ([]string, error) {
+	out, err := exec.Command(program, framework.ListArgs(filter)...).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return framework.ParseList(ProgramName(program), out), nil
+}
+
+// splitFilter 把 gtest 过滤器拆分为包含和排除的模式
+func splitFilter(filter string) (positive, negative []string) {
+	pos, neg, _ := strings.Cut(filter, "-")
+	for _, pattern := range strings.Split(pos, ":") {
+		if pattern != "" {
+			positive = append(positive, pattern)
+		}
+	}
+	for _, pattern := range strings.Split(neg, ":") {
+		if pattern != "" {
+			negative = append(negative, pattern)
+		}
+	}
+
+	return positive, negative
+}
+
+// casePattern 返回模式中的用例名部分。
+// Catch2 和 doctest 以测试程序名作为套件名, 不带套件名的模式 (例如 "json*") 表示全部用例。
+func casePattern(pattern string) string {
+	if index := strings.Index(pattern, "."); index != -1 {
+		return pattern[index+1:]
+	}
+	return "*"
+}
+
+// setSuite 把结果的套件名统一设置为测试程序名
+func setSuite(program string, cases []Case) []Case {
+	for i := range cases {
+		cases[i].Suite = program
+	}
+	return cases
+}
@@ -0,0 +1,180 @@
+package tester
+
+import (
+	"strconv"
+	"strings"
+)
+
+type googleTest struct{}
+
+func (googleTest) Name() string { return "gtest" }
+
+func (googleTest) ListArgs(filter string) []string {
+	args := []string{"--gtest_list_tests"}
+	if filter != "" {
+		args = append(args, "--gtest_filter="+filter)
+	}
+	return args
+}
+
+func (googleTest) ParseList(program string, output []byte) []string {
+	return ParseTestList(string(output))
+}
+
+func (googleTest) RunArgs(job Job, junitPath string) ([]string, []string) {
+	var args, env []string
+	if len(job.Tests) > 0 {
+		args = append(args, "--gtest_filter="+strings.Join(job.Tests, ":"))
+	} else if job.Filter != "" {
+		args = append(args, "--gtest_filter="+job.Filter)
+	}
+
+	if job.Shuffle {
+		args = append(args, "--gtest_shuffle")
+	}
+	if job.Seed > 0 {
+		args = append(args, "--gtest_random_seed="+strconv.Itoa(job.Seed))
+	}
+
+	// --gtest_output 只能给出一次, 只写 JUnit XML, JSON 报告由合并后的结果生成
+	if junitPath != "" {
+		args = append(args, "--gtest_output=xml:"+junitPath)
+	}
+
+	if job.Shards > 0 {
+		env = append(env,
+			"GTEST_TOTAL_SHARDS="+strconv.Itoa(job.Shards),
+			"GTEST_SHARD_INDEX="+strconv.Itoa(job.Shard),
+		)
+	}
+
+	return args, env
+}
+
+func (googleTest) Normalize(program string, cases []Case) []Case { return cases }
+
+func (googleTest) Shardable() bool { return true }
+
+// gtest 输出中表示用例开始和结束的前缀
+var gtestProgress = []struct {
+	prefix string
+	status string
+}{
+	{"[ RUN      ] ", ""},
+	{"[       OK ] ", StatusPassed},
+	{"[  FAILED  ] ", StatusFailed},
+	{"[  SKIPPED ] ", StatusSkipped},
+}
+
+func (googleTest) ParseProgress(line string) (Progress, bool) {
+	line = strings.TrimRight(line, "\r")
+	for _, p := range gtestProgress {
+		if !strings.HasPrefix(line, p.prefix) {
+			continue
+		}
+
+		// 例如 "Json.parser (12 ms)" 或 "Param/Json.parser/0, where GetParam() = 1 (12 ms)"
+		rest := line[len(p.prefix):]
+		progress := Progress{Status: p.status}
+		if index := strings.LastIndex(rest, " ("); index != -1 && strings.HasSuffix(rest, " ms)") {
+			ms, err := strconv.ParseFloat(rest[index+2:len(rest)-len(" ms)")], 64)
+			if err == nil {
+				progress.Time = ms / 1000
+				rest = rest[:index]
+			}
+		}
+		progress.Test, _, _ = strings.Cut(rest, ",")
+		progress.Test = strings.TrimSpace(progress.Test)
+
+		return progress, progress.Test != ""
+	}
+
+	return Progress{}, false
+}
+
+// ParseTestList 解析 --gtest_list_tests 的输出, 返回 Suite.Case 形式的测试名
+func ParseTestList(output string) []string {
+	var (
+		suite string
+		tests []string
+	)
+
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		// 参数化测试会带有 # GetParam() = ... 注释
+		if index := strings.Index(line, "#"); index != -1 {
+			line = line[:index]
+		}
+
+		name := strings.TrimSpace(line)
+		switch {
+		case name == "":
+
+		case strings.HasPrefix(line, "  "):
+			if suite != "" {
+				tests = append(tests, suite+name)
+			}
+
+		case strings.HasSuffix(name, "."):
+			suite = name
+
+		default:
+			// 例如 "Running main() from gtest_main.cc"
+			suite = ""
+		}
+	}
+
+	return tests
+}
+
+// SplitName 把 Suite.Case 拆分为套件名和用例名
+func SplitName(fullName string) (suite, name string) {
+	index := strings.Index(fullName, ".")
+	if index == -1 {
+		return "", fullName
+	}
+	return fullName[:index], fullName[index+1:]
+}
+
+// MatchFilter 判断测试名是否匹配 gtest 过滤器, 例如 "Json.*:Xml.*-Json.slow"
+func MatchFilter(filter string, fullName string) bool {
+	if filter == "" {
+		return true
+	}
+
+	positive, negative, _ := strings.Cut(filter, "-")
+	if positive == "" {
+		positive = "*"
+	}
+
+	return matchPatterns(positive, fullName) && !matchPatterns(negative, fullName)
+}
+
+func matchPatterns(patterns string, name string) bool {
+	for _, pattern := range strings.Split(patterns, ":") {
+		if pattern != "" && matchGlob(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob 匹配 gtest 的通配符: * 匹配任意字符串, ? 匹配单个字符
+func matchGlob(pattern string, name string) bool {
+	if pattern == "" {
+		return name == ""
+	}
+
+	switch pattern[0] {
+	case '*':
+		for i := 0; i <= len(name); i++ {
+			if matchGlob(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	case '?':
+		return name != "" && matchGlob(pattern[1:], name[1:])
+	default:
+		return name != "" && pattern[0] == name[0] && matchGlob(pattern[1:], name[1:])
+	}
+}
@@ -0,0 +1,126 @@
+package tester
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// 历史记录中保留的最大运行次数
+const maxHistoryRuns = 30
+
+// HistoryRun 记录一次测试运行的结果
+type HistoryRun struct {
+	Time  time.Time `json:"time"`  // 运行时间
+	Cases []Case    `json:"cases"` // 本次运行的用例结果
+}
+
+// History 保存最近若干次测试运行的结果
+type History struct {
+	Runs  []HistoryRun `json:"runs"`            // 按时间从旧到新排列
+	Flaky []FlakyTest  `json:"flaky,omitempty"` // 检测到的不稳定用例
+}
+
+// HistoryEntry 是某个用例在一次运行中的结果
+type HistoryEntry struct {
+	Time time.Time
+	Case Case
+}
+
+// LoadHistory 读取测试历史, 文件不存在时返回空历史
+func LoadHistory(path string) (*History, error) {
+	history := &History{}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, history); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// Save 把测试历史写入 path
+func (h *History) Save(path string) error {
+	data, err := json.MarshalIndent(h, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return writeReport(path, data)
+}
+
+// Add 记录一次运行, 并只保留最近 maxHistoryRuns 次
+func (h *History) Add(cases []Case) {
+	if len(cases) == 0 {
+		return
+	}
+
+	h.Runs = append(h.Runs, HistoryRun{Time: time.Now(), Cases: cases})
+	if len(h.Runs) > maxHistoryRuns {
+		h.Runs = h.Runs[len(h.Runs)-maxHistoryRuns:]
+	}
+}
+
+// latest 返回每个用例最近一次的结果, 保持用例在最近运行中的顺序
+func (h *History) latest() []Case {
+	var (
+		seen   = make(map[string]bool)
+		latest []Case
+	)
+
+	for i := len(h.Runs) - 1; i >= 0; i-- {
+		for _, c := range h.Runs[i].Cases {
+			if !seen[c.FullName()] {
+				seen[c.FullName()] = true
+				latest = append(latest, c)
+			}
+		}
+	}
+
+	return latest
+}
+
+// LastFailed 返回最近一次运行结果为失败的用例
+func (h *History) LastFailed() []Case {
+	var failed []Case
+	for _, c := range h.latest() {
+		if c.Status == StatusFailed {
+			failed = append(failed, c)
+		}
+	}
+
+	return failed
+}
+
+// Durations 返回每个用例最近一次运行的耗时
+func (h *History) Durations() map[string]float64 {
+	durations := make(map[string]float64)
+	for _, c := range h.latest() {
+		if c.Status != StatusSkipped {
+			durations[c.FullName()] = c.Time
+		}
+	}
+
+	return durations
+}
+
+// Of 返回指定用例最近的运行结果, 按时间从新到旧排列
+func (h *History) Of(fullName string) []HistoryEntry {
+	var entries []HistoryEntry
+	for i := len(h.Runs) - 1; i >= 0; i-- {
+		for _, c := range h.Runs[i].Cases {
+			if c.FullName() == fullName {
+				entries = append(entries, HistoryEntry{Time: h.Runs[i].Time, Case: c})
+			}
+		}
+	}
+
+	return entries
+}
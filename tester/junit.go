@@ -0,0 +1,163 @@
+package tester
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 测试用例状态
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// Case 表示单个测试用例的运行结果
+type Case struct {
+	Suite   string  `json:"suite"`             // 测试套件名
+	Name    string  `json:"name"`              // 测试用例名
+	Status  string  `json:"status"`            // 运行状态
+	Time    float64 `json:"time"`              // 耗时（秒）
+	Message string  `json:"message,omitempty"` // 失败或跳过的原因
+	Binary  string  `json:"binary,omitempty"`  // 所属测试程序
+}
+
+// FullName 返回 Suite.Name 形式的完整测试名
+func (c Case) FullName() string {
+	return c.Suite + "." + c.Name
+}
+
+type junitSuites struct {
+	Suites []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name  string      `xml:"name,attr"`
+	Cases []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string         `xml:"name,attr"`
+	ClassName string         `xml:"classname,attr"`
+	Time      string         `xml:"time,attr"`
+	Status    string         `xml:"status,attr"`
+	Result    string         `xml:"result,attr"`
+	Failures  []junitMessage `xml:"failure"`
+	Errors    []junitMessage `xml:"error"`
+	Skipped   *junitMessage  `xml:"skipped"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// ParseJUnitFile 解析 JUnit XML 文件
+func ParseJUnitFile(path string) ([]Case, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseJUnit(bytes.NewReader(data))
+}
+
+// ParseJUnit 解析 JUnit XML, 同时兼容 CTest (--output-junit) 和 gtest (--gtest_output=xml) 的输出
+func ParseJUnit(r io.Reader) ([]Case, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var suites []junitSuite
+	switch root {
+	case "testsuites":
+		var s junitSuites
+		if err := xml.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		suites = s.Suites
+
+	case "testsuite":
+		var s junitSuite
+		if err := xml.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		suites = []junitSuite{s}
+
+	default:
+		return nil, fmt.Errorf("unexpected JUnit root element <%s>", root)
+	}
+
+	var cases []Case
+	for _, suite := range suites {
+		for _, tc := range suite.Cases {
+			cases = append(cases, tc.toCase(suite.Name))
+		}
+	}
+
+	return cases, nil
+}
+
+func rootElement(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", fmt.Errorf("invalid JUnit XML: %w", err)
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func (tc junitCase) toCase(suiteName string) Case {
+	c := Case{Status: StatusPassed}
+
+	// gtest: classname 为套件名; CTest: name 和 classname 都是 Suite.Case
+	switch {
+	case tc.ClassName != "" && tc.ClassName != tc.Name:
+		c.Suite, c.Name = tc.ClassName, tc.Name
+	case strings.Contains(tc.Name, "."):
+		c.Suite, c.Name = SplitName(tc.Name)
+	default:
+		c.Suite, c.Name = suiteName, tc.Name
+	}
+
+	c.Time, _ = strconv.ParseFloat(strings.TrimSuffix(tc.Time, "s"), 64)
+
+	switch {
+	case len(tc.Failures) > 0 || len(tc.Errors) > 0:
+		c.Status = StatusFailed
+		for _, f := range append(tc.Failures, tc.Errors...) {
+			if f.Message != "" {
+				c.Message = f.Message
+				break
+			}
+			c.Message = strings.TrimSpace(f.Text)
+		}
+
+	case tc.Skipped != nil || tc.Status == "notrun" || tc.Status == "disabled" || tc.Result == "skipped" || tc.Result == "suppressed":
+		c.Status = StatusSkipped
+		if tc.Skipped != nil {
+			c.Message = tc.Skipped.Message
+		}
+
+	case tc.Status == "fail" || tc.Status == "failed":
+		c.Status = StatusFailed
+	}
+
+	return c
+}
@@ -0,0 +1,28 @@
+//go:build !windows
+
+package tester
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup 让测试程序在独立的进程组中运行, 以便超时时结束整个进程树
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killTree 结束测试程序及其创建的所有子进程
+func killTree(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// crashReason 判断测试程序是否因信号异常退出, 返回信号的描述
+func crashReason(state *os.ProcessState) (string, bool) {
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return "", false
+	}
+	return status.Signal().String(), true
+}
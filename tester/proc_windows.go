@@ -0,0 +1,42 @@
+//go:build windows
+
+package tester
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// 常见的 Windows 异常退出码
+var exceptionCodes = map[uint32]string{
+	0xC0000005: "access violation",
+	0xC000001D: "illegal instruction",
+	0xC0000094: "integer divide by zero",
+	0xC00000FD: "stack overflow",
+	0xC0000409: "stack buffer overrun",
+	0xC0000374: "heap corruption",
+	0x80000003: "breakpoint",
+}
+
+// setProcessGroup 在 Windows 上不需要, taskkill /T 会结束整个进程树
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killTree 结束测试程序及其创建的所有子进程
+func killTree(cmd *exec.Cmd) error {
+	err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+	if err != nil {
+		return cmd.Process.Kill()
+	}
+	return nil
+}
+
+// crashReason 判断测试程序是否因异常退出, 返回异常的描述
+func crashReason(state *os.ProcessState) (string, bool) {
+	code := uint32(state.ExitCode())
+	if reason, ok := exceptionCodes[code]; ok {
+		return fmt.Sprintf("%s (0x%08X)", reason, code), true
+	}
+	return "", false
+}
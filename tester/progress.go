@@ -0,0 +1,92 @@
+package tester
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+// 为符号化崩溃调用栈保留的最大输出长度
+const maxTail = 64 * 1024
+
+// Progress 是从测试程序输出中解析出的用例进度
+type Progress struct {
+	Test   string  // Suite.Case 形式的测试名
+	Status string  // 用例结束时的状态, 为空表示用例开始运行
+	Time   float64 // 用例耗时（秒）
+}
+
+// monitor 转发测试程序的输出, 并跟踪当前正在运行的用例和已经结束的用例
+type monitor struct {
+	framework Framework
+	out       io.Writer
+
+	mu       sync.Mutex
+	line     []byte    // 尚未读到换行的输出
+	tail     []byte    // 最近的输出
+	running  string    // 正在运行的用例
+	last     time.Time // 最近一次用例开始或结束的时间
+	finished []Case    // 已经结束的用例
+}
+
+func newMonitor(framework Framework, out io.Writer) *monitor {
+	return &monitor{framework: framework, out: out, last: time.Now()}
+}
+
+func (m *monitor) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tail = append(m.tail, p...)
+	if len(m.tail) > maxTail {
+		m.tail = m.tail[len(m.tail)-maxTail:]
+	}
+
+	m.line = append(m.line, p...)
+	for {
+		index := bytes.IndexByte(m.line, '\n')
+		if index == -1 {
+			break
+		}
+		m.handle(string(m.line[:index]))
+		m.line = m.line[index+1:]
+	}
+
+	return m.out.Write(p)
+}
+
+func (m *monitor) handle(line string) {
+	progress, ok := m.framework.ParseProgress(line)
+	if !ok {
+		return
+	}
+
+	switch {
+	case progress.Status == "":
+		m.running = progress.Test
+		m.last = time.Now()
+
+	// 只记录正在运行的用例, 忽略 gtest 结尾汇总中重复列出的失败用例
+	case progress.Test == m.running:
+		suite, name := SplitName(progress.Test)
+		m.finished = append(m.finished, Case{Suite: suite, Name: name, Status: progress.Status, Time: progress.Time})
+		m.running = ""
+		m.last = time.Now()
+	}
+}
+
+// current 返回正在运行的用例, 以及它开始运行的时间。
+// 框架不输出进度时返回程序启动的时间。
+func (m *monitor) current() (string, time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.running, m.last
+}
+
+// output 返回最近的输出
+func (m *monitor) output() []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]byte(nil), m.tail...)
+}
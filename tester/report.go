@@ -0,0 +1,156 @@
+package tester
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Summary 汇总一次测试运行的所有结果
+type Summary struct {
+	Tests   int     `json:"tests"`   // 测试用例总数
+	Passed  int     `json:"passed"`  // 通过数
+	Failed  int     `json:"failed"`  // 失败数
+	Skipped int     `json:"skipped"` // 跳过数
+	Time    float64 `json:"time"`    // 总耗时（秒）
+	Cases   []Case  `json:"cases"`   // 所有测试用例
+}
+
+// Summarize 统计测试结果
+func Summarize(cases []Case) Summary {
+	s := Summary{Tests: len(cases), Cases: cases}
+	for _, c := range cases {
+		switch c.Status {
+		case StatusPassed:
+			s.Passed++
+		case StatusFailed:
+			s.Failed++
+		case StatusSkipped:
+			s.Skipped++
+		}
+		s.Time += c.Time
+	}
+
+	return s
+}
+
+// Slowest 返回耗时最长的 n 个测试用例
+func (s Summary) Slowest(n int) []Case {
+	cases := make([]Case, len(s.Cases))
+	copy(cases, s.Cases)
+	sort.SliceStable(cases, func(i, j int) bool { return cases[i].Time > cases[j].Time })
+
+	if len(cases) > n {
+		cases = cases[:n]
+	}
+	return cases
+}
+
+// Suites 按套件分组, 保持套件首次出现的顺序
+func Suites(cases []Case) (names []string, groups map[string][]Case) {
+	groups = make(map[string][]Case)
+	for _, c := range cases {
+		if _, ok := groups[c.Suite]; !ok {
+			names = append(names, c.Suite)
+		}
+		groups[c.Suite] = append(groups[c.Suite], c)
+	}
+
+	return names, groups
+}
+
+type reportSuites struct {
+	XMLName  xml.Name      `xml:"testsuites"`
+	Name     string        `xml:"name,attr"`
+	Tests    int           `xml:"tests,attr"`
+	Failures int           `xml:"failures,attr"`
+	Skipped  int           `xml:"skipped,attr"`
+	Time     string        `xml:"time,attr"`
+	Suites   []reportSuite `xml:"testsuite"`
+}
+
+type reportSuite struct {
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Cases    []reportCase `xml:"testcase"`
+}
+
+type reportCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure"`
+	Skipped   *junitMessage `xml:"skipped"`
+}
+
+// WriteJUnit 把测试结果合并写入一个 JUnit XML 文件
+func WriteJUnit(path string, cases []Case) error {
+	summary := Summarize(cases)
+	report := reportSuites{
+		Name:     "AllTests",
+		Tests:    summary.Tests,
+		Failures: summary.Failed,
+		Skipped:  summary.Skipped,
+		Time:     formatTime(summary.Time),
+	}
+
+	names, groups := Suites(cases)
+	for _, name := range names {
+		s := Summarize(groups[name])
+		suite := reportSuite{
+			Name:     name,
+			Tests:    s.Tests,
+			Failures: s.Failed,
+			Skipped:  s.Skipped,
+			Time:     formatTime(s.Time),
+		}
+
+		for _, c := range groups[name] {
+			rc := reportCase{Name: c.Name, ClassName: c.Suite, File: c.Binary, Time: formatTime(c.Time)}
+			switch c.Status {
+			case StatusFailed:
+				rc.Failure = &junitMessage{Message: c.Message}
+			case StatusSkipped:
+				rc.Skipped = &junitMessage{Message: c.Message}
+			}
+			suite.Cases = append(suite.Cases, rc)
+		}
+		report.Suites = append(report.Suites, suite)
+	}
+
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeReport(path, append([]byte(xml.Header), data...))
+}
+
+// WriteJSON 把测试结果汇总写入一个 JSON 文件
+func WriteJSON(path string, cases []Case) error {
+	data, err := json.MarshalIndent(Summarize(cases), "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return writeReport(path, data)
+}
+
+func writeReport(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+func formatTime(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
@@ -0,0 +1,214 @@
+package tester
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JobResult 是一个任务的运行结果
+type JobResult struct {
+	Job    Job
+	Cases  []Case
+	Output []byte // 缓冲的标准输出和标准错误
+	Err    error
+	Crash  *Crash // 测试程序崩溃或超时时的信息
+}
+
+// Run 使用 workers 个并发 worker 运行任务, 每个任务结束时以串行方式调用 done。
+// 每个任务的 JUnit XML 结果写入 resultsDir。
+func Run(framework Framework, jobs []Job, workers int, resultsDir string, done func(JobResult)) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		queue = make(chan int)
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range queue {
+				xmlPath := filepath.Join(resultsDir, fmt.Sprintf("%s-%d.xml", ProgramName(jobs[index].Program), index))
+				result := RunJob(framework, jobs[index], xmlPath, nil)
+
+				mu.Lock()
+				done(result)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for index := range jobs {
+		queue <- index
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// 检查超时的时间间隔
+const watchInterval = 100 * time.Millisecond
+
+// RunJob 运行单个任务并解析测试框架写出的 JUnit XML 结果。
+// stream 不为 nil 时测试程序的输出直接写入 stream, 否则缓冲到 JobResult.Output。
+// 用例或测试程序超时时结束整个进程树; 超时或崩溃时 Crash 记录当时正在运行的用例。
+func RunJob(framework Framework, job Job, xmlPath string, stream io.Writer) JobResult {
+	result := JobResult{Job: job}
+
+	os.MkdirAll(filepath.Dir(xmlPath), 0755)
+	os.Remove(xmlPath)
+
+	var output bytes.Buffer
+	out := io.Writer(&output)
+	if stream != nil {
+		out = stream
+	}
+	progress := newMonitor(framework, out)
+
+	args, env := framework.RunArgs(job, xmlPath)
+	cmd := exec.Command(job.Program, args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = progress
+	cmd.Stderr = progress
+	if job.Timeout > 0 || job.BinaryTimeout > 0 {
+		setProcessGroup(cmd)
+	}
+
+	result.Err = cmd.Start()
+	if result.Err == nil {
+		result.Crash, result.Err = wait(cmd, job, progress)
+	}
+	result.Output = output.Bytes()
+
+	if result.Crash != nil {
+		result.Crash.Frames = Symbolize(ParseFrames(progress.output()))
+		result.Err = result.Crash
+	}
+
+	cases, err := ParseJUnitFile(xmlPath)
+	if err != nil {
+		// 程序异常退出时没有 XML 结果, 使用已经结束的用例, 其余用例记为失败
+		result.Cases = crashedCases(job, progress, result.Err)
+		return result
+	}
+
+	cases = framework.Normalize(ProgramName(job.Program), cases)
+	for i := range cases {
+		cases[i].Binary = ProgramName(job.Program)
+	}
+	result.Cases = cases
+
+	return result
+}
+
+// wait 等待测试程序结束, 超时时结束整个进程树
+func wait(cmd *exec.Cmd, job Job, progress *monitor) (*Crash, error) {
+	var (
+		started = time.Now()
+		exited  = make(chan error, 1)
+		ticker  = time.NewTicker(watchInterval)
+	)
+	defer ticker.Stop()
+
+	go func() { exited <- cmd.Wait() }()
+
+	for {
+		select {
+		case err := <-exited:
+			if reason, ok := crashReason(cmd.ProcessState); ok {
+				test, _ := progress.current()
+				return &Crash{Test: test, Reason: reason}, err
+			}
+			return nil, err
+
+		case <-ticker.C:
+			test, since := progress.current()
+
+			var reason string
+			switch {
+			case job.BinaryTimeout > 0 && time.Since(started) > job.BinaryTimeout:
+				reason = fmt.Sprintf("timed out after %s", job.BinaryTimeout)
+			case job.Timeout > 0 && time.Since(since) > job.Timeout:
+				reason = fmt.Sprintf("timed out after %s", job.Timeout)
+			default:
+				continue
+			}
+
+			killTree(cmd)
+			err := <-exited
+			return &Crash{Test: test, Reason: reason, TimedOut: true}, err
+		}
+	}
+}
+
+// crashedCases 返回没有 XML 结果时的用例: 已经结束的用例保留其结果,
+// 正在运行的用例记为失败; 不知道正在运行的用例时把其余用例都记为失败
+func crashedCases(job Job, progress *monitor, err error) []Case {
+	message := "no test results"
+	if err != nil {
+		message = err.Error()
+	}
+
+	test, _ := progress.current()
+	cases := append([]Case(nil), progress.finished...)
+	for i := range cases {
+		cases[i].Binary = ProgramName(job.Program)
+	}
+
+	if test != "" {
+		suite, name := SplitName(test)
+		return append(cases, Case{Suite: suite, Name: name, Status: StatusFailed, Message: message, Binary: ProgramName(job.Program)})
+	}
+
+	if len(cases) > 0 && len(job.Tests) == 0 {
+		return cases
+	}
+
+	finished := make(map[string]bool)
+	for _, c := range cases {
+		finished[c.FullName()] = true
+	}
+	for _, c := range failedCases(job, err) {
+		if !finished[c.FullName()] {
+			cases = append(cases, c)
+		}
+	}
+	return cases
+}
+
+func failedCases(job Job, err error) []Case {
+	message := "no test results"
+	if err != nil {
+		message = err.Error()
+	}
+
+	if len(job.Tests) == 0 {
+		name := "all"
+		if job.Shards > 0 {
+			name = fmt.Sprintf("shard_%d", job.Shard)
+		}
+		return []Case{{Suite: ProgramName(job.Program), Name: name, Status: StatusFailed, Message: message, Binary: ProgramName(job.Program)}}
+	}
+
+	var cases []Case
+	for _, test := range job.Tests {
+		suite, name := SplitName(test)
+		cases = append(cases, Case{Suite: suite, Name: name, Status: StatusFailed, Message: message, Binary: ProgramName(job.Program)})
+	}
+	return cases
+}
+
+// ProgramName 返回不带扩展名的测试程序名
+func ProgramName(program string) string {
+	return strings.TrimSuffix(filepath.Base(program), filepath.Ext(program))
+}
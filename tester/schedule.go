@@ -0,0 +1,134 @@
+package tester
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// 没有历史耗时记录时每个用例的预估耗时（秒）
+const defaultEstimate = 0.1
+
+// Program 表示一个测试程序及其中的测试用例
+type Program struct {
+	Path  string   // 测试程序路径
+	Tests []string // Suite.Case 形式的测试用例
+}
+
+// Job 是调度给一个 worker 的一次测试程序运行
+type Job struct {
+	Program  string   // 测试程序路径
+	Tests    []string // 本次运行的测试用例, 为空时运行 Filter 匹配的全部用例
+	Filter   string   // gtest 语法的过滤器
+	Shard    int      // 分片序号
+	Shards   int      // 分片总数, 0 表示不分片
+	Estimate float64  // 预估耗时（秒）
+	Shuffle  bool     // 是否打乱用例顺序
+	Seed     int      // 打乱顺序使用的随机种子
+
+	// 单个用例的超时时间, 0 表示不限制。框架不输出逐个用例的进度时对整个测试程序生效。
+	Timeout time.Duration
+	// 整个测试程序的超时时间, 0 表示不限制
+	BinaryTimeout time.Duration
+}
+
+// Plan 把测试程序中的用例分配为最多 workers 路并行的任务。
+// durations 为以前运行记录的用例耗时: 有记录时按耗时把用例均衡地分组,
+// 否则使用测试框架自带的分片 (例如 gtest 的 GTEST_TOTAL_SHARDS/GTEST_SHARD_INDEX),
+// 框架不支持分片时按预估耗时分组。
+func Plan(framework Framework, programs []Program, filter string, workers int, durations map[string]float64) []Job {
+	if workers < 1 {
+		workers = 1
+	}
+
+	estimate := func(test string) float64 {
+		if d, ok := durations[test]; ok {
+			return d
+		}
+		return defaultEstimate
+	}
+
+	var total float64
+	for _, p := range programs {
+		for _, test := range p.Tests {
+			total += estimate(test)
+		}
+	}
+	perWorker := total / float64(workers)
+
+	var jobs []Job
+	for _, p := range programs {
+		if len(p.Tests) == 0 {
+			continue
+		}
+
+		var programTotal float64
+		known := false
+		for _, test := range p.Tests {
+			programTotal += estimate(test)
+			if _, ok := durations[test]; ok {
+				known = true
+			}
+		}
+
+		// 只把耗时超过平均负载的测试程序拆分成多个任务
+		parts := 1
+		if perWorker > 0 {
+			parts = int(math.Ceil(programTotal / perWorker))
+		}
+		if parts > workers {
+			parts = workers
+		}
+		if parts > len(p.Tests) {
+			parts = len(p.Tests)
+		}
+		if parts < 1 {
+			parts = 1
+		}
+
+		if !known && framework.Shardable() {
+			for i := 0; i < parts; i++ {
+				job := Job{Program: p.Path, Filter: filter, Estimate: programTotal / float64(parts)}
+				if parts > 1 {
+					job.Shard, job.Shards = i, parts
+				}
+				jobs = append(jobs, job)
+			}
+			continue
+		}
+
+		for _, bucket := range balance(p.Tests, parts, estimate) {
+			job := Job{Program: p.Path, Tests: bucket}
+			for _, test := range bucket {
+				job.Estimate += estimate(test)
+			}
+			jobs = append(jobs, job)
+		}
+	}
+
+	// 先运行耗时长的任务
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].Estimate > jobs[j].Estimate })
+	return jobs
+}
+
+// balance 按耗时从长到短把用例依次放入当前总耗时最小的分组
+func balance(tests []string, parts int, estimate func(string) float64) [][]string {
+	sorted := make([]string, len(tests))
+	copy(sorted, tests)
+	sort.SliceStable(sorted, func(i, j int) bool { return estimate(sorted[i]) > estimate(sorted[j]) })
+
+	buckets := make([][]string, parts)
+	loads := make([]float64, parts)
+	for _, test := range sorted {
+		lightest := 0
+		for i := range loads {
+			if loads[i] < loads[lightest] {
+				lightest = i
+			}
+		}
+		buckets[lightest] = append(buckets[lightest], test)
+		loads[lightest] += estimate(test)
+	}
+
+	return buckets
+}
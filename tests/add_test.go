@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zelviner/cgear/cmake"
+)
+
+func TestFindPackages(t *testing.T) {
+	prefix := t.TempDir()
+	dir := filepath.Join(prefix, "lib", "cmake", "GTest")
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "GTestConfig.cmake"), []byte("include(${CMAKE_CURRENT_LIST_DIR}/GTestTargets.cmake)\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "GTestConfigVersion.cmake"), []byte("set(PACKAGE_VERSION 1.14.0)\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "GTestTargets.cmake"), []byte(`
+foreach(_cmake_expected_target IN ITEMS GTest::gtest GTest::gtest_main GTest::gmock GTest::gmock_main)
+endforeach()
+add_library(GTest::gtest STATIC IMPORTED)
+add_library(GTest::gtest_main STATIC IMPORTED)
+add_library(GTest::gmock STATIC IMPORTED)
+add_library(GTest::gmock_main STATIC IMPORTED)
+add_library(${_IMPORT_PREFIX}::internal STATIC IMPORTED)
+`), 0644)
+	headers := filepath.Join(prefix, "include", "gtest", "gtest.h")
+	os.MkdirAll(filepath.Dir(headers), 0755)
+	os.WriteFile(headers, nil, 0644)
+
+	packages, err := cmake.FindPackages([]string{headers, filepath.Join(dir, "GTestConfig.cmake"), filepath.Join(dir, "GTestConfigVersion.cmake")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []cmake.Package{{Name: "GTest", Targets: []string{"GTest::gmock", "GTest::gmock_main", "GTest::gtest", "GTest::gtest_main"}}}
+	if !reflect.DeepEqual(packages, want) {
+		t.Errorf("FindPackages = %+v, want %+v", packages, want)
+	}
+
+	// 默认不链接提供 main 函数的目标
+	if targets := cmake.DefaultTargets(packages); !reflect.DeepEqual(targets, []string{"GTest::gmock", "GTest::gtest"}) {
+		t.Errorf("DefaultTargets = %v", targets)
+	}
+	mains := []cmake.Package{{Name: "benchmark", Targets: []string{"benchmark::benchmark_main"}}}
+	if targets := cmake.DefaultTargets(mains); !reflect.DeepEqual(targets, []string{"benchmark::benchmark_main"}) {
+		t.Errorf("DefaultTargets of a package with only *_main targets = %v", targets)
+	}
+}
+
+const addLists = `# [1] 基础配置 -----------------------------------------------------
+set(APP_NAME demo)
+
+# [4] 查找依赖 ------------------------------------------------------
+# find_package(fmt CONFIG REQUIRED)
+
+# [5] 添加可执行文件 -------------------------------------------------
+add_executable(${APP_NAME} ${SOURCES})
+
+# [6] 链接依赖库 ----------------------------------------------------
+target_link_libraries(${APP_NAME} PUBLIC
+    # fmt::fmt  # fmt库，用于格式化输出
+)
+`
+
+func TestAddLink(t *testing.T) {
+	project := t.TempDir()
+	os.WriteFile(filepath.Join(project, "CMakeLists.txt"), []byte("project(demo)\nadd_subdirectory(src)\n"), 0644)
+	os.MkdirAll(filepath.Join(project, "src"), 0755)
+	os.MkdirAll(filepath.Join(project, "build", "src"), 0755)
+	lists := filepath.Join(project, "src", "CMakeLists.txt")
+	os.WriteFile(lists, []byte(addLists+"add_library(${APP_NAME}_core INTERFACE)\nadd_library(demo::demo ALIAS ${APP_NAME})\n"), 0644)
+	os.WriteFile(filepath.Join(project, "build", "src", "CMakeLists.txt"), []byte("add_executable(generated main.cpp)\n"), 0644)
+
+	targets, err := cmake.FindListsTargets(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 2 || targets[0].Name != "demo" || targets[0].Ref != "${APP_NAME}" || targets[0].Kind != "executable" ||
+		targets[1].Name != "demo_core" || targets[1].Kind != "interface" {
+		t.Fatalf("FindListsTargets = %+v", targets)
+	}
+
+	original, _ := os.ReadFile(lists)
+	link := cmake.Link{Name: "googletest", Packages: []cmake.Package{{Name: "GTest"}}, Targets: []string{"GTest::gtest"}}
+	if err := cmake.AddLink(targets[0], link); err != nil {
+		t.Fatal(err)
+	}
+	// 再次添加时替换之前写入的行
+	link.Targets = []string{"GTest::gmock", "GTest::gtest"}
+	if err := cmake.AddLink(targets[0], link); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(lists)
+	content := string(data)
+	find := "find_package(GTest CONFIG REQUIRED) # cgear: googletest\n# [5]"
+	linked := "    # fmt::fmt  # fmt库，用于格式化输出\n)\ntarget_link_libraries(${APP_NAME} PRIVATE GTest::gmock GTest::gtest) # cgear: googletest\n"
+	if !strings.Contains(content, find) || !strings.Contains(content, linked) || strings.Count(content, "# cgear: googletest") != 2 {
+		t.Errorf("AddLink wrote:\n%s", content)
+	}
+
+	// 已经链接的目标不重复写入
+	if err := cmake.AddLink(targets[1], cmake.Link{Name: "fmt", Packages: []cmake.Package{{Name: "GTest"}, {Name: "fmt"}}, Targets: []string{"fmt::fmt"}}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(lists)
+	if content := string(data); !strings.Contains(content, "target_link_libraries(${APP_NAME}_core INTERFACE fmt::fmt) # cgear: fmt") ||
+		strings.Contains(content, "find_package(GTest CONFIG REQUIRED) # cgear: fmt") ||
+		!strings.Contains(content, "find_package(fmt CONFIG REQUIRED) # cgear: fmt\n") {
+		t.Errorf("AddLink(fmt) wrote:\n%s", content)
+	}
+
+	for _, name := range []string{"googletest", "fmt"} {
+		changed, err := cmake.RemoveLinks(project, name)
+		if err != nil || len(changed) != 1 || changed[0] != lists {
+			t.Errorf("RemoveLinks(%s) = %v, %v", name, changed, err)
+		}
+	}
+	if data, _ := os.ReadFile(lists); string(data) != string(original) {
+		t.Errorf("RemoveLinks left:\n%s", data)
+	}
+}
+
+const testLists = `find_package(GTest REQUIRED)
+
+function(add_integration_test name)
+    file(GLOB_RECURSE files ${name}/*.cpp)
+    add_executable(${name}_test ${files})
+    target_link_libraries(${name}_test PUBLIC GTest::gtest_main ${ARGN})
+endfunction(add_integration_test name)
+
+# [4] 添加具体测试 --------------------------------------------------
+add_integration_test(demo)
+`
+
+func TestAddLinkHelperTargets(t *testing.T) {
+	project := t.TempDir()
+	os.WriteFile(filepath.Join(project, "CMakeLists.txt"), []byte("set(APP_NAME demo)\nadd_executable(${APP_NAME} main.cpp)\n"), 0644)
+	os.MkdirAll(filepath.Join(project, "test"), 0755)
+	os.MkdirAll(filepath.Join(project, "bench"), 0755)
+	lists := filepath.Join(project, "test", "CMakeLists.txt")
+	os.WriteFile(lists, []byte(testLists), 0644)
+	os.WriteFile(filepath.Join(project, "bench", "CMakeLists.txt"), []byte("add_benchmark(${APP_NAME})\n"), 0644)
+
+	targets, err := cmake.FindListsTargets(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, target := range targets {
+		names = append(names, target.Name+":"+target.Ref+":"+target.Kind)
+	}
+	want := []string{"demo:${APP_NAME}:executable", "demo_bench:${APP_NAME}_bench:executable", "demo_test:demo_test:executable"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("FindListsTargets = %v, want %v", names, want)
+	}
+
+	link := cmake.Link{Name: "fmt", Packages: []cmake.Package{{Name: "GTest"}, {Name: "fmt"}}, Targets: []string{"fmt::fmt"}}
+	if err := cmake.AddLink(targets[2], link); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(lists)
+	wantLists := strings.Replace(testLists, "REQUIRED)\n", "REQUIRED)\nfind_package(fmt CONFIG REQUIRED) # cgear: fmt\n", 1) +
+		"target_link_libraries(demo_test PRIVATE fmt::fmt) # cgear: fmt\n"
+	if string(data) != wantLists {
+		t.Errorf("AddLink wrote:\n%s", data)
+	}
+}
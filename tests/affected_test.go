@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/zelviner/cgear/cmake"
+)
+
+// writeReply 在 buildPath 中写入一个最小的 File API codemodel 回复
+func writeReply(t *testing.T, source, build string) {
+	reply := filepath.Join(build, ".cmake", "api", "v1", "reply")
+	files := map[string]string{
+		"index-2024-01-01T00-00-00-0000.json": `{"objects": [{"kind": "cache", "jsonFile": "cache.json"}]}`,
+		"index-2024-01-02T00-00-00-0000.json": `{"objects": [{"kind": "codemodel", "jsonFile": "codemodel-v2-1.json"}]}`,
+		"codemodel-v2-1.json": `{
+			"paths": {"source": "` + filepath.ToSlash(source) + `", "build": "` + filepath.ToSlash(build) + `"},
+			"configurations": [
+				{"name": "Release", "targets": []},
+				{"name": "Debug", "targets": [
+					{"id": "json::@1", "jsonFile": "target-json.json"},
+					{"id": "app::@2", "jsonFile": "target-app.json"},
+					{"id": "json_test::@3", "jsonFile": "target-json_test.json"},
+					{"id": "util_test::@3", "jsonFile": "target-util_test.json"}
+				]}
+			]
+		}`,
+		"target-json.json": `{"name": "json", "type": "STATIC_LIBRARY",
+			"artifacts": [{"path": "lib/libjson.a"}],
+			"sources": [{"path": "src/json/parser.cpp"}, {"path": "src/json/writer.cpp"}]}`,
+		"target-app.json": `{"name": "app", "type": "EXECUTABLE",
+			"artifacts": [{"path": "` + filepath.ToSlash(filepath.Join(source, "bin", "app.exe")) + `"}],
+			"sources": [{"path": "src/main.cpp"}],
+			"dependencies": [{"id": "json::@1"}]}`,
+		"target-json_test.json": `{"name": "json_test", "type": "EXECUTABLE",
+			"artifacts": [{"path": "` + filepath.ToSlash(filepath.Join(source, "bin", "test", "json_test.exe")) + `"}],
+			"sources": [{"path": "test/json_test.cpp"}],
+			"dependencies": [{"id": "json::@1"}]}`,
+		"target-util_test.json": `{"name": "util_test", "type": "EXECUTABLE",
+			"artifacts": [{"path": "` + filepath.ToSlash(filepath.Join(source, "bin", "test", "util_test.exe")) + `"}],
+			"sources": [{"path": "test/util_test.cpp"}]}`,
+	}
+
+	if err := os.MkdirAll(reply, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(reply, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func targetNames(targets []cmake.Target) []string {
+	var names []string
+	for _, target := range targets {
+		names = append(names, target.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestReadCodemodel(t *testing.T) {
+	source := t.TempDir()
+	build := filepath.Join(source, "build")
+	writeReply(t, source, build)
+
+	targets, err := cmake.ReadCodemodel(build, "Debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 4 {
+		t.Fatalf("ReadCodemodel() returned %d targets, want 4", len(targets))
+	}
+
+	json := targets[0]
+	if json.Name != "json" || json.Type != "STATIC_LIBRARY" {
+		t.Errorf("target = %+v", json)
+	}
+	if want := filepath.Join(source, "src", "json", "parser.cpp"); json.Sources[0] != want {
+		t.Errorf("source = %s, want %s", json.Sources[0], want)
+	}
+	if want := filepath.Join(build, "lib", "libjson.a"); json.Artifacts[0] != want {
+		t.Errorf("artifact = %s, want %s", json.Artifacts[0], want)
+	}
+	if !reflect.DeepEqual(targets[2].Dependencies, []string{"json"}) {
+		t.Errorf("dependencies of json_test = %v", targets[2].Dependencies)
+	}
+
+	if _, err := cmake.ReadCodemodel(t.TempDir(), "Debug"); err == nil {
+		t.Error("ReadCodemodel() without a reply should fail")
+	}
+}
+
+func TestAffectedTargets(t *testing.T) {
+	source := t.TempDir()
+	build := filepath.Join(source, "build")
+	writeReply(t, source, build)
+
+	targets, err := cmake.ReadCodemodel(build, "Debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// util_test 通过头文件依赖 src/util.h
+	targets[3].Sources = append(targets[3].Sources, filepath.Join(source, "src", "util.h"))
+
+	tests := []struct {
+		changed []string
+		want    []string
+	}{
+		{[]string{"src/json/writer.cpp"}, []string{"app", "json", "json_test"}},
+		{[]string{"test/json_test.cpp"}, []string{"json_test"}},
+		{[]string{"src/util.h", "README.md"}, []string{"util_test"}},
+		{[]string{"docs/index.md"}, nil},
+	}
+
+	for _, tt := range tests {
+		var changed []string
+		for _, file := range tt.changed {
+			changed = append(changed, filepath.Join(source, filepath.FromSlash(file)))
+		}
+		if got := targetNames(cmake.Affected(targets, changed)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Affected(%v) = %v, want %v", tt.changed, got, tt.want)
+		}
+	}
+}
+
+func TestParseDepfiles(t *testing.T) {
+	dir := filepath.FromSlash("/project/build/src")
+	depfile := "CMakeFiles/json.dir/parser.cpp.o: /project/src/json/parser.cpp \\\n" +
+		" /project/src/json/parser.h ../../include/my\\ lib.h \\\n" +
+		" /usr/include/c++/12/string\n"
+
+	want := []string{
+		filepath.FromSlash("/project/src/json/parser.cpp"),
+		filepath.FromSlash("/project/src/json/parser.h"),
+		filepath.FromSlash("/project/include/my lib.h"),
+		filepath.FromSlash("/usr/include/c++/12/string"),
+	}
+	if got := cmake.ParseDepfile([]byte(depfile), dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseDepfile() = %q, want %q", got, want)
+	}
+
+	ninja := "CMakeFiles/app.dir/src/main.cpp.o: #deps 2, deps mtime 1700000000 (VALID)\n" +
+		"    ../src/main.cpp\n" +
+		"    ../src/util.h\n" +
+		"\n" +
+		"src/CMakeFiles/json.dir/parser.cpp.o: #deps 1, deps mtime 1700000000 (VALID)\n" +
+		"    /project/src/json/parser.cpp\n" +
+		"\n" +
+		"build.ninja: #deps 0, deps mtime 0 (VALID)\n"
+
+	deps := cmake.ParseNinjaDeps([]byte(ninja), filepath.FromSlash("/project/build"))
+	wantDeps := map[string][]string{
+		"app":  {filepath.FromSlash("/project/src/main.cpp"), filepath.FromSlash("/project/src/util.h")},
+		"json": {filepath.FromSlash("/project/src/json/parser.cpp")},
+	}
+	if !reflect.DeepEqual(deps, wantDeps) {
+		t.Errorf("ParseNinjaDeps() = %q, want %q", deps, wantDeps)
+	}
+}
+
+func TestReadDepfiles(t *testing.T) {
+	build := t.TempDir()
+	objDir := filepath.Join(build, "test", "CMakeFiles", "util_test.dir")
+	if err := os.MkdirAll(objDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	depfile := "CMakeFiles/util_test.dir/util_test.cpp.o: ../../test/util_test.cpp ../../src/util.h\n"
+	if err := os.WriteFile(filepath.Join(objDir, "util_test.cpp.o.d"), []byte(depfile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{filepath.Join(filepath.Dir(build), "test", "util_test.cpp"), filepath.Join(filepath.Dir(build), "src", "util.h")}
+	if got := cmake.ReadDepfiles(build)["util_test"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadDepfiles() = %q, want %q", got, want)
+	}
+}
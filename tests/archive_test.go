@@ -0,0 +1,221 @@
+package tests
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/deps"
+)
+
+// 压缩包中的文件, 都在顶层目录 json-1.0/ 下
+var archiveFiles = map[string]string{
+	"json-1.0/CMakeLists.txt": "project(json)\n",
+	"json-1.0/include/json.h": "#define JSON_VERSION 1\n",
+}
+
+func tarGz(t *testing.T) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range archiveFiles {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func zipFile(t *testing.T) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range archiveFiles {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func sum(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func TestValidateArchives(t *testing.T) {
+	valid := config.Dependency{Name: "json", URL: "https://example.com/json-1.0.tar.gz", SHA256: strings.Repeat("a", 64)}
+	if err := deps.Validate([]config.Dependency{valid}); err != nil {
+		t.Errorf("Validate(%+v): %s", valid, err)
+	}
+
+	for _, dep := range []config.Dependency{
+		{Name: "json", URL: "https://example.com/json-1.0.tar.gz"},
+		{Name: "json", URL: "https://example.com/json-1.0.tar.gz", SHA256: "abc"},
+		{Name: "json", URL: "https://example.com/json-1.0.rar", SHA256: strings.Repeat("a", 64)},
+		{Name: "json", URL: "ftp://example.com/json-1.0.zip", SHA256: strings.Repeat("a", 64)},
+		{Name: "json", URL: "https://example.com/json-1.0.zip", SHA256: strings.Repeat("a", 64), Source: "nlohmann/json"},
+	} {
+		if err := deps.Validate([]config.Dependency{dep}); err == nil {
+			t.Errorf("Validate(%+v) should fail", dep)
+		}
+	}
+}
+
+func TestSourceArchives(t *testing.T) {
+	archives := map[string][]byte{"/json-1.0.tar.gz": tarGz(t), "/json-1.0.zip": zipFile(t)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := archives[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	if _, err := exec.LookPath("xz"); err == nil {
+		dir := t.TempDir()
+		for name, content := range archiveFiles {
+			os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755)
+			os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		}
+		archive := filepath.Join(t.TempDir(), "json-1.0.tar.xz")
+		if output, err := exec.Command("tar", "-cJf", archive, "-C", dir, "json-1.0").CombinedOutput(); err == nil {
+			archives["/json-1.0.tar.xz"], _ = os.ReadFile(archive)
+		} else {
+			t.Logf("tar -cJf: %s", output)
+		}
+	}
+
+	patch := filepath.Join(t.TempDir(), "version.patch")
+	os.WriteFile(patch, []byte(`diff --git a/include/json.h b/include/json.h
+--- a/include/json.h
++++ b/include/json.h
+@@ -1 +1 @@
+-#define JSON_VERSION 1
++#define JSON_VERSION 2
+`), 0644)
+
+	for path, data := range archives {
+		url := server.URL + path
+		dest := filepath.Join(t.TempDir(), "download"+deps.ArchiveExt(url))
+
+		if err := deps.Download(url, strings.Repeat("0", 64), dest, nil); err == nil || !strings.Contains(err.Error(), "sha256 mismatch") {
+			t.Errorf("Download(%s) with a wrong sha256: %v", path, err)
+		}
+		if _, err := os.Stat(dest); !os.IsNotExist(err) {
+			t.Errorf("Download(%s) kept a file that failed verification", path)
+		}
+		if err := deps.Download(url, sum(data), dest, nil); err != nil {
+			t.Fatalf("Download(%s): %s", path, err)
+		}
+
+		dir := filepath.Join(t.TempDir(), "json-1.0")
+		if err := deps.Unpack(dest, dir); err != nil {
+			t.Fatalf("Unpack(%s): %s", path, err)
+		}
+		if err := deps.ApplyPatches(dir, []string{patch}, nil); err != nil {
+			t.Fatalf("ApplyPatches() on %s: %s", path, err)
+		}
+		if content, _ := os.ReadFile(filepath.Join(dir, "include", "json.h")); string(content) != "#define JSON_VERSION 2\n" {
+			t.Errorf("json.h from %s = %q", path, content)
+		}
+	}
+
+	// 依赖图中的压缩包解压到 pkg/<name>-<version>, 版本为压缩包的 sha256
+	installer := &deps.Installer{PkgPath: t.TempDir(), Installed: t.TempDir()}
+	dep := config.Dependency{Name: "json", URL: server.URL + "/json-1.0.tar.gz", SHA256: sum(archives["/json-1.0.tar.gz"]), Version: "1.0"}
+	graph, err := installer.Graph([]config.Dependency{dep}, &deps.Lock{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revision := graph.Packages["json"].Revision; revision != dep.SHA256 {
+		t.Errorf("Revision = %s, want %s", revision, dep.SHA256)
+	}
+	if _, err := os.Stat(filepath.Join(installer.PkgPath, "json-1.0", "CMakeLists.txt")); err != nil {
+		t.Error("json was not unpacked into pkg/json-1.0")
+	}
+
+	locked := deps.Locked{Name: "json", URL: dep.URL, Version: "1.0", Revision: dep.SHA256}
+	if !locked.Matches(dep) {
+		t.Error("locked json does not match its declaration")
+	}
+	dep.SHA256 = strings.Repeat("b", 64)
+	if locked.Matches(dep) {
+		t.Error("locked json matches a different sha256")
+	}
+}
+
+// tarEntries 生成含有 entries 的 tar.gz, Linkname 不为空的是符号链接
+func tarEntries(entries ...tar.Header) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, header := range entries {
+		header := header
+		if header.Linkname != "" {
+			header.Typeflag = tar.TypeSymlink
+		} else {
+			header.Typeflag, header.Mode, header.Size = tar.TypeReg, 0644, int64(len(header.Name))
+		}
+		tw.WriteHeader(&header)
+		if header.Typeflag == tar.TypeReg {
+			tw.Write([]byte(header.Name))
+		}
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestMaliciousArchives(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(root, "outside")
+	os.MkdirAll(outside, 0755)
+
+	for name, entries := range map[string][]tar.Header{
+		"relative link": {{Name: "pkg/escape", Linkname: "../../outside"}, {Name: "pkg/escape/evil.txt"}},
+		"absolute link": {{Name: "pkg/abs", Linkname: outside}, {Name: "pkg/abs/evil.txt"}},
+		"nested link":   {{Name: "pkg/a/b/up", Linkname: "../../../.."}, {Name: "pkg/a/b/up/outside/evil.txt"}},
+	} {
+		archive := filepath.Join(root, "evil.tar.gz")
+		os.WriteFile(archive, tarEntries(entries...), 0644)
+		if err := deps.Unpack(archive, filepath.Join(root, "unpacked")); err == nil || !strings.Contains(err.Error(), "symbolic link") {
+			t.Errorf("%s: Unpack = %v", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(outside, "evil.txt")); err == nil {
+			t.Fatalf("%s: file written outside of the directory", name)
+		}
+	}
+
+	// 指向目录之内的链接保留
+	archive := filepath.Join(root, "lib.tar.gz")
+	os.WriteFile(archive, tarEntries(tar.Header{Name: "lib/libfoo.so.1"}, tar.Header{Name: "lib/libfoo.so", Linkname: "libfoo.so.1"}), 0644)
+	dir := filepath.Join(root, "lib")
+	if err := deps.Unpack(archive, dir); err != nil {
+		t.Fatal(err)
+	}
+	if link, err := os.Readlink(filepath.Join(dir, "libfoo.so")); err != nil || link != "libfoo.so.1" {
+		t.Errorf("Readlink = %s, %v", link, err)
+	}
+
+	// 复制到安装目录时也检查链接
+	sdk := filepath.Join(root, "sdk")
+	os.MkdirAll(filepath.Join(sdk, "include"), 0755)
+	os.Symlink(outside, filepath.Join(sdk, "include", "outside"))
+	if _, err := deps.InstallPrebuilt(t.TempDir(), &deps.Prebuilt{Name: "sdk", Include: filepath.Join(sdk, "include")}); err == nil || !strings.Contains(err.Error(), "symbolic link") {
+		t.Errorf("InstallPrebuilt = %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zelviner/cgear/bencher"
+)
+
+var baselineJSON = `{
+  "context": {"date": "2026-10-01T10:00:00+08:00", "num_cpus": 8},
+  "benchmarks": [
+    {"name": "BM_Parse", "run_name": "BM_Parse", "run_type": "iteration", "iterations": 1000, "real_time": 100, "cpu_time": 99, "time_unit": "us"},
+    {"name": "BM_Parse", "run_name": "BM_Parse", "run_type": "iteration", "iterations": 1000, "real_time": 102, "cpu_time": 101, "time_unit": "us"},
+    {"name": "BM_Parse_mean", "run_name": "BM_Parse", "run_type": "aggregate", "aggregate_name": "mean", "repetitions": 2, "iterations": 2, "real_time": 101, "cpu_time": 100, "time_unit": "us"},
+    {"name": "BM_Dump", "run_name": "BM_Dump", "run_type": "iteration", "iterations": 5000, "real_time": 20, "cpu_time": 20, "time_unit": "ns"},
+    {"name": "BM_Copy", "run_name": "BM_Copy", "run_type": "iteration", "iterations": 5000, "real_time": 10, "cpu_time": 10, "time_unit": "ns"},
+    {"name": "BM_Old", "run_name": "BM_Old", "run_type": "iteration", "iterations": 10, "real_time": 1, "cpu_time": 1, "time_unit": "ms"}
+  ]
+}`
+
+var currentJSON = `{
+  "benchmarks": [
+    {"name": "BM_Parse_mean", "run_name": "BM_Parse", "run_type": "aggregate", "aggregate_name": "mean", "repetitions": 2, "iterations": 2, "real_time": 0.121, "cpu_time": 0.120, "time_unit": "ms"},
+    {"name": "BM_Parse_stddev", "run_name": "BM_Parse", "run_type": "aggregate", "aggregate_name": "stddev", "repetitions": 2, "iterations": 2, "real_time": 0.001, "cpu_time": 0.001, "time_unit": "ms"},
+    {"name": "BM_Dump", "iterations": 5000, "real_time": 15, "cpu_time": 15, "time_unit": "ns"},
+    {"name": "BM_Copy", "iterations": 5000, "real_time": 10.2, "cpu_time": 10.2, "time_unit": "ns"},
+    {"name": "BM_New", "iterations": 5000, "real_time": 3, "cpu_time": 3, "time_unit": "ns"}
+  ]
+}`
+
+func TestBenchStats(t *testing.T) {
+	report, err := bencher.Parse(strings.NewReader(baselineJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := report.Stats()
+	if len(stats) != 4 {
+		t.Fatalf("Stats() returned %d benchmarks, want 4", len(stats))
+	}
+
+	parse := stats[0]
+	if parse.Name != "BM_Parse" || parse.Samples != 2 || parse.Mean != 101000 || parse.StdDev < 1414 || parse.StdDev > 1415 {
+		t.Errorf("BM_Parse = %+v", parse)
+	}
+}
+
+func TestBenchCompare(t *testing.T) {
+	baseline, err := bencher.Parse(strings.NewReader(baselineJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := bencher.Parse(strings.NewReader(currentJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"BM_Parse": bencher.Slower,    // +19.8%, 超过噪声
+		"BM_Dump":  bencher.Faster,    // -25%
+		"BM_Copy":  bencher.Unchanged, // +2%, 低于阈值
+		"BM_New":   bencher.Added,
+		"BM_Old":   bencher.Removed,
+	}
+
+	deltas := bencher.Compare(baseline.Stats(), current.Stats(), 0.05)
+	if len(deltas) != len(want) {
+		t.Fatalf("Compare() = %+v", deltas)
+	}
+	for _, delta := range deltas {
+		if delta.Status != want[delta.Name] {
+			t.Errorf("%s: status %s, want %s (change %.3f)", delta.Name, delta.Status, want[delta.Name], delta.Change)
+		}
+	}
+}
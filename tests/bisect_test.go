@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/zelviner/cgear/bisector"
+)
+
+// gitRepo 创建一个仓库, 每个元素是一个提交中写入的文件
+func gitRepo(t *testing.T, commits []string) (string, []string) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+	var shas []string
+	for i, file := range commits {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(file), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, dir, "add", file)
+		runGit(t, dir, "commit", "-q", "-m", "commit "+string(rune('a'+i)))
+		shas = append(shas, runGit(t, dir, "rev-parse", "HEAD"))
+	}
+	return dir, shas
+}
+
+func TestBisect(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the step scripts use sh")
+	}
+
+	dir, shas := gitRepo(t, []string{"a", "b", "c", "bad", "d", "e"})
+	result, err := bisector.Run(dir, shas[0], shas[5], []string{"sh", "-c", "test ! -f bad"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FirstBad != shas[3] {
+		t.Errorf("FirstBad = %s, want %s", result.FirstBad, shas[3])
+	}
+
+	if branch := runGit(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); branch != "main" {
+		t.Errorf("HEAD after bisect = %s, want main", branch)
+	}
+
+	commit, err := bisector.Describe(dir, result.FirstBad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit.Subject != "commit d" || commit.Author != "tester <tester@example.com>" {
+		t.Errorf("Describe() = %+v", commit)
+	}
+}
+
+func TestBisectSkip(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the step scripts use sh")
+	}
+
+	// 引入 bad 的提交无法构建, 第一个坏提交只能确定在两个提交之中
+	dir, shas := gitRepo(t, []string{"a", "broken", "bad", "fixed", "e"})
+	script := "if test -f broken && ! test -f fixed; then exit 125; fi; test ! -f bad"
+	result, err := bisector.Run(dir, shas[0], shas[4], []string{"sh", "-c", script}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.FirstBad != "" || len(result.Candidates) != 3 {
+		t.Fatalf("result = %+v, want the skipped commits and the first tested bad commit", result)
+	}
+	for i, sha := range result.Candidates {
+		found := false
+		for _, want := range shas[1:4] {
+			found = found || sha == want
+		}
+		if !found {
+			t.Errorf("candidate %d = %s is not between the good and bad commits", i, sha)
+		}
+	}
+
+	// 有未提交的改动时拒绝开始
+	os.WriteFile(filepath.Join(dir, "a"), []byte("changed"), 0644)
+	if _, err := bisector.Run(dir, shas[0], shas[4], []string{"true"}, io.Discard); err == nil {
+		t.Error("Run() with uncommitted changes should fail")
+	}
+}
+
+func TestBisectExitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the commands use sh")
+	}
+
+	tests := []struct {
+		script string
+		want   int
+	}{
+		{"exit 0", bisector.Good},
+		{"exit 3", 3},
+		{"exit 125", bisector.Skip},
+		{"exit 200", bisector.Bad},
+		{"kill -SEGV $$", bisector.Bad},
+	}
+	for _, tt := range tests {
+		if got := bisector.ExitCode(exec.Command("sh", "-c", tt.script).Run()); got != tt.want {
+			t.Errorf("ExitCode(%q) = %d, want %d", tt.script, got, tt.want)
+		}
+	}
+
+	if got := bisector.ExitCode(errors.New("not started")); got != bisector.Skip {
+		t.Errorf("ExitCode(start error) = %d, want %d", got, bisector.Skip)
+	}
+}
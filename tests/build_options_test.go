@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/deps"
+)
+
+func TestValidateBuildOptions(t *testing.T) {
+	dep := config.Dependency{Name: "fmt", Source: "fmtlib:fmt", BuildTypeOptions: map[string]map[string]string{"Debug": {"FMT_TEST": "ON"}}}
+	if err := deps.Validate([]config.Dependency{dep}); err != nil {
+		t.Errorf("Validate: %s", err)
+	}
+
+	dep.BuildTypeOptions = map[string]map[string]string{"RelWithDebInfo": {"FMT_TEST": "ON"}}
+	if err := deps.Validate([]config.Dependency{dep}); err == nil || !strings.Contains(err.Error(), "RelWithDebInfo") {
+		t.Errorf("Validate accepted an unknown build type: %v", err)
+	}
+
+	dep = config.Dependency{Name: "lua", Source: "lua:lua", Build: []string{"make"}, Components: []string{"dev"}}
+	if err := deps.Validate([]config.Dependency{dep}); err == nil {
+		t.Error("Validate accepted components with build commands")
+	}
+}
+
+func TestBuildInvalidation(t *testing.T) {
+	patch := filepath.Join(t.TempDir(), "fix.patch")
+	os.WriteFile(patch, []byte("first"), 0644)
+
+	dep := config.Dependency{Name: "fmt", Source: "fmtlib:fmt", Options: map[string]string{"FMT_TEST": "OFF"}}
+	key := func(dep config.Dependency, buildType string) string {
+		return deps.CacheKey(dep, "abc", "gcc", "x64-linux", buildType, nil)
+	}
+	if deps.BuildHash(dep) != "" {
+		t.Error("BuildHash of a dependency with only options is not empty")
+	}
+
+	record := deps.NewRecord(dep)
+	debug, release := key(dep, "Debug"), key(dep, "Release")
+
+	// 只覆盖 Debug 的选项不影响 Release 的构建
+	dep.BuildTypeOptions = map[string]map[string]string{"Debug": {"FMT_TEST": "ON"}}
+	if key(dep, "Debug") == debug || key(dep, "Release") != release {
+		t.Error("Debug options changed the wrong cache keys")
+	}
+	if record.Satisfies(dep) {
+		t.Error("record satisfies changed build type options")
+	}
+
+	dep.BuildTypeOptions = nil
+	dep.Patches = []string{patch}
+	record = deps.NewRecord(dep)
+	before := key(dep, "Release")
+	os.WriteFile(patch, []byte("second"), 0644)
+	if record.Satisfies(dep) || key(dep, "Release") == before {
+		t.Error("changing a patch did not invalidate the build")
+	}
+
+	record = deps.NewRecord(dep)
+	dep.Build = []string{"make"}
+	if record.Satisfies(dep) || key(dep, "Release") == key(config.Dependency{Name: "fmt", Source: "fmtlib:fmt", Patches: dep.Patches}, "Release") {
+		t.Error("build commands did not invalidate the build")
+	}
+}
+
+func TestCustomBuild(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("build commands use sh")
+	}
+
+	root := t.TempDir()
+	commitPackage(t, filepath.Join(root, "lib"), map[string]string{"lib.h": "#define LIB 1\n"})
+
+	dep := config.Dependency{
+		Name:             "lib",
+		Source:           filepath.Join(root, "lib"),
+		Options:          map[string]string{"LIB_NAME": "lib"},
+		BuildTypeOptions: map[string]map[string]string{"Debug": {"LIB_NAME": "libd"}},
+		Build: []string{
+			"mkdir -p $CGEAR_INSTALL_DIR/include $CGEAR_INSTALL_DIR/lib/pkgconfig",
+			"cp lib.h $CGEAR_INSTALL_DIR/include/",
+			"echo $CGEAR_BUILD_TYPE > $CGEAR_INSTALL_DIR/lib/$LIB_NAME.a",
+			"echo prefix=$CGEAR_INSTALL_DIR > $CGEAR_INSTALL_DIR/lib/pkgconfig/lib.pc",
+		},
+	}
+
+	installer := &deps.Installer{PkgPath: t.TempDir(), Installed: t.TempDir(), LogPath: t.TempDir()}
+	record, done, err := installer.Install(dep, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !done {
+		t.Fatal("Install skipped a new dependency")
+	}
+
+	prefix := installer.Prefix()
+	want := []string{"debug/include/lib.h", "debug/lib/libd.a", "debug/lib/pkgconfig/lib.pc", "include/lib.h", "lib/lib.a", "lib/pkgconfig/lib.pc"}
+	if strings.Join(record.Files, " ") != strings.Join(want, " ") {
+		t.Errorf("Files = %v, want %v", record.Files, want)
+	}
+	if data, _ := os.ReadFile(filepath.Join(prefix, "debug", "lib", "libd.a")); string(data) != "Debug\n" {
+		t.Errorf("debug/lib/libd.a = %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(prefix, "lib", "pkgconfig", "lib.pc")); string(data) != "prefix="+prefix+"\n" {
+		t.Errorf("lib.pc was not relocated: %q", data)
+	}
+
+	if _, done, err := installer.Install(dep, ""); err != nil || done {
+		t.Errorf("Install of an unchanged dependency: done %v, %v", done, err)
+	}
+	dep.Build = append(dep.Build, "touch $CGEAR_INSTALL_DIR/include/extra.h")
+	if _, done, err := installer.Install(dep, ""); err != nil || !done {
+		t.Errorf("Install after changing the build commands: done %v, %v", done, err)
+	}
+	if _, err := os.Stat(filepath.Join(prefix, "include", "extra.h")); err != nil {
+		t.Error(err)
+	}
+
+	dep.Build = []string{"exit 3"}
+	if _, _, err := installer.Install(dep, ""); err == nil || !strings.Contains(err.Error(), "exit 3") {
+		t.Errorf("Install with a failing command: %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/deps"
+)
+
+// fileServer 是一个最简单的支持 GET、PUT、DELETE 和目录列表的 HTTP 服务器
+func fileServer(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	files := make(map[string][]byte)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		name := strings.TrimPrefix(r.URL.Path, "/cache/")
+		switch r.Method {
+		case http.MethodGet:
+			if name == "" {
+				for name := range files {
+					io.WriteString(w, `<a href="`+name+`">`+name+"</a>\n")
+				}
+				return
+			}
+			data, ok := files[name]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(data)
+		case http.MethodPut:
+			data, _ := io.ReadAll(r.Body)
+			files[name] = data
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodDelete:
+			delete(files, name)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCacheKey(t *testing.T) {
+	dep := config.Dependency{Name: "fmt", Source: "fmtlib/fmt", Options: map[string]string{"FMT_TEST": "OFF"}}
+	key := deps.CacheKey(dep, "abc", "clang 17", "x64-linux", "Release", []string{"zlib@1"})
+	if !strings.HasPrefix(key, "fmt-release-") {
+		t.Errorf("CacheKey() = %s", key)
+	}
+
+	changed := []string{
+		deps.CacheKey(dep, "abd", "clang 17", "x64-linux", "Release", []string{"zlib@1"}),
+		deps.CacheKey(dep, "abc", "clang 18", "x64-linux", "Release", []string{"zlib@1"}),
+		deps.CacheKey(dep, "abc", "clang 17", "x86-linux", "Release", []string{"zlib@1"}),
+		deps.CacheKey(dep, "abc", "clang 17", "x64-linux", "Debug", []string{"zlib@1"}),
+		deps.CacheKey(dep, "abc", "clang 17", "x64-linux", "Release", []string{"zlib@2"}),
+		deps.CacheKey(config.Dependency{Name: "fmt", Options: map[string]string{"FMT_TEST": "ON"}}, "abc", "clang 17", "x64-linux", "Release", []string{"zlib@1"}),
+	}
+	for i, other := range changed {
+		if other == key {
+			t.Errorf("key %d did not change", i)
+		}
+	}
+}
+
+func TestCacheStores(t *testing.T) {
+	server := fileServer(t)
+
+	for _, location := range []string{filepath.Join(t.TempDir(), "cache"), server.URL + "/cache"} {
+		cache := deps.NewCache(location)
+
+		prefix := t.TempDir()
+		os.MkdirAll(filepath.Join(prefix, "include"), 0755)
+		os.MkdirAll(filepath.Join(prefix, "lib", "cmake"), 0755)
+		os.WriteFile(filepath.Join(prefix, "include", "fmt.h"), []byte("#pragma once"), 0644)
+		os.WriteFile(filepath.Join(prefix, "lib", "libfmt.so.10"), []byte("library"), 0755)
+		os.WriteFile(filepath.Join(prefix, "lib", "cmake", "fmt-targets.cmake"), []byte(`set(_IMPORT_PREFIX "`+filepath.ToSlash(prefix)+`")`), 0644)
+		files := []string{"include/fmt.h", "lib/libfmt.so.10", "lib/cmake/fmt-targets.cmake"}
+		if runtime.GOOS != "windows" {
+			os.Symlink("libfmt.so.10", filepath.Join(prefix, "lib", "libfmt.so"))
+			files = append(files, "lib/libfmt.so")
+		}
+
+		if entry, err := cache.Restore("fmt-release-1", prefix); entry != nil || err != nil {
+			t.Fatalf("Restore() of a missing key = %+v, %v", entry, err)
+		}
+
+		entry := &deps.CacheEntry{Key: "fmt-release-1", Name: "fmt", Revision: "abc", BuildType: "Release", Files: files}
+		if err := cache.Save(entry, prefix); err != nil {
+			t.Fatalf("Save() to %s: %s", location, err)
+		}
+		if err := cache.Save(&deps.CacheEntry{Key: "bad", Files: []string{"../outside"}}, prefix); err == nil {
+			t.Error("Save() of files outside of the prefix should fail")
+		}
+
+		entries, err := cache.Entries()
+		if err != nil || len(entries) != 1 || entries[0].Key != "fmt-release-1" || entries[0].Size == 0 {
+			t.Fatalf("Entries() of %s = %+v, %v", location, entries, err)
+		}
+
+		target := t.TempDir()
+		restored, err := cache.Restore("fmt-release-1", target)
+		if err != nil || restored == nil || len(restored.Files) != len(files) {
+			t.Fatalf("Restore() from %s = %+v, %v", location, restored, err)
+		}
+		if data, _ := os.ReadFile(filepath.Join(target, "lib", "libfmt.so.10")); string(data) != "library" {
+			t.Errorf("restored library = %q", data)
+		}
+		if data, _ := os.ReadFile(filepath.Join(target, "lib", "cmake", "fmt-targets.cmake")); !strings.Contains(string(data), filepath.ToSlash(target)) {
+			t.Errorf("fmt-targets.cmake was not relocated: %s", data)
+		}
+		if runtime.GOOS != "windows" {
+			if link, err := os.Readlink(filepath.Join(target, "lib", "libfmt.so")); err != nil || link != "libfmt.so.10" {
+				t.Errorf("restored symlink = %s, %v", link, err)
+			}
+		}
+
+		if err := cache.Remove("fmt-release-1"); err != nil {
+			t.Fatal(err)
+		}
+		if entries, _ := cache.Entries(); len(entries) != 0 {
+			t.Errorf("Entries() after Remove() = %+v", entries)
+		}
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache-stats.json")
+	deps.AddCacheStats(path, deps.CacheStats{Hits: 2, Misses: 1})
+	deps.AddCacheStats(path, deps.CacheStats{Hits: 1, Uploads: 1})
+
+	stats, err := deps.ReadCacheStats(path)
+	if err != nil || stats.Hits != 3 || stats.Misses != 1 || stats.Uploads != 1 || stats.Since.IsZero() {
+		t.Errorf("ReadCacheStats() = %+v, %v", stats, err)
+	}
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/zelviner/cgear/tester"
+)
+
+func TestGtestProgress(t *testing.T) {
+	cases := []struct {
+		line     string
+		progress tester.Progress
+		ok       bool
+	}{
+		{"[ RUN      ] Json.parser", tester.Progress{Test: "Json.parser"}, true},
+		{"[       OK ] Json.parser (12 ms)", tester.Progress{Test: "Json.parser", Status: tester.StatusPassed, Time: 0.012}, true},
+		{"[  FAILED  ] Param/Json.parser/0, where GetParam() = 1 (3 ms)\r", tester.Progress{Test: "Param/Json.parser/0", Status: tester.StatusFailed, Time: 0.003}, true},
+		{"[  SKIPPED ] Json.slow (0 ms)", tester.Progress{Test: "Json.slow", Status: tester.StatusSkipped}, true},
+		{"[----------] 2 tests from Json", tester.Progress{}, false},
+	}
+
+	for _, c := range cases {
+		progress, ok := tester.GoogleTest.ParseProgress(c.line)
+		if ok != c.ok || progress != c.progress {
+			t.Errorf("ParseProgress(%q) = %+v, %v, want %+v, %v", c.line, progress, ok, c.progress, c.ok)
+		}
+	}
+}
+
+func TestParseFrames(t *testing.T) {
+	output := []byte(`==1234==ERROR: AddressSanitizer: SEGV on unknown address 0x000000000000
+    #0 0x55d4c1a2b in  (/work/bin/test/json_test+0x1a2b)
+    #1 0x7f00112233 in __libc_start_main (/lib/x86_64-linux-gnu/libc.so.6+0x29d90)
+/work/bin/test/json_test(+0x3c4d) [0x55d4c3c4d]
+/work/bin/test/json_test(main+0x12) [0x55d4c3c60]
+`)
+
+	want := []tester.Frame{
+		{Module: "/work/bin/test/json_test", Offset: "0x1a2b"},
+		{Module: "/lib/x86_64-linux-gnu/libc.so.6", Offset: "0x29d90"},
+		{Module: "/work/bin/test/json_test", Offset: "0x3c4d"},
+	}
+
+	frames := tester.ParseFrames(output)
+	if len(frames) != len(want) {
+		t.Fatalf("ParseFrames() = %+v, want %+v", frames, want)
+	}
+	for i := range want {
+		if frames[i] != want[i] {
+			t.Errorf("frame %d = %+v, want %+v", i, frames[i], want[i])
+		}
+	}
+}
+
+// writeScript 写出一个模拟 gtest 输出的测试程序
+func writeScript(t *testing.T, body string) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not supported on windows")
+	}
+
+	path := filepath.Join(t.TempDir(), "hang_test.exe")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunJobTimeout(t *testing.T) {
+	program := writeScript(t, `echo "[ RUN      ] Json.parser"
+echo "[       OK ] Json.parser (1 ms)"
+echo "[ RUN      ] Json.hang"
+sleep 30 &
+wait
+`)
+
+	job := tester.Job{Program: program, Timeout: 300 * time.Millisecond}
+	started := time.Now()
+	result := tester.RunJob(tester.GoogleTest, job, filepath.Join(t.TempDir(), "result.xml"), nil)
+
+	if elapsed := time.Since(started); elapsed > 10*time.Second {
+		t.Fatalf("RunJob() took %s, the process tree was not killed", elapsed)
+	}
+	if result.Crash == nil || !result.Crash.TimedOut || result.Crash.Test != "Json.hang" {
+		t.Fatalf("Crash = %+v, want timeout in Json.hang", result.Crash)
+	}
+
+	if len(result.Cases) != 2 {
+		t.Fatalf("Cases = %+v, want 2 cases", result.Cases)
+	}
+	if result.Cases[0].FullName() != "Json.parser" || result.Cases[0].Status != tester.StatusPassed {
+		t.Errorf("Cases[0] = %+v, want passed Json.parser", result.Cases[0])
+	}
+	if result.Cases[1].FullName() != "Json.hang" || result.Cases[1].Status != tester.StatusFailed {
+		t.Errorf("Cases[1] = %+v, want failed Json.hang", result.Cases[1])
+	}
+}
+
+func TestRunJobCrash(t *testing.T) {
+	program := writeScript(t, `echo "[ RUN      ] Json.crash"
+kill -SEGV $$
+`)
+
+	result := tester.RunJob(tester.GoogleTest, tester.Job{Program: program}, filepath.Join(t.TempDir(), "result.xml"), nil)
+	if result.Crash == nil || result.Crash.TimedOut || result.Crash.Test != "Json.crash" {
+		t.Fatalf("Crash = %+v, want crash in Json.crash", result.Crash)
+	}
+	if result.Err == nil || result.Err.Error() != "segmentation fault while running Json.crash" {
+		t.Errorf("Err = %v", result.Err)
+	}
+}
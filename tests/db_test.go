@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zelviner/cgear/deps"
+)
+
+func TestPackageDatabase(t *testing.T) {
+	prefix := t.TempDir()
+	install := func(record *deps.Record) {
+		for _, file := range record.Files {
+			path := filepath.Join(prefix, filepath.FromSlash(file))
+			os.MkdirAll(filepath.Dir(path), 0755)
+			os.WriteFile(path, []byte(record.Name), 0644)
+		}
+		if err := deps.WriteRecord(prefix, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	install(&deps.Record{Name: "zlib", Files: []string{"include/zlib.h", "include/zconf.h", "lib/libz.a"}})
+	install(&deps.Record{Name: "png", Dependencies: []string{"zlib"}, Files: []string{"include/png/png.h", "lib/libpng.a", "share/png/pngConfig.cmake"}})
+
+	records, err := deps.List(prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Name != "png" || records[1].Name != "zlib" {
+		t.Fatalf("List() = %+v", records)
+	}
+
+	if dependents, _ := deps.Dependents(prefix, "zlib"); len(dependents) != 1 || dependents[0] != "png" {
+		t.Errorf("Dependents(zlib) = %v", dependents)
+	}
+	if err := deps.Uninstall(prefix, "zlib"); err == nil {
+		t.Fatal("Uninstall() removed a package that png depends on")
+	}
+	if err := deps.Uninstall(prefix, "json"); err == nil {
+		t.Error("Uninstall() of a missing package should fail")
+	}
+
+	if err := deps.Uninstall(prefix, "png"); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"include/png", "lib/libpng.a", "share", ".cgear/png.json"} {
+		if _, err := os.Stat(filepath.Join(prefix, path)); !os.IsNotExist(err) {
+			t.Errorf("%s was not removed", path)
+		}
+	}
+	for _, path := range []string{"include/zlib.h", "include/zconf.h", "lib/libz.a", ".cgear/zlib.json"} {
+		if _, err := os.Stat(filepath.Join(prefix, path)); err != nil {
+			t.Errorf("%s of zlib was removed", path)
+		}
+	}
+
+	if err := deps.Uninstall(prefix, "zlib"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(prefix); err != nil {
+		t.Error("Uninstall() removed the prefix")
+	}
+	if records, _ := deps.List(prefix); len(records) != 0 {
+		t.Errorf("List() after uninstall = %+v", records)
+	}
+}
+
+func TestInstalledFilesOutsidePrefix(t *testing.T) {
+	root := t.TempDir()
+	prefix := filepath.Join(root, "x64-windows")
+	buildPath := filepath.Join(root, "build")
+	os.MkdirAll(buildPath, 0755)
+
+	manifest := filepath.ToSlash(filepath.Join(prefix, "include", "foo.h")) + "\n" + filepath.ToSlash(filepath.Join(prefix, "debug", "lib", "foo.lib")) + "\n"
+	os.WriteFile(filepath.Join(buildPath, "install_manifest.txt"), []byte(manifest), 0644)
+	if files, err := deps.InstalledFiles(buildPath, prefix); err != nil || len(files) != 2 || files[0] != "include/foo.h" || files[1] != "debug/lib/foo.lib" {
+		t.Errorf("InstalledFiles() = %v, %v", files, err)
+	}
+
+	// 安装到 prefix 之外的文件不能被记录
+	outside := filepath.Join(root, "usr", "local", "include", "foo.h")
+	os.WriteFile(filepath.Join(buildPath, "install_manifest.txt"), []byte(manifest+filepath.ToSlash(outside)+"\n"), 0644)
+	if _, err := deps.InstalledFiles(buildPath, prefix); err == nil {
+		t.Error("InstalledFiles() accepted a file outside of the prefix")
+	}
+
+	// 旧的记录中 prefix 之外的路径在卸载时被跳过
+	os.MkdirAll(filepath.Dir(outside), 0755)
+	os.WriteFile(outside, nil, 0644)
+	escaped := filepath.Join(root, "escaped.h")
+	os.WriteFile(escaped, nil, 0644)
+	if err := deps.WriteRecord(prefix, &deps.Record{Name: "foo", Files: []string{filepath.ToSlash(outside), "../escaped.h"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := deps.Uninstall(prefix, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{outside, escaped} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Uninstall() removed %s outside of the prefix", path)
+		}
+	}
+}
+
+func TestUninstallSharedFiles(t *testing.T) {
+	prefix := t.TempDir()
+	for _, file := range []string{"bin/zlib1.dll", "debug/bin/zlib1.dll", "include/foo/foo.h", "include/bar/bar.h"} {
+		path := filepath.Join(prefix, filepath.FromSlash(file))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, nil, 0644)
+	}
+	deps.WriteRecord(prefix, &deps.Record{Name: "foo", Files: []string{"include/foo/foo.h", "bin/zlib1.dll", "debug/bin/zlib1.dll"}})
+	deps.WriteRecord(prefix, &deps.Record{Name: "bar", Files: []string{"include/bar/bar.h", "bin/zlib1.dll", "debug/bin/zlib1.dll"}})
+
+	// 两个包都复制到 bin 中的 DLL 在卸载其中一个之后保留
+	if err := deps.Uninstall(prefix, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	for path, kept := range map[string]bool{"include/foo": false, "bin/zlib1.dll": true, "debug/bin/zlib1.dll": true, "include/bar/bar.h": true} {
+		if _, err := os.Stat(filepath.Join(prefix, path)); (err == nil) != kept {
+			t.Errorf("%s kept = %v, want %v", path, err == nil, kept)
+		}
+	}
+
+	if err := deps.Uninstall(prefix, "bar"); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(prefix); len(entries) != 1 || entries[0].Name() != ".cgear" {
+		t.Errorf("uninstall left %v", entries)
+	}
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/deps"
+)
+
+func TestReadDependencies(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "cgear.json")
+	yamlPath := filepath.Join(dir, "Cgearfile")
+
+	os.WriteFile(jsonPath, []byte(`{
+		"build_type": "Debug",
+		"dependencies": [
+			{"name": "fmt", "source": "fmtlib:fmt", "version": "10.2.1", "options": {"FMT_TEST": "OFF"}},
+			{"name": "boost", "source": "boostorg:boost", "components": ["filesystem", "system"]}
+		]
+	}`), 0644)
+	os.WriteFile(yamlPath, []byte(`build_type: Debug
+dependencies:
+  - name: fmt
+    source: fmtlib:fmt
+    version: 10.2.1
+    options:
+      FMT_TEST: "OFF"
+  - name: boost
+    source: boostorg:boost
+    components: [filesystem, system]
+`), 0644)
+
+	want := []config.Dependency{
+		{Name: "fmt", Source: "fmtlib:fmt", Version: "10.2.1", Options: map[string]string{"FMT_TEST": "OFF"}},
+		{Name: "boost", Source: "boostorg:boost", Components: []string{"filesystem", "system"}},
+	}
+
+	for _, path := range []string{jsonPath, yamlPath} {
+		conf, err := config.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile(%s): %s", filepath.Base(path), err)
+		}
+		if !reflect.DeepEqual(conf.Dependencies, want) {
+			t.Errorf("dependencies in %s = %+v, want %+v", filepath.Base(path), conf.Dependencies, want)
+		}
+	}
+}
+
+func TestValidateDependencies(t *testing.T) {
+	tests := []struct {
+		dependencies []config.Dependency
+		err          string
+	}{
+		{[]config.Dependency{{Name: "fmt", Source: "fmtlib:fmt"}, {Name: "gtest", Source: "google:googletest"}}, ""},
+		{[]config.Dependency{{Name: "", Source: "fmtlib:fmt"}}, "invalid name"},
+		{[]config.Dependency{{Name: "../fmt", Source: "fmtlib:fmt"}}, "invalid name"},
+		{[]config.Dependency{{Name: "fmt", Source: "fmtlib:fmt"}, {Name: "fmt", Source: "fmtlib:fmt"}}, "more than once"},
+		{[]config.Dependency{{Name: "fmt"}}, "no source"},
+		{[]config.Dependency{{Name: "fmt", Source: "fmt"}}, "invalid source"},
+	}
+
+	for _, tt := range tests {
+		err := deps.Validate(tt.dependencies)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("Validate(%+v) = %s", tt.dependencies, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("Validate(%+v) = %v, want an error containing %q", tt.dependencies, err, tt.err)
+		}
+	}
+}
+
+func TestInstallRecord(t *testing.T) {
+	prefix := filepath.Join(t.TempDir(), deps.Triplet("x64"))
+	dep := config.Dependency{Name: "fmt", Source: "fmtlib:fmt", Version: "10.2.1", Options: map[string]string{"FMT_TEST": "OFF"}}
+
+	if _, err := deps.ReadRecord(prefix, "fmt"); err == nil {
+		t.Fatal("ReadRecord() of a package that is not installed should fail")
+	}
+	if err := deps.WriteRecord(prefix, deps.NewRecord(dep)); err != nil {
+		t.Fatal(err)
+	}
+
+	record, err := deps.ReadRecord(prefix, "fmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !record.Satisfies(dep) {
+		t.Errorf("record %+v does not satisfy %+v", record, dep)
+	}
+
+	changed := dep
+	changed.Version = "11.0.0"
+	if record.Satisfies(changed) {
+		t.Error("record satisfies a different version")
+	}
+
+	changed = dep
+	changed.Options = map[string]string{"FMT_TEST": "ON"}
+	if record.Satisfies(changed) {
+		t.Error("record satisfies different options")
+	}
+
+	// 没有选项时 nil 和空表等价
+	plain := config.Dependency{Name: "zlib", Source: "madler:zlib", Options: map[string]string{}}
+	deps.WriteRecord(prefix, deps.NewRecord(config.Dependency{Name: "zlib", Source: "madler:zlib"}))
+	if record, _ := deps.ReadRecord(prefix, "zlib"); record == nil || !record.Satisfies(plain) {
+		t.Errorf("record %+v does not satisfy %+v", record, plain)
+	}
+
+	if !strings.HasPrefix(deps.Triplet("x86"), "x86-") {
+		t.Errorf("Triplet(x86) = %s", deps.Triplet("x86"))
+	}
+}
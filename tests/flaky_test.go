@@ -0,0 +1,49 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/zelviner/cgear/tester"
+)
+
+func TestClassify(t *testing.T) {
+	iterations := []tester.Iteration{
+		{Seed: 11, Cases: []tester.Case{
+			{Suite: "Json", Name: "parser", Status: tester.StatusPassed},
+			{Suite: "Json", Name: "array", Status: tester.StatusFailed},
+			{Suite: "Json", Name: "order", Status: tester.StatusPassed},
+		}},
+		{Seed: 22, Cases: []tester.Case{
+			{Suite: "Json", Name: "parser", Status: tester.StatusPassed},
+			{Suite: "Json", Name: "array", Status: tester.StatusFailed},
+			{Suite: "Json", Name: "order", Status: tester.StatusFailed},
+		}},
+	}
+
+	classifications := tester.Classify(iterations)
+	if len(classifications) != 3 {
+		t.Fatalf("expected 3 classifications, got %d", len(classifications))
+	}
+
+	expected := map[string]string{
+		"Json.parser": tester.StablePass,
+		"Json.array":  tester.StableFail,
+		"Json.order":  tester.Flaky,
+	}
+	for _, c := range classifications {
+		if c.Class != expected[c.Name] {
+			t.Errorf("%s: expected %s, got %s", c.Name, expected[c.Name], c.Class)
+		}
+	}
+
+	flaky := classifications[2]
+	if len(flaky.FailingSeeds) != 1 || flaky.FailingSeeds[0] != 22 {
+		t.Errorf("unexpected failing seeds: %v", flaky.FailingSeeds)
+	}
+
+	history := &tester.History{}
+	history.AddFlaky(classifications)
+	if len(history.Flaky) != 1 || history.Flaky[0].Name != "Json.order" {
+		t.Errorf("unexpected flaky history: %+v", history.Flaky)
+	}
+}
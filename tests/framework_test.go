@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zelviner/cgear/tester"
+)
+
+var catch2List = `<?xml version="1.0" encoding="UTF-8"?>
+<Catch2TestRun name="json_test" rng-seed="1234" catch2-version="3.5.2">
+  <MatchingTests>
+    <TestCase>
+      <Name>parses arrays</Name>
+      <ClassName/>
+      <Tags>[json]</Tags>
+    </TestCase>
+    <TestCase>
+      <Name>parses 1.5 floats</Name>
+      <ClassName/>
+      <Tags>[json][float]</Tags>
+    </TestCase>
+  </MatchingTests>
+</Catch2TestRun>`
+
+var doctestList = `[doctest] doctest version is "2.4.11"
+[doctest] run with "--help" for options
+===============================================================================
+[doctest] listing all test case names
+===============================================================================
+parses arrays
+parses objects
+===============================================================================
+[doctest] unskipped test cases passing the current filters: 2
+`
+
+func TestLookupFramework(t *testing.T) {
+	for name, expected := range map[string]string{"": "gtest", "GoogleTest": "gtest", "catch2": "catch2", "doctest": "doctest"} {
+		framework, err := tester.Lookup(name)
+		if err != nil || framework.Name() != expected {
+			t.Errorf("Lookup(%q) = %v, %v", name, framework, err)
+		}
+	}
+
+	if _, err := tester.Lookup("boost"); err == nil {
+		t.Error("expected an error for an unsupported framework")
+	}
+}
+
+func TestCatch2Adapter(t *testing.T) {
+	tests := tester.Catch2.ParseList("json_test", []byte(catch2List))
+	expected := []string{"json_test.parses arrays", "json_test.parses 1.5 floats"}
+	if !reflect.DeepEqual(tests, expected) {
+		t.Errorf("expected %v, got %v", expected, tests)
+	}
+
+	args, _ := tester.Catch2.RunArgs(tester.Job{Filter: "json_test.parses*-json_test.parses 1.5 floats", Shuffle: true, Seed: 7}, "out.xml")
+	expectedArgs := []string{`"parses*" ~"parses 1.5 floats"`, "--reporter", "junit::out=out.xml", "--reporter", "console", "--order", "rand", "--rng-seed", "7"}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected %q, got %q", expectedArgs, args)
+	}
+
+	cases := tester.Catch2.Normalize("json_test", []tester.Case{{Suite: "json_test.global", Name: "parses arrays"}})
+	if cases[0].FullName() != "json_test.parses arrays" {
+		t.Errorf("unexpected case: %+v", cases[0])
+	}
+}
+
+func TestDoctestAdapter(t *testing.T) {
+	tests := tester.Doctest.ParseList("json_test", []byte(doctestList))
+	expected := []string{"json_test.parses arrays", "json_test.parses objects"}
+	if !reflect.DeepEqual(tests, expected) {
+		t.Errorf("expected %v, got %v", expected, tests)
+	}
+
+	args, _ := tester.Doctest.RunArgs(tester.Job{Tests: []string{"json_test.parses arrays", "json_test.a,b"}}, "out.xml")
+	expectedArgs := []string{`--test-case=parses arrays,a\,b`, "--reporters=junit", "--out=out.xml"}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("expected %q, got %q", expectedArgs, args)
+	}
+
+	// 不带用例名的过滤器表示全部用例
+	if args := tester.Doctest.ListArgs("json*"); len(args) != 2 {
+		t.Errorf("unexpected list args: %q", args)
+	}
+}
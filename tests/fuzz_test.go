@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/zelviner/cgear/fuzzer"
+)
+
+func TestReplay(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not supported on windows")
+	}
+
+	dir := t.TempDir()
+	corpus := filepath.Join(dir, "corpus")
+	crashes := filepath.Join(dir, "crashes")
+	os.MkdirAll(corpus, 0755)
+	os.MkdirAll(crashes, 0755)
+	os.WriteFile(filepath.Join(corpus, "a"), []byte("ok"), 0644)
+	os.WriteFile(filepath.Join(corpus, "b"), []byte("ok"), 0644)
+	os.WriteFile(filepath.Join(crashes, "crash-1"), []byte("boom"), 0644)
+
+	// 模拟 fuzz 程序: 任一输入内容为 boom 时崩溃
+	program := filepath.Join(dir, "parser_fuzz.exe")
+	os.WriteFile(program, []byte(`#!/bin/sh
+for input in "$@"; do
+    if [ "$(cat "$input")" = "boom" ]; then
+        echo "deadly signal in $input"
+        exit 1
+    fi
+done
+`), 0755)
+
+	inputs := fuzzer.Inputs(corpus, crashes, filepath.Join(dir, "missing"))
+	if len(inputs) != 3 {
+		t.Fatalf("Inputs() = %v, want 3 inputs", inputs)
+	}
+
+	results := fuzzer.Replay(program, inputs)
+	if len(results) != 3 {
+		t.Fatalf("Replay() returned %d results, want 3", len(results))
+	}
+	for _, result := range results {
+		crashed := filepath.Base(result.Input) == "crash-1"
+		if result.Passed == crashed {
+			t.Errorf("%s: passed = %v", result.Input, result.Passed)
+		}
+		if crashed && len(result.Output) == 0 {
+			t.Errorf("%s: missing output", result.Input)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/deps"
+)
+
+// runGit 在 dir 中以固定的作者和提交者运行 git, 失败时结束测试, 返回去掉首尾空白的输出
+func runGit(t *testing.T, dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=tester", "GIT_AUTHOR_EMAIL=tester@example.com",
+		"GIT_COMMITTER_NAME=tester", "GIT_COMMITTER_EMAIL=tester@example.com")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %s", strings.Join(args, " "), output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// commitPackage 把 files 写入 dir 并提交, dir 不存在时创建
+func commitPackage(t *testing.T, dir string, files map[string]string) {
+	os.MkdirAll(dir, 0755)
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		runGit(t, dir, "init", "-q", "-b", "main")
+	}
+	for name, content := range files {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "package")
+}
+
+// manifest 返回声明了 dependencies 的 cgear.json
+func manifest(dependencies ...config.Dependency) map[string]string {
+	data, _ := json.Marshal(config.Config{Dependencies: dependencies})
+	return map[string]string{"cgear.json": string(data)}
+}
+
+func TestDependencyGraph(t *testing.T) {
+	root := t.TempDir()
+	path := func(name string) string { return filepath.Join(root, name) }
+	dep := func(name string) config.Dependency { return config.Dependency{Name: name, Source: path(name)} }
+
+	commitPackage(t, path("zlib"), map[string]string{"zlib.h": ""})
+	commitPackage(t, path("zlib-ng"), map[string]string{"zlib.h": ""})
+	commitPackage(t, path("png"), manifest(dep("zlib")))
+	commitPackage(t, path("fmt"), map[string]string{"vcpkg.json": `{"name": "fmt", "dependencies": [{"name": "vcpkg-cmake", "host": true}]}`})
+	commitPackage(t, path("tiff"), map[string]string{"vcpkg.json": `{"name": "tiff", "dependencies": ["zlib", {"name": "png"}]}`})
+
+	installer := &deps.Installer{PkgPath: t.TempDir(), Installed: t.TempDir()}
+	graph, err := installer.Graph([]config.Dependency{dep("tiff"), dep("png"), dep("fmt")}, &deps.Lock{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order := strings.Join(graph.Order, " "); order != "zlib png tiff fmt" {
+		t.Errorf("Order = %s", order)
+	}
+	if pkg := graph.Packages["zlib"]; pkg == nil || pkg.DeclaredBy != "png" || len(pkg.Revision) != 40 {
+		t.Errorf("zlib = %+v", pkg)
+	}
+
+	var text, dot bytes.Buffer
+	graph.WriteText(&text, "app")
+	graph.WriteDOT(&dot, "app")
+	if !strings.Contains(text.String(), "    └── zlib ") || !strings.Contains(text.String(), "Install order: zlib, png, tiff, fmt") {
+		t.Errorf("WriteText() =\n%s", text.String())
+	}
+	if !strings.Contains(dot.String(), `"png" -> "zlib";`) || !strings.Contains(dot.String(), `"app" -> "tiff";`) {
+		t.Errorf("WriteDOT() =\n%s", dot.String())
+	}
+
+	// vcpkg.json 中的依赖没有来源
+	if _, err := installer.Graph([]config.Dependency{dep("tiff")}, &deps.Lock{}, false); err == nil || !strings.Contains(err.Error(), "has no source") {
+		t.Errorf("Graph() without a source for zlib: %v", err)
+	}
+
+	// 两个包声明了不同来源的 zlib, 项目自己声明 zlib 时以项目为准
+	other := config.Dependency{Name: "zlib", Source: path("zlib-ng")}
+	commitPackage(t, path("curl"), manifest(other))
+	if _, err := installer.Graph([]config.Dependency{dep("png"), dep("curl")}, &deps.Lock{}, false); err == nil || !strings.Contains(err.Error(), "'zlib' is required as") {
+		t.Errorf("Graph() with conflicting versions: %v", err)
+	}
+	graph, err = installer.Graph([]config.Dependency{dep("png"), dep("curl"), other}, &deps.Lock{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if graph.Packages["zlib"].Dependency.Source != other.Source {
+		t.Errorf("zlib = %+v, want the declaration of the project", graph.Packages["zlib"])
+	}
+
+	// a -> b -> a
+	commitPackage(t, path("a"), manifest(dep("b")))
+	commitPackage(t, path("b"), manifest(dep("a")))
+	if _, err := installer.Graph([]config.Dependency{dep("a")}, &deps.Lock{}, false); err == nil || !strings.Contains(err.Error(), "dependency cycle: a -> b -> a") {
+		t.Errorf("Graph() with a cycle: %v", err)
+	}
+}
+
+func TestGraphSkipsInstalledPackages(t *testing.T) {
+	root := t.TempDir()
+	path := func(name string) string { return filepath.Join(root, name) }
+	dep := func(name string) config.Dependency { return config.Dependency{Name: name, Source: path(name)} }
+
+	commitPackage(t, path("zlib"), map[string]string{"zlib.h": ""})
+	commitPackage(t, path("png"), manifest(dep("zlib")))
+
+	installer := &deps.Installer{PkgPath: t.TempDir(), Installed: t.TempDir()}
+	graph, err := installer.Graph([]config.Dependency{dep("png")}, &deps.Lock{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := &deps.Lock{}
+	for _, name := range graph.Order {
+		pkg := graph.Packages[name]
+		lock.Set(deps.Locked{Name: name, Source: pkg.Dependency.Source, Revision: pkg.Revision})
+		record := deps.NewRecord(pkg.Dependency)
+		record.Revision, record.Dependencies = pkg.Revision, pkg.Requires
+		if name == "png" {
+			record.Requires = []config.Dependency{dep("zlib")}
+		}
+		if err := deps.WriteRecord(installer.Prefix(), record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 源码不可用时, 已经以锁定的提交安装的包从记录中读取依赖
+	for _, dir := range []string{path("zlib"), path("png"), installer.PkgPath} {
+		os.RemoveAll(dir)
+	}
+	graph, err = installer.Graph([]config.Dependency{dep("png")}, lock, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(graph.Order, ",") != "zlib,png" || graph.Packages["png"].Path != "" || graph.Packages["png"].Revision != lock.Find("png").Revision {
+		t.Errorf("Graph() of installed packages: order %v, png %+v", graph.Order, graph.Packages["png"])
+	}
+
+	// 没有保存依赖声明的旧记录仍然需要下载源码
+	record, _ := deps.ReadRecord(installer.Prefix(), "png")
+	record.Requires = nil
+	deps.WriteRecord(installer.Prefix(), record)
+	if _, err := installer.Graph([]config.Dependency{dep("png")}, lock, true); err == nil || !strings.Contains(err.Error(), "failed to download png") {
+		t.Errorf("Graph() with a record without declarations: %v", err)
+	}
+}
+
+func TestSyncCountsTransitivePackages(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("build commands use sh")
+	}
+
+	root := t.TempDir()
+	path := func(name string) string { return filepath.Join(root, name) }
+	dep := func(name string) config.Dependency {
+		return config.Dependency{Name: name, Source: path(name), Build: []string{"mkdir -p $CGEAR_INSTALL_DIR/include", "touch $CGEAR_INSTALL_DIR/include/" + name + ".h"}}
+	}
+
+	commitPackage(t, path("zlib"), nil)
+	commitPackage(t, path("fmt"), nil)
+	commitPackage(t, path("png"), manifest(dep("zlib"), dep("fmt")))
+
+	installer := &deps.Installer{Toolchain: &config.Toolchain{}, PkgPath: t.TempDir(), Installed: t.TempDir()}
+	lock := &deps.Lock{}
+	installed, upToDate, err := installer.Sync([]config.Dependency{dep("png")}, lock, false)
+	if err != nil || installed != 3 || upToDate != 0 {
+		t.Fatalf("Sync() = %d installed, %d up to date, %v", installed, upToDate, err)
+	}
+	if installed, upToDate, err = installer.Sync([]config.Dependency{dep("png")}, lock, true); err != nil || installed != 0 || upToDate != 3 {
+		t.Errorf("second Sync() = %d installed, %d up to date, %v", installed, upToDate, err)
+	}
+}
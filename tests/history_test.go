@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/zelviner/cgear/tester"
+)
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".cgear", "test-history.json")
+
+	history, err := tester.LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history.Add([]tester.Case{
+		{Suite: "Json", Name: "parser", Status: tester.StatusFailed, Time: 0.2, Binary: "json_test"},
+		{Suite: "Json", Name: "array", Status: tester.StatusFailed, Time: 0.1, Binary: "json_test"},
+	})
+	history.Add([]tester.Case{
+		{Suite: "Json", Name: "parser", Status: tester.StatusPassed, Time: 0.3, Binary: "json_test"},
+	})
+
+	if err := history.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err = tester.LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failed := history.LastFailed()
+	if len(failed) != 1 || failed[0].FullName() != "Json.array" {
+		t.Errorf("unexpected last failed tests: %+v", failed)
+	}
+
+	entries := history.Of("Json.parser")
+	if len(entries) != 2 || entries[0].Case.Status != tester.StatusPassed || entries[1].Case.Status != tester.StatusFailed {
+		t.Errorf("unexpected history: %+v", entries)
+	}
+
+	if durations := history.Durations(); durations["Json.parser"] != 0.3 || durations["Json.array"] != 0.1 {
+		t.Errorf("unexpected durations: %+v", durations)
+	}
+}
+
+func TestMatchFilter(t *testing.T) {
+	cases := []struct {
+		filter string
+		name   string
+		match  bool
+	}{
+		{"*", "Json.parser", true},
+		{"Json*", "Json.parser", true},
+		{"Json.parser", "Json.array", false},
+		{"Json.*:Xml.*", "Xml.node", true},
+		{"Json.*-Json.slow", "Json.slow", false},
+		{"-Json.slow", "Xml.node", true},
+		{"Json.p?rser", "Json.parser", true},
+	}
+
+	for _, c := range cases {
+		if tester.MatchFilter(c.filter, c.name) != c.match {
+			t.Errorf("MatchFilter(%q, %q) != %v", c.filter, c.name, c.match)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zelviner/cgear/tester"
+)
+
+var ctestJUnit = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="Windows" tests="3" failures="1" disabled="0" skipped="1" hostname="" time="0" timestamp="2024-03-14T10:00:00">
+	<testcase name="Json.parser" classname="Json.parser" time="0.012" status="run">
+		<system-out>ok</system-out>
+	</testcase>
+	<testcase name="Json.array" classname="Json.array" time="0.020" status="fail">
+		<failure message="Failed"/>
+	</testcase>
+	<testcase name="Ftp.connect" classname="Ftp.connect" time="0" status="notrun">
+		<skipped message="Disabled"/>
+	</testcase>
+</testsuite>`
+
+var gtestJUnit = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="2" failures="1" disabled="0" errors="0" time="0.01" name="AllTests">
+  <testsuite name="Xml" tests="2" failures="1" disabled="0" skipped="0" errors="0" time="0.01">
+    <testcase name="parser" status="run" result="completed" time="0.004" classname="Xml" />
+    <testcase name="node" status="run" result="completed" time="0.006" classname="Xml">
+      <failure message="xml_test.cpp:12&#x0A;Expected equality" type=""><![CDATA[xml_test.cpp:12]]></failure>
+    </testcase>
+  </testsuite>
+</testsuites>`
+
+func TestParseCTestJUnit(t *testing.T) {
+	cases, err := tester.ParseJUnit(strings.NewReader(ctestJUnit))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cases) != 3 {
+		t.Fatalf("expected 3 cases, got %d", len(cases))
+	}
+
+	expected := []tester.Case{
+		{Suite: "Json", Name: "parser", Status: tester.StatusPassed},
+		{Suite: "Json", Name: "array", Status: tester.StatusFailed},
+		{Suite: "Ftp", Name: "connect", Status: tester.StatusSkipped},
+	}
+	for i, c := range expected {
+		if cases[i].Suite != c.Suite || cases[i].Name != c.Name || cases[i].Status != c.Status {
+			t.Errorf("case %d: expected %+v, got %+v", i, c, cases[i])
+		}
+	}
+}
+
+func TestParseGTestJUnit(t *testing.T) {
+	cases, err := tester.ParseJUnit(strings.NewReader(gtestJUnit))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cases) != 2 {
+		t.Fatalf("expected 2 cases, got %d", len(cases))
+	}
+
+	if cases[0].FullName() != "Xml.parser" || cases[0].Status != tester.StatusPassed {
+		t.Errorf("unexpected case: %+v", cases[0])
+	}
+
+	if cases[1].Status != tester.StatusFailed || !strings.Contains(cases[1].Message, "Expected equality") {
+		t.Errorf("unexpected case: %+v", cases[1])
+	}
+
+	if cases[1].Time != 0.006 {
+		t.Errorf("expected time 0.006, got %v", cases[1].Time)
+	}
+}
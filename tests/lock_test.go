@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/deps"
+)
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), deps.LockFile)
+
+	lock, err := deps.ReadLock(path)
+	if err != nil || len(lock.Packages) != 0 {
+		t.Fatalf("ReadLock() of a missing file = %+v, %v", lock, err)
+	}
+
+	lock.Set(deps.Locked{Name: "zlib", Source: "madler:zlib", Revision: "1111111"})
+	lock.Set(deps.Locked{Name: "fmt", Source: "fmtlib:fmt", Version: "10.2.1", Revision: "2222222", Options: map[string]string{"FMT_TEST": "OFF"}})
+	lock.Set(deps.Locked{Name: "zlib", Source: "madler:zlib", Revision: "3333333"})
+	if err := lock.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err = deps.ReadLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lock.Packages) != 2 || lock.Packages[0].Name != "fmt" || lock.Find("zlib").Revision != "3333333" {
+		t.Fatalf("packages = %+v", lock.Packages)
+	}
+
+	dep := config.Dependency{Name: "fmt", Source: "fmtlib:fmt", Version: "10.2.1", Options: map[string]string{"FMT_TEST": "OFF"}}
+	if !lock.Find("fmt").Matches(dep) {
+		t.Error("locked fmt does not match its declaration")
+	}
+	dep.Version = "11.0.0"
+	if lock.Find("fmt").Matches(dep) {
+		t.Error("locked fmt matches a different version")
+	}
+
+	lock.Retain([]config.Dependency{dep})
+	if len(lock.Packages) != 1 || lock.Find("zlib") != nil {
+		t.Errorf("Retain() left %+v", lock.Packages)
+	}
+	lock.Remove("fmt")
+	if len(lock.Packages) != 0 {
+		t.Errorf("Remove() left %+v", lock.Packages)
+	}
+}
+
+// bareRepo 创建一个带有两个提交、标签 v1.0.0 和分支 dev 的裸仓库
+func bareRepo(t *testing.T) (string, map[string]string) {
+	work := t.TempDir()
+	bare := filepath.Join(t.TempDir(), "lib.git")
+	revisions := make(map[string]string)
+	commitPackage(t, work, map[string]string{"version.txt": "1"})
+	runGit(t, work, "tag", "-a", "v1.0.0", "-m", "release 1.0.0")
+	revisions["v1.0.0"] = runGit(t, work, "rev-parse", "HEAD")
+
+	runGit(t, work, "checkout", "-q", "-b", "dev")
+	commitPackage(t, work, map[string]string{"version.txt": "dev"})
+	revisions["dev"] = runGit(t, work, "rev-parse", "HEAD")
+
+	runGit(t, work, "checkout", "-q", "main")
+	commitPackage(t, work, map[string]string{"version.txt": "2"})
+	revisions["main"] = runGit(t, work, "rev-parse", "HEAD")
+
+	runGit(t, work, "clone", "-q", "--bare", work, bare)
+	return bare, revisions
+}
+
+func TestFetchRevision(t *testing.T) {
+	bare, revisions := bareRepo(t)
+	dir := filepath.Join(t.TempDir(), "lib")
+
+	tests := []struct {
+		ref      string
+		revision string
+		content  string
+	}{
+		{"", revisions["main"], "2"},
+		{"v1.0.0", revisions["v1.0.0"], "1"},
+		{"dev", revisions["dev"], "dev"},
+		{revisions["v1.0.0"], revisions["v1.0.0"], "1"},
+		{revisions["dev"][:8], revisions["dev"], "dev"},
+	}
+
+	for _, tt := range tests {
+		revision, err := deps.Fetch(bare, tt.ref, dir, nil)
+		if err != nil {
+			t.Fatalf("Fetch(%q): %s", tt.ref, err)
+		}
+		if revision != tt.revision {
+			t.Errorf("Fetch(%q) = %s, want %s", tt.ref, revision, tt.revision)
+		}
+		if content, _ := os.ReadFile(filepath.Join(dir, "version.txt")); string(content) != tt.content {
+			t.Errorf("Fetch(%q) checked out %q, want %q", tt.ref, content, tt.content)
+		}
+	}
+
+	if _, err := deps.Fetch(bare, "v9.9.9", dir, nil); err == nil {
+		t.Error("Fetch() of a missing tag should fail")
+	}
+
+	for ref, want := range map[string]string{"": revisions["main"], "v1.0.0": revisions["v1.0.0"], "dev": revisions["dev"]} {
+		if got, err := deps.Resolve(bare, ref); err != nil || got != want {
+			t.Errorf("Resolve(%q) = %s, %v, want %s", ref, got, err, want)
+		}
+	}
+	if _, err := deps.Resolve(bare, "v9.9.9"); err == nil {
+		t.Error("Resolve() of a missing tag should fail")
+	}
+}
+
+func TestHashFiles(t *testing.T) {
+	prefix := t.TempDir()
+	os.MkdirAll(filepath.Join(prefix, "include"), 0755)
+	os.WriteFile(filepath.Join(prefix, "include", "lib.h"), []byte("#pragma once"), 0644)
+	os.WriteFile(filepath.Join(prefix, "lib.a"), []byte("archive"), 0644)
+
+	hash := deps.HashFiles(prefix, []string{"lib.a", "include/lib.h"})
+	if !strings.HasPrefix(hash, "sha256:") || hash != deps.HashFiles(prefix, []string{"include/lib.h", "lib.a"}) {
+		t.Errorf("HashFiles() = %s depends on the order of the files", hash)
+	}
+
+	os.WriteFile(filepath.Join(prefix, "lib.a"), []byte("changed"), 0644)
+	if deps.HashFiles(prefix, []string{"lib.a", "include/lib.h"}) == hash {
+		t.Error("HashFiles() did not change with the content")
+	}
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/zelviner/cgear/mutator"
+)
+
+var mutateSource = `#include <vector>
+#define LIMIT 10
+
+// if (a < b) return 1;
+int clamp(int value, std::vector<int> &values) {
+    const char *text = "a < b && 1";
+    if (value < 0 && !values.empty()) {
+        values.push_back(value);
+    }
+    for (int i = 0; i != 3; ++i) {}
+    return value >= 100 ? true : false;
+}
+`
+
+func TestGenerateMutants(t *testing.T) {
+	type mutation struct {
+		line        int
+		operator    string
+		original    string
+		replacement string
+	}
+
+	want := []mutation{
+		{7, mutator.Comparison, "<", ">="},
+		{7, mutator.Constant, "0", "1"},
+		{7, mutator.Logical, "&&", "||"},
+		{8, mutator.Deletion, "values.push_back(value);", ""},
+		{10, mutator.Constant, "0", "1"},
+		{10, mutator.Comparison, "!=", "=="},
+		{10, mutator.Constant, "3", "0"},
+		{11, mutator.Comparison, ">=", "<"},
+		{11, mutator.Constant, "100", "0"},
+		{11, mutator.Constant, "true", "false"},
+		{11, mutator.Constant, "false", "true"},
+	}
+
+	mutants := mutator.Generate("src/clamp.cpp", []byte(mutateSource))
+	if len(mutants) != len(want) {
+		for _, m := range mutants {
+			t.Logf("%d: %s %q -> %q", m.Line, m.Operator, m.Original, m.Replacement)
+		}
+		t.Fatalf("Generate() returned %d mutants, want %d", len(mutants), len(want))
+	}
+
+	for i, m := range mutants {
+		got := mutation{m.Line, m.Operator, m.Original, m.Replacement}
+		if got != want[i] {
+			t.Errorf("mutant %d = %+v, want %+v", i, got, want[i])
+		}
+		if m.ID != i+1 || m.File != "src/clamp.cpp" {
+			t.Errorf("mutant %d: ID %d, file %s", i, m.ID, m.File)
+		}
+	}
+
+	mutated := string(mutator.Apply([]byte("if (a < b) {}"), mutator.Mutant{Offset: 6, Original: "<", Replacement: ">="}))
+	if mutated != "if (a >= b) {}" {
+		t.Errorf("Apply() = %q", mutated)
+	}
+}
+
+func TestMutationReport(t *testing.T) {
+	results := []mutator.Result{
+		{Mutant: mutator.Mutant{File: "src/b.cpp", Line: 9}, Status: mutator.Survived},
+		{Mutant: mutator.Mutant{File: "src/a.cpp", Line: 3}, Status: mutator.Killed},
+		{Mutant: mutator.Mutant{File: "src/b.cpp", Line: 2}, Status: mutator.Survived},
+		{Mutant: mutator.Mutant{File: "src/a.cpp", Line: 5}, Status: mutator.Killed},
+		{Mutant: mutator.Mutant{File: "src/a.cpp", Line: 6}, Status: mutator.Killed},
+		{Mutant: mutator.Mutant{File: "src/a.cpp", Line: 7}, Status: mutator.Invalid},
+	}
+
+	report := mutator.NewReport(results)
+	if report.Killed != 3 || report.Survived != 2 || report.Invalid != 1 || report.Score != 0.6 {
+		t.Errorf("report = %d killed, %d survived, %d invalid, score %v", report.Killed, report.Survived, report.Invalid, report.Score)
+	}
+
+	if len(report.Files) != 2 || report.Files[0].File != "src/a.cpp" {
+		t.Fatalf("Files = %+v", report.Files)
+	}
+	survived := report.Files[1].Survived
+	if len(survived) != 2 || survived[0].Line != 2 || survived[1].Line != 9 {
+		t.Errorf("survived mutants of src/b.cpp = %+v", survived)
+	}
+}
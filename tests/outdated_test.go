@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/deps"
+)
+
+func TestVersionConstraints(t *testing.T) {
+	for _, test := range []struct {
+		constraint string
+		tag        string
+		match      bool
+	}{
+		{"^1.2", "v1.9.0", true},
+		{"^1.2", "v2.0.0", false},
+		{"^1.2", "1.1.9", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"~1.2.3", "1.2.10", true},
+		{"~1.2.3", "1.3.0", false},
+		{">=1.0, <2", "release-1.8.0", true},
+		{">= 1.0 < 2", "curl-2_0_0", false},
+		{"^1.0", "1.5.0-rc1", false},
+		{"^1.0.0-rc1", "1.5.0-rc1", true},
+	} {
+		constraint, err := deps.ParseConstraint(test.constraint)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%s): %s", test.constraint, err)
+		}
+		v, ok := deps.ParseSemver(test.tag)
+		if !ok {
+			t.Fatalf("ParseSemver(%s) failed", test.tag)
+		}
+		if constraint.Match(v) != test.match {
+			t.Errorf("%s matches %s = %v", test.constraint, test.tag, !test.match)
+		}
+	}
+
+	if _, err := deps.ParseConstraint("^main"); err == nil {
+		t.Error("ParseConstraint(^main) should fail")
+	}
+	if err := deps.Validate([]config.Dependency{{Name: "fmt", Source: "fmtlib:fmt", Version: "^x"}}); err == nil {
+		t.Error("Validate accepted an invalid constraint")
+	}
+}
+
+// tagRepository 创建一个裸仓库, 依次提交并打上 tags 中的标签, 返回仓库路径和每个标签的提交
+func tagRepository(t *testing.T, tags ...string) (string, map[string]string) {
+	root := t.TempDir()
+	work := filepath.Join(root, "work")
+	commits := make(map[string]string)
+	for _, tag := range tags {
+		commitPackage(t, work, map[string]string{"version.txt": tag})
+		runGit(t, work, "tag", "-a", "-m", tag, tag)
+		commits[tag] = runGit(t, work, "rev-parse", "HEAD")
+	}
+
+	bare := filepath.Join(root, "lib.git")
+	runGit(t, root, "clone", "-q", "--bare", work, bare)
+	return bare, commits
+}
+
+func TestOutdated(t *testing.T) {
+	bare, commits := tagRepository(t, "v1.0.0", "v1.1.0", "v2.0.0", "v2.1.0-rc1", "nightly")
+
+	installer := &deps.Installer{PkgPath: t.TempDir(), Installed: t.TempDir()}
+	dep := config.Dependency{Name: "lib", Source: bare, Version: "^1.0"}
+	outdated, err := installer.CheckOutdated(dep, commits["v1.0.0"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outdated.Current != "v1.0.0" || outdated.Wanted != "v1.1.0" || outdated.Latest != "v2.0.0" || !outdated.IsOutdated() {
+		t.Errorf("CheckOutdated = %+v", outdated)
+	}
+
+	// 版本号的标签与兼容的标签比较
+	dep.Version = "v2.0.0"
+	if outdated, err = installer.CheckOutdated(dep, commits["v2.0.0"]); err != nil || outdated.Wanted != "v2.0.0" || outdated.IsOutdated() {
+		t.Errorf("CheckOutdated(v2.0.0) = %+v, %v", outdated, err)
+	}
+
+	// 版本约束解析为满足约束的最新标签
+	dep.Version = "~1"
+	graph, err := installer.Graph([]config.Dependency{dep}, &deps.Lock{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revision := graph.Packages["lib"].Revision; revision != commits["v1.1.0"] {
+		t.Errorf("~1 resolved to %s, want %s", revision, commits["v1.1.0"])
+	}
+
+	dep.Version = "^3"
+	if _, err := installer.Graph([]config.Dependency{dep}, &deps.Lock{}, false); err == nil || !strings.Contains(err.Error(), "no tag") {
+		t.Errorf("Graph(^3): %v", err)
+	}
+}
+
+func TestSaveDependencies(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "cgear.json"), []byte(`{"platform": "x64", "dependencies": [], "build_type": "Debug"}`), 0644)
+
+	dependencies := []config.Dependency{{Name: "fmt", Source: "fmtlib:fmt", Version: ">=10.0, <11"}}
+	if err := config.SaveDependencies(dir, dependencies); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "cgear.json"))
+	content := string(data)
+	if !strings.Contains(content, `">=10.0, <11"`) {
+		t.Errorf("version was escaped:\n%s", content)
+	}
+	if p, d, b := strings.Index(content, "platform"), strings.Index(content, "dependencies"), strings.Index(content, "build_type"); !(p < d && d < b) {
+		t.Errorf("fields were reordered:\n%s", content)
+	}
+	conf, err := config.ReadFile(filepath.Join(dir, "cgear.json"))
+	if err != nil || len(conf.Dependencies) != 1 || conf.Dependencies[0].Version != ">=10.0, <11" || conf.Platform != "x64" {
+		t.Errorf("ReadFile = %+v, %v", conf, err)
+	}
+
+	if err := config.SaveDependencies(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "cgear.json")); strings.Contains(string(data), "dependencies") {
+		t.Errorf("empty dependencies were kept:\n%s", data)
+	}
+
+	yamlDir := t.TempDir()
+	os.WriteFile(filepath.Join(yamlDir, "Cgearfile"), []byte("platform: x64\nbuild_type: Release\n"), 0644)
+	if err := config.SaveDependencies(yamlDir, dependencies); err != nil {
+		t.Fatal(err)
+	}
+	conf, err = config.ReadFile(filepath.Join(yamlDir, "Cgearfile"))
+	if err != nil || len(conf.Dependencies) != 1 || conf.Dependencies[0].Name != "fmt" || conf.BuildType != "Release" {
+		t.Errorf("ReadFile(Cgearfile) = %+v, %v", conf, err)
+	}
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zelviner/cgear/deps"
+)
+
+func TestSchedule(t *testing.T) {
+	names := []string{"zlib", "fmt", "png", "tiff"}
+	requires := map[string][]string{"png": {"zlib"}, "tiff": {"png", "fmt"}}
+
+	var (
+		mu       sync.Mutex
+		finished = make(map[string]bool)
+		started  sync.WaitGroup
+	)
+	started.Add(2)
+	err := deps.Schedule(names, requires, func(name string) error {
+		mu.Lock()
+		for _, require := range requires[name] {
+			if !finished[require] {
+				t.Errorf("%s started before %s finished", name, require)
+			}
+		}
+		mu.Unlock()
+
+		// zlib 和 fmt 互不依赖, 必须同时执行
+		if name == "zlib" || name == "fmt" {
+			started.Done()
+			wait := make(chan struct{})
+			go func() { started.Wait(); close(wait) }()
+			select {
+			case <-wait:
+			case <-time.After(5 * time.Second):
+				t.Errorf("%s did not run in parallel", name)
+			}
+		}
+
+		mu.Lock()
+		finished[name] = true
+		mu.Unlock()
+		return nil
+	})
+	if err != nil || len(finished) != len(names) {
+		t.Fatalf("Schedule() = %v, finished %v", err, finished)
+	}
+
+	var ran []string
+	err = deps.Schedule(names, requires, func(name string) error {
+		mu.Lock()
+		ran = append(ran, name)
+		mu.Unlock()
+		if name == "png" {
+			return errors.New("png failed")
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "png failed" {
+		t.Errorf("Schedule() = %v, want the error of png", err)
+	}
+	for _, name := range ran {
+		if name == "tiff" {
+			t.Error("tiff ran although png failed")
+		}
+	}
+}
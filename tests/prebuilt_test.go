@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zelviner/cgear/cmake"
+	"github.com/zelviner/cgear/deps"
+)
+
+// writeFiles 在 dir 下创建 files 中的空文件
+func writeFiles(t *testing.T, dir string, files ...string) {
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(file), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestInstallPrebuilt(t *testing.T) {
+	sdk := t.TempDir()
+	writeFiles(t, sdk, "include/foo.h", "lib/x64/Release/foo.lib", "lib/x64/Release/foo_net.lib",
+		"lib/x64/Debug/food.lib", "lib/x64/Debug/foo_netd.lib", "bin/foo.dll", "bin/readme.txt", "debug/bin/food.dll")
+
+	prefix := t.TempDir()
+	prebuilt := &deps.Prebuilt{
+		Name:     "foo",
+		Source:   sdk,
+		Version:  "2.1",
+		Include:  filepath.Join(sdk, "include"),
+		Lib:      filepath.Join(sdk, "lib", "x64", "Release"),
+		DebugLib: filepath.Join(sdk, "lib", "x64", "Debug"),
+		Bin:      filepath.Join(sdk, "bin"),
+		DebugBin: filepath.Join(sdk, "debug", "bin"),
+	}
+	record, err := deps.InstallPrebuilt(prefix, prebuilt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range []string{"include/foo/foo.h", "lib/foo/foo.lib", "debug/lib/foo/food.lib", "bin/foo.dll", "debug/bin/food.dll",
+		"lib/cmake/foo/fooConfig.cmake", "lib/cmake/foo/fooConfigVersion.cmake", "lib/pkgconfig/foo.pc", "debug/lib/pkgconfig/foo.pc"} {
+		found := false
+		for _, f := range record.Files {
+			found = found || f == file
+		}
+		if _, err := os.Stat(filepath.Join(prefix, file)); err != nil || !found {
+			t.Errorf("%s not installed or not recorded: %v", file, err)
+		}
+	}
+	// Release 版本的库不再复制到 debug 目录
+	if _, err := os.Stat(filepath.Join(prefix, "debug", "lib", "foo", "foo.lib")); err == nil {
+		t.Error("release library copied into debug/lib")
+	}
+
+	data, _ := os.ReadFile(filepath.Join(prefix, "lib", "cmake", "foo", "fooConfig.cmake"))
+	config := string(data)
+	for _, want := range []string{
+		"add_library(foo::foo SHARED IMPORTED)",
+		`IMPORTED_LOCATION "${_IMPORT_PREFIX}/bin/foo.dll"`,
+		`IMPORTED_IMPLIB "${_IMPORT_PREFIX}/lib/foo/foo.lib"`,
+		`IMPORTED_LOCATION_DEBUG "${_IMPORT_PREFIX}/debug/bin/food.dll"`,
+		`IMPORTED_IMPLIB_DEBUG "${_IMPORT_PREFIX}/debug/lib/foo/food.lib"`,
+		"add_library(foo::foo_net STATIC IMPORTED)",
+		`IMPORTED_LOCATION_DEBUG "${_IMPORT_PREFIX}/debug/lib/foo/foo_netd.lib"`,
+		`INTERFACE_INCLUDE_DIRECTORIES "${_IMPORT_PREFIX}/include/foo"`,
+	} {
+		if !strings.Contains(config, want) {
+			t.Errorf("fooConfig.cmake lacks %s:\n%s", want, config)
+		}
+	}
+	if strings.Contains(config, "INTERFACE IMPORTED") {
+		t.Errorf("foo::foo is a library, no umbrella target expected:\n%s", config)
+	}
+
+	data, _ = os.ReadFile(filepath.Join(prefix, "debug", "lib", "pkgconfig", "foo.pc"))
+	if pc := string(data); !strings.Contains(pc, "prefix=${pcfiledir}/../../..") || !strings.Contains(pc, "Libs: ${prefix}/debug/lib/foo/food.lib ${prefix}/debug/lib/foo/foo_netd.lib") || !strings.Contains(pc, "Version: 2.1") {
+		t.Errorf("debug foo.pc:\n%s", pc)
+	}
+
+	// 生成的包配置可以被 cgear add 找到
+	files := []string{filepath.Join(prefix, "lib", "cmake", "foo", "fooConfig.cmake")}
+	if packages, err := cmake.FindPackages(files); err != nil || !reflect.DeepEqual(packages, []cmake.Package{{Name: "foo", Targets: []string{"foo::foo", "foo::foo_net"}}}) {
+		t.Errorf("FindPackages = %+v, %v", packages, err)
+	}
+
+	// 没有 Debug 版本的运行时库时复制 Release 版本的, 但不作为 Debug 版本的库使用
+	prebuilt = &deps.Prebuilt{Name: "foo", Source: sdk, Lib: filepath.Join(sdk, "lib", "x64", "Release"), Bin: filepath.Join(sdk, "bin")}
+	if _, err := deps.InstallPrebuilt(prefix, prebuilt); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(prefix, "debug", "bin", "foo.dll")); err != nil {
+		t.Error("release DLLs not copied to debug/bin")
+	}
+	data, _ = os.ReadFile(filepath.Join(prefix, "lib", "cmake", "foo", "fooConfig.cmake"))
+	if config := string(data); !strings.Contains(config, "add_library(foo::foo SHARED IMPORTED)") || strings.Contains(config, "_DEBUG") {
+		t.Errorf("fooConfig.cmake without debug libraries:\n%s", config)
+	}
+
+	// 重新安装只有头文件的版本时删除之前的文件
+	prebuilt = &deps.Prebuilt{Name: "foo", Source: sdk, Include: filepath.Join(sdk, "include")}
+	if record, err = deps.InstallPrebuilt(prefix, prebuilt); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(prefix, "bin", "foo.dll")); err == nil {
+		t.Error("files of the previous install were kept")
+	}
+	data, _ = os.ReadFile(filepath.Join(prefix, "lib", "cmake", "foo", "fooConfig.cmake"))
+	if config := string(data); !strings.Contains(config, "add_library(foo::foo INTERFACE IMPORTED)") || strings.Contains(config, "INTERFACE_LINK_LIBRARIES") {
+		t.Errorf("header-only fooConfig.cmake:\n%s", config)
+	}
+	if err := deps.Uninstall(prefix, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(prefix); len(entries) != 1 || entries[0].Name() != ".cgear" {
+		t.Errorf("uninstall left %v", entries)
+	}
+
+	if _, err := deps.InstallPrebuilt(prefix, &deps.Prebuilt{Name: "foo", Lib: filepath.Join(sdk, "include")}); err == nil {
+		t.Error("a library directory without libraries was accepted")
+	}
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zelviner/cgear/tester"
+)
+
+var reportCases = []tester.Case{
+	{Suite: "Json", Name: "parser", Status: tester.StatusPassed, Time: 0.5, Binary: "json_test"},
+	{Suite: "Json", Name: "array", Status: tester.StatusFailed, Time: 1.5, Message: "Expected equality", Binary: "json_test"},
+	{Suite: "Ftp", Name: "connect", Status: tester.StatusSkipped, Binary: "ftp_test"},
+}
+
+func TestWriteJUnit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test-results", "junit.xml")
+	if err := tester.WriteJUnit(path, reportCases); err != nil {
+		t.Fatal(err)
+	}
+
+	cases, err := tester.ParseJUnitFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cases) != len(reportCases) {
+		t.Fatalf("expected %d cases, got %d", len(reportCases), len(cases))
+	}
+
+	for i, c := range reportCases {
+		if cases[i].FullName() != c.FullName() || cases[i].Status != c.Status || cases[i].Message != c.Message {
+			t.Errorf("case %d: expected %+v, got %+v", i, c, cases[i])
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.json")
+	if err := tester.WriteJSON(path, reportCases); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var summary tester.Summary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.Tests != 3 || summary.Passed != 1 || summary.Failed != 1 || summary.Skipped != 1 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	if slowest := summary.Slowest(1); len(slowest) != 1 || slowest[0].FullName() != "Json.array" {
+		t.Errorf("unexpected slowest tests: %+v", slowest)
+	}
+}
+
+func TestGoogleTestReport(t *testing.T) {
+	dir := t.TempDir()
+	xmlPath := filepath.Join(dir, "xml_test.xml")
+	args, _ := tester.GoogleTest.RunArgs(tester.Job{Filter: "Xml.*"}, xmlPath)
+
+	// gtest 只接受一个 --gtest_output, JSON 报告由 XML 结果生成
+	var outputs []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--gtest_output=") {
+			outputs = append(outputs, arg)
+		}
+	}
+	if len(outputs) != 1 || outputs[0] != "--gtest_output=xml:"+xmlPath {
+		t.Fatalf("unexpected output args: %q", args)
+	}
+
+	os.WriteFile(xmlPath, []byte(gtestJUnit), 0644)
+	cases, err := tester.ParseJUnitFile(xmlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases = tester.GoogleTest.Normalize("xml_test", cases)
+
+	jsonPath := filepath.Join(dir, "summary.json")
+	if err := tester.WriteJSON(jsonPath, cases); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(jsonPath)
+	var summary tester.Summary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Tests != 2 || summary.Passed != 1 || summary.Failed != 1 || len(summary.Cases) != 2 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if failed := summary.Cases[1]; failed.FullName() != "Xml.node" || !strings.Contains(failed.Message, "Expected equality") {
+		t.Errorf("unexpected failed case: %+v", failed)
+	}
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/zelviner/cgear/tester"
+)
+
+var gtestList = `Running main() from C:/Users/ZEL/Zel/pkg/googletest/googletest/src/gtest_main.cc
+Json.
+  parser
+  array
+Range/Param.
+  value/0  # GetParam() = 1
+  value/1  # GetParam() = 2
+`
+
+func TestParseTestList(t *testing.T) {
+	tests := tester.ParseTestList(gtestList)
+
+	expected := []string{"Json.parser", "Json.array", "Range/Param.value/0", "Range/Param.value/1"}
+	if len(tests) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, tests)
+	}
+
+	for i := range expected {
+		if tests[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], tests[i])
+		}
+	}
+}
+
+func TestPlanWithoutDurations(t *testing.T) {
+	programs := []tester.Program{
+		{Path: "json_test.exe", Tests: []string{"Json.a", "Json.b", "Json.c", "Json.d"}},
+	}
+
+	jobs := tester.Plan(tester.GoogleTest, programs, "*", 2, nil)
+	if len(jobs) != 2 {
+		t.Fatalf("expected 2 shards, got %d", len(jobs))
+	}
+
+	for i, job := range jobs {
+		_, env := tester.GoogleTest.RunArgs(job, "")
+		if job.Shards != 2 || job.Shard != i || len(env) != 2 {
+			t.Errorf("unexpected shard job: %+v", job)
+		}
+	}
+
+	// doctest 不支持分片, 按预估耗时分组并显式指定用例
+	jobs = tester.Plan(tester.Doctest, programs, "*", 2, nil)
+	if len(jobs) != 2 || jobs[0].Shards != 0 || len(jobs[0].Tests) != 2 {
+		t.Errorf("unexpected doctest jobs: %+v", jobs)
+	}
+}
+
+func TestPlanWithDurations(t *testing.T) {
+	programs := []tester.Program{
+		{Path: "json_test.exe", Tests: []string{"Json.slow", "Json.a", "Json.b", "Json.c"}},
+		{Path: "xml_test.exe", Tests: []string{"Xml.a"}},
+	}
+	durations := map[string]float64{"Json.slow": 3, "Json.a": 1, "Json.b": 1, "Json.c": 1, "Xml.a": 0.5}
+
+	jobs := tester.Plan(tester.GoogleTest, programs, "*", 2, durations)
+	if len(jobs) != 3 {
+		t.Fatalf("expected 3 jobs, got %d: %+v", len(jobs), jobs)
+	}
+
+	// 耗时最长的分组排在最前面, 且 Json.slow 单独一组
+	if len(jobs[0].Tests) != 1 || jobs[0].Tests[0] != "Json.slow" || jobs[0].Estimate != 3 {
+		t.Errorf("unexpected first job: %+v", jobs[0])
+	}
+
+	if jobs[1].Estimate != 3 || len(jobs[1].Tests) != 3 {
+		t.Errorf("unexpected second job: %+v", jobs[1])
+	}
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zelviner/cgear/deps"
+)
+
+func TestParseSource(t *testing.T) {
+	local := t.TempDir()
+	wd, _ := os.Getwd()
+
+	tests := []struct {
+		spec string
+		url  string
+		ref  string
+		name string
+	}{
+		{"google:googletest", "git@github.com:google/googletest", "", "googletest"},
+		{"google:googletest#main", "git@github.com:google/googletest", "main", "googletest"},
+		{"fmtlib/fmt", "https://github.com/fmtlib/fmt.git", "", "fmt"},
+		{"fmtlib/fmt@10.2.1", "https://github.com/fmtlib/fmt.git", "10.2.1", "fmt"},
+		{"fmtlib/fmt.git#3f2a9c1", "https://github.com/fmtlib/fmt.git", "3f2a9c1", "fmt"},
+		{"gitlab.com/group/sub/json", "https://gitlab.com/group/sub/json", "", "json"},
+		{"https://git.example.com/libs/json.git#release/2.x", "https://git.example.com/libs/json.git", "release/2.x", "json"},
+		{"ssh://git@git.example.com:2222/libs/json.git", "ssh://git@git.example.com:2222/libs/json.git", "", "json"},
+		{"git@git.example.com:libs/json.git#dev", "git@git.example.com:libs/json.git", "dev", "json"},
+		{"file:///srv/git/json.git", "file:///srv/git/json.git", "", "json"},
+		{local + "#v1.0.0", local, "v1.0.0", filepath.Base(local)},
+		{"./libs/json", filepath.Join(wd, "libs", "json"), "", "json"},
+	}
+
+	for _, tt := range tests {
+		source, err := deps.ParseSource(tt.spec)
+		if err != nil {
+			t.Errorf("ParseSource(%q): %s", tt.spec, err)
+			continue
+		}
+		if source.URL != tt.url || source.Ref != tt.ref {
+			t.Errorf("ParseSource(%q) = %+v, want URL %s and ref %q", tt.spec, source, tt.url, tt.ref)
+		}
+		if source.Name() != tt.name {
+			t.Errorf("ParseSource(%q).Name() = %s, want %s", tt.spec, source.Name(), tt.name)
+		}
+	}
+
+	for _, spec := range []string{"", "json", "fmtlib/fmt@1.0#main", "https://host/repo.git#"} {
+		if _, err := deps.ParseSource(spec); err == nil {
+			t.Errorf("ParseSource(%q) should fail", spec)
+		}
+	}
+
+	source, _ := deps.ParseSource("fmtlib/fmt@10.2.1")
+	if revision, err := source.Revision(""); err != nil || revision != "10.2.1" {
+		t.Errorf("Revision(\"\") = %s, %v", revision, err)
+	}
+	if _, err := source.Revision("11.0.0"); err == nil {
+		t.Error("Revision() with a conflicting version should fail")
+	}
+	source, _ = deps.ParseSource("fmtlib/fmt")
+	if revision, _ := source.Revision("11.0.0"); revision != "11.0.0" {
+		t.Errorf("Revision(11.0.0) = %s", revision)
+	}
+}
+
+func TestFetchLocalSources(t *testing.T) {
+	bare, revisions := bareRepo(t)
+	dir := filepath.Join(t.TempDir(), "lib")
+
+	for _, spec := range []string{"file://" + filepath.ToSlash(bare) + "#dev", bare + "#dev", bare + "#" + revisions["dev"][:10]} {
+		source, err := deps.ParseSource(spec)
+		if err != nil {
+			t.Fatalf("ParseSource(%q): %s", spec, err)
+		}
+
+		revision, err := deps.Fetch(source.URL, source.Ref, dir, nil)
+		if err != nil {
+			t.Fatalf("Fetch(%q): %s", spec, err)
+		}
+		if revision != revisions["dev"] {
+			t.Errorf("Fetch(%q) = %s, want %s", spec, revision, revisions["dev"])
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zelviner/cgear/config"
+	"github.com/zelviner/cgear/deps"
+)
+
+func TestVendor(t *testing.T) {
+	root := t.TempDir()
+	commitPackage(t, filepath.Join(root, "lib"), map[string]string{"lib.h": "#define LIB 1\n"})
+
+	data := tarGz(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write(data) }))
+	dependencies := []config.Dependency{
+		{Name: "lib", Source: filepath.Join(root, "lib")},
+		{Name: "json", URL: server.URL + "/json-1.0.tar.gz", SHA256: sum(data), Version: "1.0"},
+	}
+
+	installer := &deps.Installer{PkgPath: t.TempDir(), Installed: t.TempDir()}
+	graph, err := installer.Graph(dependencies, &deps.Lock{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vendorPath := filepath.Join(t.TempDir(), deps.VendorDir)
+	os.MkdirAll(vendorPath, 0755)
+	os.WriteFile(filepath.Join(vendorPath, "stale-0123456789.tar.gz"), nil, 0644)
+	if _, err := installer.WriteVendor(graph, vendorPath); err != nil {
+		t.Fatal(err)
+	}
+
+	revision := graph.Packages["lib"].Revision
+	files, _ := os.ReadDir(vendorPath)
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	want := []string{"json-" + sum(data)[:12] + ".tar.gz", "lib-" + revision[:10] + ".tar.gz", deps.VendorIndex}
+	if strings.Join(names, " ") != strings.Join(want, " ") {
+		t.Errorf("vendor = %v, want %v", names, want)
+	}
+
+	// 没有网络时只从 vendor 目录安装
+	server.Close()
+	os.RemoveAll(filepath.Join(root, "lib"))
+	vendor, err := deps.ReadVendor(vendorPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offline := &deps.Installer{PkgPath: t.TempDir(), Installed: t.TempDir(), Vendor: vendor}
+	graph, err = offline.Graph(dependencies, &deps.Lock{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg := graph.Packages["lib"]; pkg.Revision != revision {
+		t.Errorf("offline lib revision = %s, want %s", pkg.Revision, revision)
+	}
+	if content, _ := os.ReadFile(filepath.Join(graph.Packages["lib"].Path, "lib.h")); string(content) != "#define LIB 1\n" {
+		t.Errorf("offline lib.h = %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(graph.Packages["json"].Path, "include", "json.h")); err != nil {
+		t.Error(err)
+	}
+
+	lock := &deps.Lock{}
+	lock.Set(deps.Locked{Name: "lib", Source: dependencies[0].Source, Revision: strings.Repeat("1", 40)})
+	if _, err := offline.Graph(dependencies, lock, false); err == nil || !strings.Contains(err.Error(), "cgear vendor") {
+		t.Errorf("Graph with a lock that differs from vendor: %v", err)
+	}
+
+	dependencies[0].Version = "v2"
+	if _, err := offline.Graph(dependencies, &deps.Lock{}, false); err == nil || !strings.Contains(err.Error(), "cgear vendor") {
+		t.Errorf("Graph with a changed declaration: %v", err)
+	}
+}
+
+func TestMirrors(t *testing.T) {
+	mirrors := deps.ParseMirrors("https://github.com/=https://git.example.com/github/, git@github.com:=/srv/mirror/")
+	if mirrors["https://github.com/"] != "https://git.example.com/github/" || mirrors["git@github.com:"] != "/srv/mirror/" {
+		t.Errorf("ParseMirrors = %v", mirrors)
+	}
+
+	root := t.TempDir()
+	commitPackage(t, filepath.Join(root, "mirror", "fmtlib", "fmt"), map[string]string{"fmt.h": ""})
+
+	installer := &deps.Installer{
+		PkgPath:   t.TempDir(),
+		Installed: t.TempDir(),
+		Mirrors: map[string]string{
+			"https://":                       "https://unreachable.invalid/",
+			"https://example.invalid/":       "/nonexistent/",
+			"https://example.invalid/fmtlib": filepath.Join(root, "mirror", "fmtlib"),
+		},
+	}
+	dep := config.Dependency{Name: "fmt", Source: "https://example.invalid/fmtlib/fmt"}
+	graph, err := installer.Graph([]config.Dependency{dep}, &deps.Lock{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(graph.Packages["fmt"].Path, "fmt.h")); err != nil {
+		t.Error("fmt was not fetched from the mirror")
+	}
+}
@@ -0,0 +1,175 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/zelviner/cgear/logger/colors"
+)
+
+// State 是任务的状态
+type State int
+
+const (
+	Running State = iota // 正在执行
+	Done                 // 成功完成
+	Skipped              // 不需要执行
+	Failed               // 失败
+)
+
+var spinner = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+type task struct {
+	name  string
+	text  string
+	state State
+	start time.Time
+	end   time.Time
+}
+
+// Progress 显示多个并行任务的状态。
+// 输出是终端时, 正在执行的任务各占一行并原地刷新, 结束的任务移到上方保留;
+// 否则每次状态变化输出一行。Progress 可以被并发使用。
+type Progress struct {
+	out  io.Writer
+	live bool
+
+	mu       sync.Mutex
+	tasks    map[string]*task
+	running  []*task // 正在执行的任务, 按开始的顺序
+	finished []*task // 结束但还没有输出的任务
+	drawn    int     // 上次刷新输出的行数
+	frame    int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New 创建输出到 out 的 Progress, 调用 Stop 结束刷新
+func New(out *os.File) *Progress {
+	p := &Progress{
+		out:   out,
+		live:  isTerminal(out),
+		tasks: make(map[string]*task),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+
+	if p.live {
+		go p.loop()
+	} else {
+		close(p.done)
+	}
+	return p
+}
+
+// Set 设置任务 name 的状态和说明, 任务不存在时创建
+func (p *Progress) Set(name string, state State, text string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	t := p.tasks[name]
+	if t == nil {
+		t = &task{name: name, state: state, start: time.Now()}
+		p.tasks[name] = t
+		if state == Running {
+			p.running = append(p.running, t)
+		}
+	} else if t.state == Running && state != Running {
+		for i := range p.running {
+			if p.running[i] == t {
+				p.running = append(p.running[:i], p.running[i+1:]...)
+				break
+			}
+		}
+	}
+
+	t.state, t.text = state, text
+	if state != Running {
+		t.end = time.Now()
+		p.finished = append(p.finished, t)
+	}
+
+	if !p.live {
+		fmt.Fprintln(p.out, p.format(t))
+		p.finished = nil
+	}
+}
+
+// Stop 停止刷新并输出所有任务最后的状态
+func (p *Progress) Stop() {
+	select {
+	case <-p.stop:
+	default:
+		close(p.stop)
+	}
+	<-p.done
+}
+
+func (p *Progress) loop() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.draw()
+		case <-p.stop:
+			p.draw()
+			return
+		}
+	}
+}
+
+// draw 清除上次输出的执行中的任务, 输出新结束的任务, 再输出执行中的任务
+func (p *Progress) draw() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.drawn > 0 {
+		fmt.Fprintf(p.out, "\x1b[%dA\x1b[J", p.drawn)
+	}
+	for _, t := range p.finished {
+		fmt.Fprintln(p.out, p.format(t))
+	}
+	p.finished = nil
+
+	p.frame++
+	for _, t := range p.running {
+		fmt.Fprintln(p.out, p.format(t))
+	}
+	p.drawn = len(p.running)
+}
+
+func (p *Progress) format(t *task) string {
+	end := t.end
+	if t.state == Running {
+		end = time.Now()
+	}
+	elapsed := end.Sub(t.start).Round(time.Second)
+
+	switch t.state {
+	case Running:
+		mark := "•"
+		if p.live {
+			mark = spinner[p.frame%len(spinner)]
+		}
+		return fmt.Sprintf("  %s %-28s %s %s", colors.Cyan(mark), t.name, t.text, colors.Gray(elapsed.String()))
+	case Done:
+		return fmt.Sprintf("  %s %-28s %s %s", colors.Green("✔"), t.name, t.text, colors.Gray(elapsed.String()))
+	case Skipped:
+		return fmt.Sprintf("  %s %-28s %s", colors.Gray("-"), t.name, t.text)
+	default:
+		return fmt.Sprintf("  %s %-28s %s", colors.Red("✘"), t.name, colors.Red(t.text))
+	}
+}
+
+// isTerminal 判断 f 是否为终端
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -96,6 +96,14 @@ func GetCgearInstalledPath() string {
 	return cgearInstalled
 }
 
+// GetCgearCachePath 获取二进制缓存的位置, 可以用 CGEAR_CACHE 指定目录或 HTTP 地址
+func GetCgearCachePath() string {
+	if cgearCache := os.Getenv("CGEAR_CACHE"); cgearCache != "" {
+		return cgearCache
+	}
+	return filepath.Join(GetCgearHomePath(), "cache")
+}
+
 // 检查当前路径是否为 Cgear tool 生成的 C++ 项目
 func IsCgearProject(thePath string) bool {
 	cmakeListsFiles := []string{